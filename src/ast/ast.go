@@ -16,8 +16,31 @@ import (
 
 type HasValue interface{}
 
+// Node is embedded in every node to remember where it came from.
+type Node struct {
+	Span lexer.Span
+}
+
+// ast.Spanned
+func (n Node) Location() lexer.Span {
+	return n.Span
+}
+
+type Spanned interface {
+	Location() lexer.Span
+}
+
+// the span of any node, or an invalid span for things that are not nodes
+func SpanOf(node interface{}) lexer.Span {
+	if spanned, ok := node.(Spanned); ok {
+		return spanned.Location()
+	}
+	return lexer.Span{}
+}
+
 type NumberLiteral struct {
 	HasValue
+	Node
 	Value float64
 }
 
@@ -31,6 +54,7 @@ func NewNumberLiteral(tok *lexer.TokItem) *NumberLiteral {
 		f, _ = l.(*big.Float).Float64()
 	}
 	nl.Value = f
+	nl.Span = tok.TokSpan
 	return &nl
 }
 
@@ -49,6 +73,7 @@ func (nl NumberLiteral) Evaluate() *evaluator.NicerValue {
 
 type BooleanLiteral struct {
 	HasValue
+	Node
 	Value bool
 }
 
@@ -57,7 +82,9 @@ func NewBooleanLiteral(tok *lexer.TokItem) *BooleanLiteral {
 	if err != nil {
 		b = false
 	}
-	return &BooleanLiteral{Value: b}
+	bl := &BooleanLiteral{Value: b}
+	bl.Span = tok.TokSpan
+	return bl
 }
 
 // ast.Visitable
@@ -75,11 +102,14 @@ func (bl BooleanLiteral) Evaluate() *evaluator.NicerValue {
 
 type StringLiteral struct {
 	HasValue
+	Node
 	Value string
 }
 
 func NewStringLiteral(tok *lexer.TokItem) *StringLiteral {
-	return &StringLiteral{Value: tok.TokValue.(string)}
+	sl := &StringLiteral{Value: tok.TokValue.(string)}
+	sl.Span = tok.TokSpan
+	return sl
 }
 
 // ast.Visitable
@@ -95,14 +125,42 @@ func (sl StringLiteral) Evaluate() *evaluator.NicerValue {
 	}
 }
 
+// `nothing`, which takes the default value of whatever type it is used as
+type NothingLiteral struct {
+	HasValue
+	Node
+}
+
+func NewNothingLiteral(tok *lexer.TokItem) *NothingLiteral {
+	nl := &NothingLiteral{}
+	nl.Span = tok.TokSpan
+	return nl
+}
+
+// ast.Visitable
+func (nl NothingLiteral) Accept(v Visitor) {
+	v.VisitNothingLiteral(v, &nl)
+}
+
+// evaluator.Evaluable
+func (nl NothingLiteral) Evaluate() *evaluator.NicerValue {
+	return evaluator.NewNothing()
+}
+
 type Identifier struct {
 	HasValue
+	Node
 	Name string
+	// what the name refers to, and how many scopes out from here it was
+	// declared; Binding is nil until Resolve works it out
+	Binding *Binding
+	Depth   int
 }
 
 func NewIdentifier(tok *lexer.TokItem) *Identifier {
 	ident := &Identifier{}
 	ident.Name = tok.TokValue.(string)
+	ident.Span = tok.TokSpan
 	return ident
 }
 
@@ -111,17 +169,25 @@ func (id Identifier) Accept(v Visitor) {
 	v.VisitIdentifier(v, &id)
 }
 
+// `do Name to A, B, and C`, which is also a statement of its own. Calling a
+// method names the struct it is called on, as in `do Name of Receiver to A`.
 type FunctionCall struct {
-	FuncName   *Identifier
-	FuncParams Visitable
+	HasValue
+	Node
+	FuncName *Identifier
+	Receiver Visitable // nil unless calling a method
+	Args     []Visitable
 }
 
-// TODO: Actual comma-separated list of expr
-func NewFunctionCall(name *lexer.TokItem, parameters Visitable) *FunctionCall {
-	return &FunctionCall{
-		FuncName:   NewIdentifier(name),
-		FuncParams: parameters,
+// last is the last token of the call
+func NewFunctionCall(do *lexer.TokItem, name *Identifier, receiver Visitable, args []Visitable, last *lexer.TokItem) *FunctionCall {
+	fc := &FunctionCall{
+		FuncName: name,
+		Receiver: receiver,
+		Args:     args,
 	}
+	fc.Span = do.TokSpan.Join(name.Span).Join(last.TokSpan)
+	return fc
 }
 
 // ast.Visitable
@@ -129,8 +195,282 @@ func (fc FunctionCall) Accept(v Visitor) {
 	v.VisitFunctionCall(v, &fc)
 }
 
-// type CommaSeparatedList struct {
-// }
+// `Field of Object`, a field of a struct
+type FieldExpr struct {
+	HasValue
+	Node
+	Field  *Identifier
+	Object Visitable
+}
+
+func NewFieldExpr(field *Identifier, object Visitable) *FieldExpr {
+	fe := &FieldExpr{Field: field, Object: object}
+	fe.Span = field.Span.Join(SpanOf(object))
+	return fe
+}
+
+// ast.Visitable
+func (fe FieldExpr) Accept(v Visitor) {
+	v.VisitFieldExpr(v, &fe)
+}
+
+// operators are kept as their source text, e.g. "+", ">=", "and"
+type BinaryExpr struct {
+	HasValue
+	Node
+	Left     Visitable
+	Operator string
+	Right    Visitable
+}
+
+func NewBinaryExpr(left Visitable, operator *lexer.TokItem, right Visitable) *BinaryExpr {
+	be := &BinaryExpr{
+		Left:     left,
+		Operator: operator.TokValue.(string),
+		Right:    right,
+	}
+	be.Span = SpanOf(left).Join(SpanOf(right))
+	return be
+}
+
+// ast.Visitable
+func (be BinaryExpr) Accept(v Visitor) {
+	v.VisitBinaryExpr(v, &be)
+}
+
+// unary minus and `not`
+type UnaryExpr struct {
+	HasValue
+	Node
+	Operator string
+	Operand  Visitable
+}
+
+func NewUnaryExpr(operator *lexer.TokItem, operand Visitable) *UnaryExpr {
+	ue := &UnaryExpr{
+		Operator: operator.TokValue.(string),
+		Operand:  operand,
+	}
+	ue.Span = operator.TokSpan.Join(SpanOf(operand))
+	return ue
+}
+
+// ast.Visitable
+func (ue UnaryExpr) Accept(v Visitor) {
+	v.VisitUnaryExpr(v, &ue)
+}
+
+// a chain of same-direction comparisons like `0 < N < Q`, which means
+// `0 < N and N < Q` except that N is only evaluated once
+type ComparisonChain struct {
+	HasValue
+	Node
+	Operands  []Visitable
+	Operators []string // Operators[i] sits between Operands[i] and Operands[i+1]
+}
+
+func NewComparisonChain(operands []Visitable, operators []string) *ComparisonChain {
+	cc := &ComparisonChain{
+		Operands:  operands,
+		Operators: operators,
+	}
+	cc.Span = SpanOf(operands[0]).Join(SpanOf(operands[len(operands)-1]))
+	return cc
+}
+
+// ast.Visitable
+func (cc ComparisonChain) Accept(v Visitor) {
+	v.VisitComparisonChain(v, &cc)
+}
+
+// `X is nothing` or `X is not nothing`, which tests whether X refers to
+// nothing rather than comparing it with a default value
+type NothingCheck struct {
+	HasValue
+	Node
+	Operand Visitable
+	Negated bool
+}
+
+// last is the `nothing` token
+func NewNothingCheck(operand Visitable, negated bool, last *lexer.TokItem) *NothingCheck {
+	nc := &NothingCheck{Operand: operand, Negated: negated}
+	nc.Span = SpanOf(operand).Join(last.TokSpan)
+	return nc
+}
+
+// ast.Visitable
+func (nc NothingCheck) Accept(v Visitor) {
+	v.VisitNothingCheck(v, &nc)
+}
+
+// an expression wrapped in parentheses
+type GroupedExpr struct {
+	HasValue
+	Node
+	Inner Visitable
+}
+
+func NewGroupedExpr(lparen *lexer.TokItem, inner Visitable, rparen *lexer.TokItem) *GroupedExpr {
+	ge := &GroupedExpr{Inner: inner}
+	ge.Span = lparen.TokSpan.Join(rparen.TokSpan)
+	return ge
+}
+
+// ast.Visitable
+func (ge GroupedExpr) Accept(v Visitor) {
+	v.VisitGroupedExpr(v, &ge)
+}
+
+// `containing A, B, and C, done`. Range elements are spliced into the list
+// rather than nested in it.
+type ListLiteral struct {
+	HasValue
+	Node
+	Elements []Visitable
+}
+
+func NewListLiteral(containing *lexer.TokItem, elements []Visitable, done *lexer.TokItem) *ListLiteral {
+	ll := &ListLiteral{Elements: elements}
+	ll.Span = containing.TokSpan.Join(done.TokSpan)
+	return ll
+}
+
+// ast.Visitable
+func (ll ListLiteral) Accept(v Visitor) {
+	v.VisitListLiteral(v, &ll)
+}
+
+// `containing K as V, ..., and K as V, done`; Keys[i] maps to Values[i]
+type MapLiteral struct {
+	HasValue
+	Node
+	Keys   []Visitable
+	Values []Visitable
+}
+
+func NewMapLiteral(containing *lexer.TokItem, keys, values []Visitable, done *lexer.TokItem) *MapLiteral {
+	ml := &MapLiteral{Keys: keys, Values: values}
+	ml.Span = containing.TokSpan.Join(done.TokSpan)
+	return ml
+}
+
+// ast.Visitable
+func (ml MapLiteral) Accept(v Visitor) {
+	v.VisitMapLiteral(v, &ml)
+}
+
+// `where F is A, ..., G is B done`, a new struct of the type it is declared
+// with or written after; Fields[i] is given Values[i]
+type StructLiteral struct {
+	HasValue
+	Node
+	Type   TypeExpr
+	Fields []*Identifier
+	Values []Visitable
+}
+
+func NewStructLiteral(where *lexer.TokItem, typeName TypeExpr, fields []*Identifier, values []Visitable, done *lexer.TokItem) *StructLiteral {
+	sl := &StructLiteral{Type: typeName, Fields: fields, Values: values}
+	sl.Span = where.TokSpan.Join(done.TokSpan)
+	return sl
+}
+
+// ast.Visitable
+func (sl StructLiteral) Accept(v Visitor) {
+	v.VisitStructLiteral(v, &sl)
+}
+
+// `[every Step-th] from Start to End [of Collection]`; FromStart and ToEnd
+// are set for the `start` and `end` keywords, leaving Start or End nil.
+// `start of X` names the collection for `start` alone, otherwise both bounds
+// use Of.
+type RangeLiteral struct {
+	HasValue
+	Node
+	Step      Visitable // nil without `every`
+	Start     Visitable
+	End       Visitable
+	FromStart bool
+	ToEnd     bool
+	StartOf   Visitable // nil unless `start of X`
+	Of        Visitable // nil unless `end of X` or `... of X`
+}
+
+func NewRangeLiteral(first *lexer.TokItem, step, start, startOf, end, of Visitable, last *lexer.TokItem) *RangeLiteral {
+	rl := &RangeLiteral{
+		Step:      step,
+		Start:     start,
+		End:       end,
+		FromStart: start == nil,
+		ToEnd:     end == nil,
+		StartOf:   startOf,
+		Of:        of,
+	}
+	rl.Span = first.TokSpan.Join(SpanOf(start)).Join(SpanOf(end)).Join(last.TokSpan)
+	return rl
+}
+
+// ast.Visitable
+func (rl RangeLiteral) Accept(v Visitor) {
+	v.VisitRangeLiteral(v, &rl)
+}
+
+// `N-th from Collection`, or `start`/`end from Collection` for the first and
+// last element, leaving Index nil
+type IndexExpr struct {
+	HasValue
+	Node
+	Index      Visitable
+	AtStart    bool
+	AtEnd      bool
+	Collection Visitable
+}
+
+// keyword is the `start` or `end` token standing in for a nil index
+func NewIndexExpr(index Visitable, keyword *lexer.TokItem, collection Visitable) *IndexExpr {
+	ie := &IndexExpr{
+		Index:      index,
+		Collection: collection,
+	}
+	span := SpanOf(index)
+	if index == nil {
+		ie.AtStart = keyword.TokType == lexer.KW_Start
+		ie.AtEnd = keyword.TokType == lexer.KW_End
+		span = keyword.TokSpan
+	}
+	ie.Span = span.Join(SpanOf(collection))
+	return ie
+}
+
+// ast.Visitable
+func (ie IndexExpr) Accept(v Visitor) {
+	v.VisitIndexExpr(v, &ie)
+}
+
+// the elements of Collection at each index of Range, like `0-th to 5-th from
+// Values` or `every 2-th from start to end from Values`. `start` and `end`
+// refer to Collection unless the range names another.
+type SliceExpr struct {
+	HasValue
+	Node
+	Range      *RangeLiteral
+	Collection Visitable
+}
+
+func NewSliceExpr(rangeLiteral *RangeLiteral, collection Visitable) *SliceExpr {
+	se := &SliceExpr{
+		Range:      rangeLiteral,
+		Collection: collection,
+	}
+	se.Span = rangeLiteral.Span.Join(SpanOf(collection))
+	return se
+}
+
+// ast.Visitable
+func (se SliceExpr) Accept(v Visitor) {
+	v.VisitSliceExpr(v, &se)
+}
 
 type Statement interface{}
 
@@ -140,6 +480,7 @@ type Declaration interface {
 
 type VarAssignment struct {
 	Statement
+	Node
 	Name  *Identifier
 	Value Visitable
 }
@@ -148,6 +489,7 @@ func NewVarAssignment(varName *Identifier, val Visitable) *VarAssignment {
 	varass := new(VarAssignment)
 	varass.Name = varName
 	varass.Value = val
+	varass.Span = varName.Span.Join(SpanOf(val))
 	return varass
 }
 
@@ -156,10 +498,51 @@ func (va VarAssignment) Accept(v Visitor) {
 	v.VisitVarAssignment(v, &va)
 }
 
+// `Index-th of Collection is Value`, where a nil Value is `nothing`
+type IndexAssignment struct {
+	Statement
+	Node
+	Target *IndexExpr
+	Value  Visitable
+}
+
+// last is the last token of the value
+func NewIndexAssignment(target *IndexExpr, val Visitable, last *lexer.TokItem) *IndexAssignment {
+	ia := &IndexAssignment{Target: target, Value: val}
+	ia.Span = target.Span.Join(last.TokSpan)
+	return ia
+}
+
+// ast.Visitable
+func (ia IndexAssignment) Accept(v Visitor) {
+	v.VisitIndexAssignment(v, &ia)
+}
+
+// `Field of Object is Value`, where a nil Value is `nothing`
+type FieldAssignment struct {
+	Statement
+	Node
+	Target *FieldExpr
+	Value  Visitable
+}
+
+// last is the last token of the value
+func NewFieldAssignment(target *FieldExpr, val Visitable, last *lexer.TokItem) *FieldAssignment {
+	fa := &FieldAssignment{Target: target, Value: val}
+	fa.Span = target.Span.Join(last.TokSpan)
+	return fa
+}
+
+// ast.Visitable
+func (fa FieldAssignment) Accept(v Visitor) {
+	v.VisitFieldAssignment(v, &fa)
+}
+
 type VarDecl struct {
 	Declaration
+	Node
 	VarName  *Identifier
-	TypeName *Identifier
+	TypeName TypeExpr
 	Value    Visitable // TODO: Expr
 }
 
@@ -167,27 +550,31 @@ type VarDecl struct {
 func (vd VarDecl) Accept(v Visitor) {
 	v.VisitVarDecl(v, &vd)
 }
-func NewVarDecl(name, typeName *Identifier, value Visitable) *VarDecl {
+func NewVarDecl(keyword *lexer.TokItem, name *Identifier, typeName TypeExpr, value Visitable) *VarDecl {
 	vardecl := new(VarDecl)
 	vardecl.VarName = name
 	vardecl.TypeName = typeName
 	vardecl.Value = value
+	vardecl.Span = keyword.TokSpan.Join(typeName.Location()).Join(SpanOf(value))
 	return vardecl
 }
 
 type ConstDecl struct {
 	Declaration
+	Node
 	ConstName *Identifier
-	TypeName  *Identifier
+	TypeName  TypeExpr
 	Value     Visitable // TODO: Expr
 }
 
-func NewConstDecl(name, typeName *Identifier, value Visitable) *ConstDecl {
-	return &ConstDecl{
+func NewConstDecl(keyword *lexer.TokItem, name *Identifier, typeName TypeExpr, value Visitable) *ConstDecl {
+	constdecl := &ConstDecl{
 		ConstName: name,
 		TypeName:  typeName,
 		Value:     value,
 	}
+	constdecl.Span = keyword.TokSpan.Join(typeName.Location()).Join(SpanOf(value))
+	return constdecl
 }
 
 // ast.Visitable
@@ -196,7 +583,12 @@ func (cd ConstDecl) Accept(v Visitor) {
 }
 
 type Program struct {
+	Node
 	Statements []Statement
+	// how many names are declared at the top level, once resolved
+	Slots int
+	// the types declared in the program, once resolved
+	Types TypeTable
 }
 
 func NewProgram() *Program {
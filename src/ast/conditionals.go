@@ -0,0 +1,36 @@
+package ast
+
+import "nicer-syntax/lexer"
+
+// Block is the body of a conditional or loop. The names declared in it go in
+// a scope of its own, inside the one the block appears in.
+type Block struct {
+	Statements []Statement
+	// how many names the block declares, once resolved
+	Slots int
+}
+
+func NewBlock(stmts []Statement) *Block {
+	return &Block{Statements: stmts}
+}
+
+// `if Condition, then ... done`. A chained `else if` is an IfStmt on its own
+// in the Else block.
+type IfStmt struct {
+	Statement
+	Node
+	Condition Visitable
+	Then      *Block
+	Else      *Block // nil without an `else`
+}
+
+func NewIfStmt(keyword *lexer.TokItem, condition Visitable, then, otherwise *Block, last *lexer.TokItem) *IfStmt {
+	is := &IfStmt{Condition: condition, Then: then, Else: otherwise}
+	is.Span = keyword.TokSpan.Join(last.TokSpan)
+	return is
+}
+
+// ast.Visitable
+func (is IfStmt) Accept(v Visitor) {
+	v.VisitIfStmt(v, &is)
+}
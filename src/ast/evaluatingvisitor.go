@@ -19,17 +19,98 @@ func (vs *ValueStack) Pop() *evaluator.NicerValue {
 
 type EvaluatingVisitor struct {
 	DefaultVisitor
-	*ValueStack // stack of values
-	IdentValue  map[string]*evaluator.NicerValue
+	ValueStack // stack of values
+	// the values of the names declared in the innermost scope being run, which
+	// can see the values of the scopes around it
+	scope *evaluator.Scope
+	// the program's own scope, which methods are run inside
+	global *evaluator.Scope
+	// the types the program declares
+	types TypeTable
+	// what the type parameters of the generic code being run stand for
+	bindings map[string]evaluator.NicerType
+	// the first runtime error hit; evaluation stops once this is set
+	Err *evaluator.RuntimeError
+	// set by `return` until the call it returns from finishes, along with the
+	// value it returns
+	returning bool
+	returned  *evaluator.NicerValue
+	// set by `stop` and `skip` until the loop they are in sees them
+	stopping, skipping bool
+	// how many calls are running inside one another
+	calls int
 }
 
+// how many calls can run inside one another before a program is assumed to
+// recurse forever
+const maxCalls = 10000
+
 func NewEvaluatingVisitor() *EvaluatingVisitor {
 	ev := new(EvaluatingVisitor)
-	ev.ValueStack = new(ValueStack)
-	ev.IdentValue = make(map[string]*evaluator.NicerValue)
+	ev.scope = evaluator.NewScope(nil, 0)
 	return ev
 }
 
+// Lookup returns the value of the innermost declaration of name that the
+// scope being run can see, or nothing.
+func (v *EvaluatingVisitor) Lookup(name string) *evaluator.NicerValue {
+	depth, slot, ok := v.scope.Lookup(name)
+	if !ok {
+		return nil
+	}
+	return v.scope.Get(depth, slot)
+}
+
+// where name keeps its value. Names that were never resolved, like those in
+// an expression evaluated on its own, are looked up by name.
+func (v *EvaluatingVisitor) locate(name *Identifier) (int, int, bool) {
+	if name.Binding != nil {
+		return name.Depth, name.Binding.Slot, true
+	}
+	return v.scope.Lookup(name.Name)
+}
+
+// the type t names in the code being run, where each type parameter stands
+// for a type of its own
+func (v *EvaluatingVisitor) instance(t TypeExpr) evaluator.NicerType {
+	return Substitute(t, v.bindings)
+}
+
+// declare name with a value of its declared type. Constants hold lists and
+// maps that cannot change.
+func (v *EvaluatingVisitor) declareAs(name *Identifier, typeName TypeExpr, val *evaluator.NicerValue, constant bool, decl interface{}) {
+	if v.Err != nil {
+		return
+	}
+	t := v.instance(typeName)
+	val, ok := conform(t, val)
+	if !ok {
+		v.raise(&evaluator.RuntimeError{
+			Reason:       fmt.Sprintf("Cannot declare %s with %s", t, val.TypeName()),
+			VariableName: name.Name,
+		}, decl)
+		return
+	}
+	if constant {
+		val = evaluator.Constant(val)
+	}
+	slot := -1
+	if name.Binding != nil {
+		slot = name.Binding.Slot
+	}
+	v.scope.Declare(slot, name.Name, val, constant)
+}
+
+// record a runtime error, keeping only the first one.
+func (v *EvaluatingVisitor) raise(err *evaluator.RuntimeError, node interface{}) {
+	if v.Err != nil {
+		return
+	}
+	err.Node = node
+	err.Span = SpanOf(node)
+	v.Err = err
+}
+
 func (v *EvaluatingVisitor) Visit(vis Visitable) {
 	switch vis := vis.(type) {
 	case *NumberLiteral:
@@ -38,8 +119,38 @@ func (v *EvaluatingVisitor) Visit(vis Visitable) {
 		v.VisitBooleanLiteral(v, vis)
 	case *StringLiteral:
 		v.VisitStringLiteral(v, vis)
+	case *NothingLiteral:
+		v.VisitNothingLiteral(v, vis)
 	case *Identifier:
 		v.VisitIdentifier(v, vis)
+	case *FunctionCall:
+		v.VisitFunctionCall(v, vis)
+	case *FunctionLiteral:
+		v.VisitFunctionLiteral(v, vis)
+	case *FieldExpr:
+		v.VisitFieldExpr(v, vis)
+	case *BinaryExpr:
+		v.VisitBinaryExpr(v, vis)
+	case *UnaryExpr:
+		v.VisitUnaryExpr(v, vis)
+	case *ComparisonChain:
+		v.VisitComparisonChain(v, vis)
+	case *NothingCheck:
+		v.VisitNothingCheck(v, vis)
+	case *GroupedExpr:
+		v.VisitGroupedExpr(v, vis)
+	case *ListLiteral:
+		v.VisitListLiteral(v, vis)
+	case *MapLiteral:
+		v.VisitMapLiteral(v, vis)
+	case *StructLiteral:
+		v.VisitStructLiteral(v, vis)
+	case *RangeLiteral:
+		v.VisitRangeLiteral(v, vis)
+	case *IndexExpr:
+		v.VisitIndexExpr(v, vis)
+	case *SliceExpr:
+		v.VisitSliceExpr(v, vis)
 	default:
 		v.ValueStack.Push(nil)
 	}
@@ -53,43 +164,680 @@ func (v *EvaluatingVisitor) VisitBooleanLiteral(_ Visitor, bl *BooleanLiteral) {
 func (v *EvaluatingVisitor) VisitStringLiteral(_ Visitor, sl *StringLiteral) {
 	v.ValueStack.Push(sl.Evaluate())
 }
+func (v *EvaluatingVisitor) VisitNothingLiteral(_ Visitor, nl *NothingLiteral) {
+	v.ValueStack.Push(nl.Evaluate())
+}
 func (v *EvaluatingVisitor) VisitIdentifier(_ Visitor, id *Identifier) {
-	val, ok := v.IdentValue[id.Name]
-	if ok {
-		v.ValueStack.Push(val)
+	if depth, slot, ok := v.locate(id); ok {
+		v.ValueStack.Push(v.scope.Get(depth, slot))
 	} else {
-		// TODO: `nothing` values
-		// v.ValueStack.Push(&evaluator.NicerValue{
-		// 	Type:  evaluator.NT_number,
-		// 	Value: 0.0,
-		// })
+		v.raise(&evaluator.RuntimeError{
+			Reason:       "Use of undeclared identifier",
+			VariableName: id.Name,
+		}, id)
+		v.ValueStack.Push(nil)
 	}
 }
+
+// the arguments are evaluated before the function, or the struct a method
+// is called on. Built-in functions are called by name, unless a declaration
+// hides them.
 func (v *EvaluatingVisitor) VisitFunctionCall(_ Visitor, fc *FunctionCall) {
-	v.Visit(fc.FuncParams) // evaluate arguments first
-	function, ok := evaluator.BuiltInFunctions[fc.FuncName.Name]
-	if ok {
+	args := make([]*evaluator.NicerValue, 0, len(fc.Args))
+	for _, arg := range fc.Args {
+		v.Visit(arg)
+		args = append(args, v.ValueStack.Pop())
+		if v.Err != nil {
+			v.ValueStack.Push(nil)
+			return
+		}
+	}
+	if fc.Receiver != nil {
+		v.Visit(fc.Receiver)
+		method := v.method(fc, v.ValueStack.Pop())
+		if method == nil {
+			v.ValueStack.Push(nil)
+			return
+		}
+		v.ValueStack.Push(v.call(fc, method, args))
+		return
+	}
+	if builtin, ok := evaluator.BuiltInFunctions[fc.FuncName.Name]; ok && fc.FuncName.Binding == nil {
+		val, err := builtin(args)
+		if err != nil {
+			err.VariableName = fc.FuncName.Name
+			v.raise(err, fc)
+		} else if val == nil {
+			val = evaluator.NewNothing()
+		}
+		v.ValueStack.Push(val)
+		return
+	}
+	v.VisitIdentifier(v, fc.FuncName)
+	callee := v.ValueStack.Pop()
+	if v.Err != nil {
+		v.ValueStack.Push(nil)
+		return
+	}
+	function, ok := callee.Value.(*FunctionValue)
+	if !ok {
+		what := callee.TypeName()
+		if callee.IsNothing() {
+			what = "nothing"
+		}
+		v.raise(&evaluator.RuntimeError{
+			Reason:       fmt.Sprintf("Cannot call %s", what),
+			VariableName: fc.FuncName.Name,
+		}, fc)
+		v.ValueStack.Push(nil)
+		return
+	}
+	v.ValueStack.Push(v.call(fc, function, args))
+}
+
+// the method fc calls on receiver, or nil after raising an error
+func (v *EvaluatingVisitor) method(fc *FunctionCall, receiver *evaluator.NicerValue) *FunctionValue {
+	if v.Err != nil {
+		return nil
+	}
+	var method *FunctionDecl
+	if st, _, ok := v.types.Struct(evaluator.NicerType(receiver.TypeName())); ok {
+		method = st.Method(fc.FuncName.Name)
+	}
+	switch {
+	case method == nil:
+		v.raise(&evaluator.RuntimeError{
+			Reason:       fmt.Sprintf("%s has no such method", receiver.TypeName()),
+			VariableName: fc.FuncName.Name,
+		}, fc)
+	case receiver.IsNothing():
+		v.raise(&evaluator.RuntimeError{Reason: "Cannot call a method of nothing", VariableName: fc.FuncName.Name}, fc)
+	default:
+		return &FunctionValue{Literal: method.Function, Scope: v.global, This: receiver}
+	}
+	return nil
+}
+
+// run function in a scope of its own, inside the one it was defined in, with
+// its parameters declared as args, and `this` as the struct a method was
+// called on. A function that returns nothing gives nothing; the result is nil
+// after raising an error.
+func (v *EvaluatingVisitor) call(fc *FunctionCall, function *FunctionValue, args []*evaluator.NicerValue) *evaluator.NicerValue {
+	fl, name := function.Literal, fc.FuncName.Name
+	if len(args) != len(fl.Params) {
+		v.raise(&evaluator.RuntimeError{
+			Reason:       fmt.Sprintf("Expected %d arguments, got %d", len(fl.Params), len(args)),
+			VariableName: name,
+		}, fc)
+		return nil
+	}
+	if v.calls >= maxCalls {
+		v.raise(&evaluator.RuntimeError{Reason: "Too many calls inside one another", VariableName: name}, fc)
+		return nil
+	}
+	scope := evaluator.NewScope(function.Scope, fl.Slots)
+	bindings := v.callBindings(function, args)
+	for i, param := range fl.Params {
+		t := Substitute(param.TypeName, bindings)
+		arg, ok := conform(t, args[i])
+		if !ok {
+			v.raise(&evaluator.RuntimeError{
+				Reason:       fmt.Sprintf("Cannot pass %s as %s", args[i].TypeName(), t),
+				VariableName: param.Name.Name,
+			}, fc)
+			return nil
+		}
+		if param.Constant {
+			arg = evaluator.Constant(arg)
+		}
+		// parameters take the first slots
+		scope.Declare(i, param.Name.Name, arg, param.Constant)
+	}
+	if function.This != nil {
+		scope.Declare(len(fl.Params), "this", function.This, true)
+	}
+	caller, callerBindings := v.scope, v.bindings
+	v.scope, v.bindings = scope, bindings
+	v.calls++
+	v.runBlock(fl.Body)
+	v.calls--
+	v.scope, v.bindings = caller, callerBindings
+	returned, returning := v.returned, v.returning
+	v.returned, v.returning = nil, false
+	if v.Err != nil {
+		return nil
+	}
+	if fl.Returns == nil {
+		if returned != nil {
+			v.raise(&evaluator.RuntimeError{Reason: fmt.Sprintf("Cannot return %s from a function that returns nothing", returned.TypeName()), VariableName: name}, fc)
+			return nil
+		}
+		return evaluator.NewNothing()
+	}
+	if !returning || returned == nil {
+		v.raise(&evaluator.RuntimeError{Reason: fmt.Sprintf("Finished without returning %s", Substitute(fl.Returns, bindings)), VariableName: name}, fc)
+		return nil
+	}
+	returns := Substitute(fl.Returns, bindings)
+	val, ok := conform(returns, returned)
+	if !ok {
+		v.raise(&evaluator.RuntimeError{
+			Reason:       fmt.Sprintf("Cannot return %s, expected %s", returned.TypeName(), returns),
+			VariableName: name,
+		}, fc)
+		return nil
+	}
+	return val
+}
+
+// what the type parameters stand for while function runs: those of the code
+// it was defined in, those of the struct a method is called on, and those a
+// generic function works out from the types of its arguments
+func (v *EvaluatingVisitor) callBindings(function *FunctionValue, args []*evaluator.NicerValue) map[string]evaluator.NicerType {
+	bindings := make(map[string]evaluator.NicerType)
+	for param, t := range function.Bindings {
+		bindings[param] = t
+	}
+	if function.This != nil {
+		if _, these, ok := v.types.Struct(function.This.Type); ok {
+			for param, t := range these {
+				bindings[param] = t
+			}
+		}
+	}
+	fl := function.Literal
+	for _, param := range fl.TypeParams {
+		delete(bindings, param.Name)
+	}
+	for i, param := range fl.Params {
+		Bind(param.TypeName, args[i].Type, fl.TypeParams, bindings)
+	}
+	return bindings
+}
+
+// a function evaluates to itself, along with the scope it can see
+func (v *EvaluatingVisitor) VisitFunctionLiteral(_ Visitor, fl *FunctionLiteral) {
+	v.ValueStack.Push(&evaluator.NicerValue{
+		Type:  v.instance(fl.Type),
+		Value: &FunctionValue{Literal: fl, Scope: v.scope, Bindings: v.bindings},
+	})
+}
+
+func (v *EvaluatingVisitor) VisitFieldExpr(_ Visitor, fe *FieldExpr) {
+	object := v.structOf(fe, "get")
+	if object == nil {
+		v.ValueStack.Push(nil)
+		return
+	}
+	s, _ := object.AsStruct()
+	val, _ := s.Get(fe.Field.Name)
+	v.ValueStack.Push(val)
+}
+
+// the struct fe is a field of, or nil after raising an error. A reference to
+// nothing has no fields to get or set, so it must be given a struct first,
+// through a literal or another struct.
+func (v *EvaluatingVisitor) structOf(fe *FieldExpr, action string) *evaluator.NicerValue {
+	v.Visit(fe.Object)
+	object := v.ValueStack.Pop()
+	if v.Err != nil {
+		return nil
+	}
+	st, _, isStruct := v.types.Struct(evaluator.NicerType(object.TypeName()))
+	if !isStruct {
+		v.raise(&evaluator.RuntimeError{Reason: fmt.Sprintf("Cannot get a field of %s", object.TypeName()), VariableName: fe.Field.Name}, fe)
+		return nil
+	}
+	if _, _, ok := st.Field(fe.Field.Name); !ok {
+		v.raise(&evaluator.RuntimeError{Reason: fmt.Sprintf("%s has no such field", object.TypeName()), VariableName: fe.Field.Name}, fe)
+		return nil
+	}
+	if object.IsNothing() {
+		v.raise(&evaluator.RuntimeError{Reason: fmt.Sprintf("Cannot %s a field of nothing", action), VariableName: fe.Field.Name}, fe)
+		return nil
+	}
+	return object
+}
+
+// a struct of type t, with every field holding the default value of its type
+// once its type parameters are bound
+func newStruct(t evaluator.NicerType, st *StructType, bindings map[string]evaluator.NicerType) *evaluator.NicerValue {
+	val := evaluator.NewStruct(t)
+	s, _ := val.AsStruct()
+	for _, d := range st.Fields {
+		name, typeName, constant := field(d)
+		def := evaluator.DefaultValue(Substitute(typeName, bindings))
+		if constant {
+			def = evaluator.Constant(def)
+		}
+		s.Declare(name.Name, def, constant)
+	}
+	return val
+}
+
+// assign val to the field fe names, which must be able to hold it
+func (v *EvaluatingVisitor) setField(fe *FieldExpr, val *evaluator.NicerValue, node interface{}) {
+	object := v.structOf(fe, "set")
+	if object == nil {
+		return
+	}
+	st, bindings, _ := v.types.Struct(object.Type)
+	typeName, _, _ := st.Field(fe.Field.Name)
+	t := Substitute(typeName, bindings)
+	val, ok := conform(t, val)
+	if !ok {
+		v.raise(&evaluator.RuntimeError{
+			Reason:       fmt.Sprintf("Cannot assign %s to %s", val.TypeName(), t),
+			VariableName: fe.Field.Name,
+		}, node)
+		return
+	}
+	s, _ := object.AsStruct()
+	if err := s.Set(fe.Field.Name, val); err != nil {
+		v.raise(err, node)
+	}
+}
+
+func (v *EvaluatingVisitor) VisitBinaryExpr(_ Visitor, be *BinaryExpr) {
+	v.Visit(be.Left)
+	left := v.ValueStack.Pop()
+	if v.Err != nil {
+		v.ValueStack.Push(nil)
+		return
+	}
+	if be.Operator == "and" || be.Operator == "or" {
+		v.shortCircuit(be, left)
+		return
+	}
+	v.Visit(be.Right)
+	right := v.ValueStack.Pop()
+	if v.Err != nil {
+		v.ValueStack.Push(nil)
+		return
+	}
+	val, err := evaluator.ApplyBinary(be.Operator, left, right)
+	if err != nil {
+		v.raise(err, be)
+	}
+	v.ValueStack.Push(val)
+}
+
+// `and` and `or` only evaluate their right side when the left side does not
+// already decide the result.
+func (v *EvaluatingVisitor) shortCircuit(be *BinaryExpr, left *evaluator.NicerValue) {
+	l, ok := left.AsBoolean()
+	if !ok {
+		v.raise(&evaluator.RuntimeError{Reason: fmt.Sprintf("Cannot apply `%s` to %s", be.Operator, left.TypeName())}, be)
+		v.ValueStack.Push(nil)
+		return
+	}
+	if (be.Operator == "and" && !l) || (be.Operator == "or" && l) {
+		v.ValueStack.Push(evaluator.NewBoolean(l))
+		return
+	}
+	v.Visit(be.Right)
+	right := v.ValueStack.Pop()
+	if v.Err != nil {
+		v.ValueStack.Push(nil)
+		return
+	}
+	r, ok := right.AsBoolean()
+	if !ok {
+		v.raise(&evaluator.RuntimeError{Reason: fmt.Sprintf("Cannot apply `%s` to %s", be.Operator, right.TypeName())}, be)
+		v.ValueStack.Push(nil)
+		return
+	}
+	v.ValueStack.Push(evaluator.NewBoolean(r))
+}
+
+func (v *EvaluatingVisitor) VisitUnaryExpr(_ Visitor, ue *UnaryExpr) {
+	v.Visit(ue.Operand)
+	operand := v.ValueStack.Pop()
+	if v.Err != nil {
+		v.ValueStack.Push(nil)
+		return
+	}
+	val, err := evaluator.ApplyUnary(ue.Operator, operand)
+	if err != nil {
+		v.raise(err, ue)
+	}
+	v.ValueStack.Push(val)
+}
+
+// operands are evaluated left to right, each exactly once, stopping at the
+// first comparison that fails
+func (v *EvaluatingVisitor) VisitComparisonChain(_ Visitor, cc *ComparisonChain) {
+	v.Visit(cc.Operands[0])
+	left := v.ValueStack.Pop()
+	for i, operator := range cc.Operators {
+		if v.Err != nil {
+			v.ValueStack.Push(nil)
+			return
+		}
+		v.Visit(cc.Operands[i+1])
+		right := v.ValueStack.Pop()
+		if v.Err != nil {
+			v.ValueStack.Push(nil)
+			return
+		}
+		result, err := evaluator.ApplyBinary(operator, left, right)
+		if err != nil {
+			v.raise(err, cc)
+			v.ValueStack.Push(nil)
+			return
+		}
+		if holds, _ := result.AsBoolean(); !holds {
+			v.ValueStack.Push(result)
+			return
+		}
+		left = right
+	}
+	v.ValueStack.Push(evaluator.NewBoolean(true))
+}
+
+// a default value like 0 or an empty list is not nothing, only `nothing`
+// itself and a struct reference that refers to nothing
+func (v *EvaluatingVisitor) VisitNothingCheck(_ Visitor, nc *NothingCheck) {
+	v.Visit(nc.Operand)
+	operand := v.ValueStack.Pop()
+	if v.Err != nil {
+		v.ValueStack.Push(nil)
+		return
+	}
+	v.ValueStack.Push(evaluator.NewBoolean(operand.IsNothing() != nc.Negated))
+}
+
+func (v *EvaluatingVisitor) VisitGroupedExpr(_ Visitor, ge *GroupedExpr) {
+	v.Visit(ge.Inner)
+}
+
+// every element must have the same type, which becomes the list's element
+// type. Ranges add each of their numbers.
+func (v *EvaluatingVisitor) VisitListLiteral(_ Visitor, ll *ListLiteral) {
+	var elementType evaluator.NicerType
+	elements := make([]*evaluator.NicerValue, 0, len(ll.Elements))
+	for _, element := range ll.Elements {
+		v.Visit(element)
 		val := v.ValueStack.Pop()
-		function([]evaluator.NicerValue{*val})
+		if v.Err != nil {
+			v.ValueStack.Push(nil)
+			return
+		}
+		values := []*evaluator.NicerValue{val}
+		if numbers, isRange := val.AsRange(); isRange {
+			var err *evaluator.RuntimeError
+			if values, err = numbers.Values(); err != nil {
+				v.raise(err, element)
+				v.ValueStack.Push(nil)
+				return
+			}
+		}
+		for _, val := range values {
+			if val == nil || val.Type == evaluator.NT_nothing {
+				v.raise(&evaluator.RuntimeError{Reason: "Lists cannot contain nothing"}, element)
+				v.ValueStack.Push(nil)
+				return
+			}
+			if elementType == "" {
+				elementType = val.Type
+			} else if val.Type != elementType {
+				v.raise(&evaluator.RuntimeError{
+					Reason: fmt.Sprintf("List elements must all have the same type, expected %s but got %s", elementType, val.TypeName()),
+				}, element)
+				v.ValueStack.Push(nil)
+				return
+			}
+			elements = append(elements, val)
+		}
+	}
+	v.ValueStack.Push(evaluator.NewList(elementType, elements))
+}
+
+// keys must all have one type and values another; a key may not repeat
+func (v *EvaluatingVisitor) VisitMapLiteral(_ Visitor, ml *MapLiteral) {
+	var m *evaluator.NicerValue
+	for i := range ml.Keys {
+		v.Visit(ml.Keys[i])
+		key := v.ValueStack.Pop()
+		v.Visit(ml.Values[i])
+		val := v.ValueStack.Pop()
+		if v.Err != nil {
+			v.ValueStack.Push(nil)
+			return
+		}
+		if key == nil || val == nil || key.Type == evaluator.NT_nothing || val.Type == evaluator.NT_nothing {
+			v.raise(&evaluator.RuntimeError{Reason: "Maps cannot contain nothing"}, ml.Keys[i])
+			v.ValueStack.Push(nil)
+			return
+		}
+		if m == nil {
+			m = evaluator.NewMap(key.Type, val.Type)
+		}
+		pairs, _ := m.AsMap()
+		if _, exists := pairs.Get(key); exists {
+			v.raise(&evaluator.RuntimeError{Reason: fmt.Sprintf("Duplicate key %v", key)}, ml.Keys[i])
+			v.ValueStack.Push(nil)
+			return
+		}
+		if err := evaluator.SetIndex(m, key, val); err != nil {
+			v.raise(err, ml.Keys[i])
+			v.ValueStack.Push(nil)
+			return
+		}
+	}
+	v.ValueStack.Push(m)
+}
+
+// fields the literal leaves out keep the default value of their type, and
+// only here can constant fields be given a value
+func (v *EvaluatingVisitor) VisitStructLiteral(_ Visitor, sl *StructLiteral) {
+	t := v.instance(sl.Type)
+	st, bindings, ok := v.types.Struct(t)
+	if !ok {
+		v.raise(&evaluator.RuntimeError{Reason: fmt.Sprintf("%s is not a struct", t)}, sl)
+		v.ValueStack.Push(nil)
+		return
+	}
+	val := newStruct(t, st, bindings)
+	s, _ := val.AsStruct()
+	for i, name := range sl.Fields {
+		typeName, constant, ok := st.Field(name.Name)
+		if !ok {
+			v.raise(&evaluator.RuntimeError{Reason: fmt.Sprintf("%s has no such field", t), VariableName: name.Name}, name)
+			v.ValueStack.Push(nil)
+			return
+		}
+		v.Visit(sl.Values[i])
+		fieldVal := v.ValueStack.Pop()
+		if v.Err != nil {
+			v.ValueStack.Push(nil)
+			return
+		}
+		fieldType := Substitute(typeName, bindings)
+		fieldVal, ok = conform(fieldType, fieldVal)
+		if !ok {
+			v.raise(&evaluator.RuntimeError{
+				Reason:       fmt.Sprintf("Cannot assign %s to %s", fieldVal.TypeName(), fieldType),
+				VariableName: name.Name,
+			}, sl.Values[i])
+			v.ValueStack.Push(nil)
+			return
+		}
+		if constant {
+			fieldVal = evaluator.Constant(fieldVal)
+		}
+		s.Declare(name.Name, fieldVal, constant)
+	}
+	v.ValueStack.Push(val)
+}
+
+func (v *EvaluatingVisitor) VisitRangeLiteral(_ Visitor, rl *RangeLiteral) {
+	v.ValueStack.Push(v.evaluateRange(rl, nil))
+}
+
+// the range's value, or nil after raising an error. `start` is always 0 and
+// `end` is the last index of the collection the range names, or else of
+// collection.
+func (v *EvaluatingVisitor) evaluateRange(rl *RangeLiteral, collection *evaluator.NicerValue) *evaluator.NicerValue {
+	startOf, endOf := collection, collection
+	if rl.Of != nil {
+		v.Visit(rl.Of)
+		endOf = v.ValueStack.Pop()
+		startOf = endOf
+	}
+	if rl.StartOf != nil {
+		v.Visit(rl.StartOf)
+		startOf = v.ValueStack.Pop()
+		if rl.Of == nil {
+			endOf = startOf
+		}
+	}
+	if v.Err != nil {
+		return nil
+	}
+	for _, named := range []*evaluator.NicerValue{startOf, endOf} {
+		if _, ok := named.Len(); named != nil && !ok {
+			v.raise(&evaluator.RuntimeError{Reason: fmt.Sprintf("Cannot range over %s", named.TypeName())}, rl)
+			return nil
+		}
+	}
+	start := 0.0
+	if !rl.FromStart {
+		var ok bool
+		if start, ok = v.evaluateNumber(rl.Start, "range start"); !ok {
+			return nil
+		}
+	}
+	var end float64
+	if !rl.ToEnd {
+		var ok bool
+		if end, ok = v.evaluateNumber(rl.End, "range end"); !ok {
+			return nil
+		}
+	} else {
+		if endOf == nil {
+			v.raise(&evaluator.RuntimeError{Reason: "`end` needs a collection, name one with `of`"}, rl)
+			return nil
+		}
+		length, _ := endOf.Len()
+		if length == 0 && rl.FromStart {
+			return evaluator.NewEmptyRange()
+		}
+		end = float64(length - 1)
+	}
+	step := 1.0
+	if rl.Step != nil {
+		var ok bool
+		if step, ok = v.evaluateNumber(rl.Step, "range step"); !ok {
+			return nil
+		}
+	}
+	r, err := evaluator.NewRange(start, end, step)
+	if err != nil {
+		v.raise(err, rl)
+		return nil
+	}
+	return r
+}
+
+func (v *EvaluatingVisitor) VisitIndexExpr(_ Visitor, ie *IndexExpr) {
+	collection, index := v.evaluateIndex(ie)
+	if v.Err != nil {
+		v.ValueStack.Push(nil)
+		return
+	}
+	val, err := evaluator.Index(collection, index)
+	if err != nil {
+		v.raise(err, ie)
+	}
+	v.ValueStack.Push(val)
+}
+
+// the collection and index of ie, with `start` and `end` worked out
+func (v *EvaluatingVisitor) evaluateIndex(ie *IndexExpr) (*evaluator.NicerValue, *evaluator.NicerValue) {
+	v.Visit(ie.Collection)
+	collection := v.ValueStack.Pop()
+	if v.Err != nil {
+		return nil, nil
+	}
+	switch {
+	case ie.AtStart:
+		return collection, evaluator.NewNumber(0)
+	case ie.AtEnd:
+		length, _ := collection.Len()
+		return collection, evaluator.NewNumber(float64(length - 1))
+	default:
+		v.Visit(ie.Index)
+		return collection, v.ValueStack.Pop()
+	}
+}
+
+func (v *EvaluatingVisitor) VisitSliceExpr(_ Visitor, se *SliceExpr) {
+	v.Visit(se.Collection)
+	collection := v.ValueStack.Pop()
+	if v.Err != nil {
+		v.ValueStack.Push(nil)
+		return
 	}
+	indices, _ := v.evaluateRange(se.Range, collection).AsRange()
+	if v.Err != nil {
+		v.ValueStack.Push(nil)
+		return
+	}
+	val, err := evaluator.Slice(collection, indices)
+	if err != nil {
+		v.raise(err, se)
+	}
+	v.ValueStack.Push(val)
+}
+
+// evaluate node, raising an error unless it is a number
+func (v *EvaluatingVisitor) evaluateNumber(node Visitable, what string) (float64, bool) {
+	v.Visit(node)
+	val := v.ValueStack.Pop()
+	if v.Err != nil {
+		return 0, false
+	}
+	n, ok := val.AsNumber()
+	if !ok {
+		v.raise(&evaluator.RuntimeError{Reason: fmt.Sprintf("The %s must be a number, got %s", what, val.TypeName())}, node)
+	}
+	return n, ok
 }
+
 func (v *EvaluatingVisitor) VisitConstDecl(_ Visitor, cd *ConstDecl) {
 	// assign to the variable map the name and value
 	v.Visit(cd.Value)
 	val := v.ValueStack.Pop()
-	v.IdentValue[cd.ConstName.Name] = val
+	v.declareAs(cd.ConstName, cd.TypeName, val, true, cd)
 }
 
 func (v *EvaluatingVisitor) VisitVarDecl(_ Visitor, cd *VarDecl) {
 	// assign to the variable map the name and value
 	v.Visit(cd.Value)
 	val := v.ValueStack.Pop()
-	v.IdentValue[cd.VarName.Name] = val
+	v.declareAs(cd.VarName, cd.TypeName, val, false, cd)
 }
 
+// the program runs in a scope of its own, once every name in it is resolved
 func (v *EvaluatingVisitor) VisitProgram(_ Visitor, p *Program) {
-	for _, stmt := range p.Statements {
+	if errs := Resolve(p); len(errs) > 0 {
+		v.raise(&evaluator.RuntimeError{Reason: errs[0].Reason, VariableName: errs[0].Name}, errs[0].Node)
+		return
+	}
+	v.types = p.Types
+	v.scope = evaluator.NewScope(nil, p.Slots)
+	v.global = v.scope
+	v.runBlock(p.Statements)
+}
+
+// run stmts in order, until one fails, returns, or stops or skips a loop
+func (v *EvaluatingVisitor) runBlock(stmts []Statement) {
+	for _, stmt := range stmts {
 		v.VisitStatement(v, stmt)
+		if v.Err != nil || v.returning || v.stopping || v.skipping {
+			return
+		}
 	}
 }
 
@@ -97,6 +845,26 @@ func (v *EvaluatingVisitor) VisitStatement(_ Visitor, s Statement) {
 	switch s := s.(type) {
 	case *VarAssignment:
 		v.VisitVarAssignment(v, s)
+	case *IndexAssignment:
+		v.VisitIndexAssignment(v, s)
+	case *FieldAssignment:
+		v.VisitFieldAssignment(v, s)
+	case *FunctionCall:
+		// called for what it does, so its value is dropped
+		v.VisitFunctionCall(v, s)
+		v.ValueStack.Pop()
+	case *ReturnStmt:
+		v.VisitReturnStmt(v, s)
+	case *IfStmt:
+		v.VisitIfStmt(v, s)
+	case *ForLoop:
+		v.VisitForLoop(v, s)
+	case *WhileLoop:
+		v.VisitWhileLoop(v, s)
+	case *StopStmt:
+		v.stopping = true
+	case *SkipStmt:
+		v.skipping = true
 	case Declaration:
 		v.VisitDeclaration(v, s)
 	}
@@ -108,23 +876,168 @@ func (v *EvaluatingVisitor) VisitDeclaration(_ Visitor, d Declaration) {
 		v.VisitVarDecl(v, d)
 	case *ConstDecl:
 		v.VisitConstDecl(v, d)
+	case *FunctionDecl:
+		v.VisitFunctionDecl(v, d)
+	case *TypeDecl:
+		v.VisitTypeDecl(v, d)
+	}
+}
+
+func (v *EvaluatingVisitor) VisitFunctionDecl(_ Visitor, fd *FunctionDecl) {
+	v.VisitFunctionLiteral(v, fd.Function)
+	v.declareAs(fd.Name, fd.Function.Type, v.ValueStack.Pop(), true, fd)
+}
+
+// types are gathered before the program runs, so there is nothing left to do
+func (v *EvaluatingVisitor) VisitTypeDecl(_ Visitor, td *TypeDecl) {}
+
+// the value is kept until the call returns
+func (v *EvaluatingVisitor) VisitReturnStmt(_ Visitor, rs *ReturnStmt) {
+	var val *evaluator.NicerValue
+	if rs.Value != nil {
+		v.Visit(rs.Value)
+		val = v.ValueStack.Pop()
+		if v.Err != nil {
+			return
+		}
+	}
+	v.returned, v.returning = val, true
+}
+
+// only the branch the condition picks is run, in a scope of its own
+func (v *EvaluatingVisitor) VisitIfStmt(_ Visitor, is *IfStmt) {
+	if v.condition(is.Condition) {
+		v.runScoped(is.Then)
+	} else if v.Err == nil && is.Else != nil {
+		v.runScoped(is.Else)
+	}
+}
+
+// the value of a condition, which must be boolean; false after an error
+func (v *EvaluatingVisitor) condition(node Visitable) bool {
+	v.Visit(node)
+	val := v.ValueStack.Pop()
+	if v.Err != nil {
+		return false
+	}
+	condition, ok := val.AsBoolean()
+	if !ok {
+		v.raise(&evaluator.RuntimeError{Reason: fmt.Sprintf("The condition must be boolean, got %s", val.TypeName())}, node)
+	}
+	return condition
+}
+
+// every time round gets a new scope with its own variable, so functions made
+// in the body each keep the value they were made with
+func (v *EvaluatingVisitor) VisitForLoop(_ Visitor, fl *ForLoop) {
+	v.Visit(fl.Source)
+	collection := v.ValueStack.Pop()
+	if v.Err != nil {
+		return
+	}
+	iterator, err := evaluator.NewIterator(collection)
+	if err != nil {
+		v.raise(err, fl.Source)
+		return
+	}
+	outer := v.scope
+	for val, ok := iterator.Next(); ok; val, ok = iterator.Next() {
+		v.scope = evaluator.NewScope(outer, fl.Body.Slots)
+		v.declareAs(fl.Variable.Name, fl.Variable.TypeName, val, fl.Variable.Constant, fl.Variable)
+		v.runBlock(fl.Body.Statements)
+		if v.loopEnds() {
+			break
+		}
+	}
+	v.scope = outer
+}
+
+// every time round runs in a new scope, like a for loop's
+func (v *EvaluatingVisitor) VisitWhileLoop(_ Visitor, wl *WhileLoop) {
+	for v.condition(wl.Condition) {
+		v.runScoped(wl.Body)
+		if v.loopEnds() {
+			break
+		}
 	}
 }
 
+// whether a loop ends once its body has run: it fails, returns or is
+// stopped. A `skip` only ends the time round it is in.
+func (v *EvaluatingVisitor) loopEnds() bool {
+	stopped := v.stopping
+	v.stopping, v.skipping = false, false
+	return stopped || v.Err != nil || v.returning
+}
+
+// run b in a new scope inside the current one
+func (v *EvaluatingVisitor) runScoped(b *Block) {
+	outer := v.scope
+	v.scope = evaluator.NewScope(outer, b.Slots)
+	v.runBlock(b.Statements)
+	v.scope = outer
+}
+
 func (v *EvaluatingVisitor) VisitVarAssignment(_ Visitor, va *VarAssignment) {
 	// assign the name to the new value
 	v.Visit(va.Value)
 	// check for the ident to exist; if not, exit
 	val := v.ValueStack.Pop()
-	if _, ok := v.IdentValue[va.Name.Name]; ok && val != nil {
-		v.IdentValue[va.Name.Name] = val
-	} else {
-		err := evaluator.RuntimeError{
+	if v.Err != nil {
+		return
+	}
+	depth, slot, ok := v.locate(va.Name)
+	if !ok || val == nil {
+		v.raise(&evaluator.RuntimeError{
 			Reason:       "Trying to assign to variable that does not exist",
 			VariableName: va.Name.Name,
-			Node:         va,
+		}, va)
+		return
+	}
+	if v.scope.IsConstant(depth, slot) {
+		v.raise(&evaluator.RuntimeError{
+			Reason:       "Cannot assign to a constant",
+			VariableName: va.Name.Name,
+		}, va)
+		return
+	}
+	if va.Name.Binding == nil {
+		// without a declared type, nothing takes the type of the old value
+		if val.Type == evaluator.NT_nothing {
+			val = evaluator.DefaultValue(v.scope.Get(depth, slot).Type)
 		}
-		fmt.Println(err.Error())
+	} else {
+		var fits bool
+		if val, fits = conform(v.instance(va.Name.Binding.Type), val); !fits {
+			v.raise(&evaluator.RuntimeError{
+				Reason:       fmt.Sprintf("Cannot assign %s to %s", val.TypeName(), v.instance(va.Name.Binding.Type)),
+				VariableName: va.Name.Name,
+			}, va)
+			return
+		}
+	}
+	v.scope.Set(depth, slot, val)
+}
+
+func (v *EvaluatingVisitor) VisitIndexAssignment(_ Visitor, ia *IndexAssignment) {
+	collection, index := v.evaluateIndex(ia.Target)
+	v.Visit(ia.Value)
+	val := v.ValueStack.Pop()
+	if v.Err != nil {
+		return
+	}
+	if err := evaluator.SetIndex(collection, index, val); err != nil {
+		v.raise(err, ia)
+	}
+}
+
+// a field of a reference to nothing cannot be assigned, as there is no struct
+// to hold it
+func (v *EvaluatingVisitor) VisitFieldAssignment(_ Visitor, fa *FieldAssignment) {
+	v.Visit(fa.Value)
+	val := v.ValueStack.Pop()
+	if v.Err != nil {
 		return
 	}
+	v.setField(fa.Target, val, fa)
 }
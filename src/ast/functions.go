@@ -0,0 +1,114 @@
+package ast
+
+import (
+	"nicer-syntax/evaluator"
+	"nicer-syntax/lexer"
+)
+
+// one of the parameters after `taking`, like `constant number N`
+type Parameter struct {
+	Node
+	Name     *Identifier
+	TypeName TypeExpr
+	Constant bool
+}
+
+// first is `constant` if it is there, or else the start of the type
+func NewParameter(first *lexer.TokItem, typeName TypeExpr, name *Identifier, constant bool) *Parameter {
+	param := &Parameter{Name: name, TypeName: typeName, Constant: constant}
+	param.Span = first.TokSpan.Join(name.Span)
+	return param
+}
+
+// `function, taking number A, and number B, returning number, doing ... done`.
+// The parameters and the names declared in the body share one scope, a new
+// one for every call.
+type FunctionLiteral struct {
+	HasValue
+	Node
+	Params  []*Parameter
+	Returns TypeExpr // nil when the function returns nothing
+	Body    []Statement
+	// the function's type, as its name is declared with
+	Type *FunctionType
+	// the struct a method belongs to, which `this` refers to an instance of;
+	// nil for functions that are not methods
+	This TypeExpr
+	// the type parameters after `function of`, for a generic function, and
+	// the constraints on them after `where`
+	TypeParams  []*Identifier
+	Constraints []*Constraint
+	// how many names the parameters and body declare, once resolved
+	Slots int
+}
+
+func NewFunctionLiteral(function *lexer.TokItem, typeParams []*Identifier, constraints []*Constraint, params []*Parameter, returns TypeExpr, body []Statement, last *lexer.TokItem) *FunctionLiteral {
+	fl := &FunctionLiteral{TypeParams: typeParams, Constraints: constraints, Params: params, Returns: returns, Body: body}
+	paramTypes := make([]TypeExpr, 0, len(params))
+	for _, param := range params {
+		paramTypes = append(paramTypes, param.TypeName)
+	}
+	fl.Type = NewFunctionType(function, paramTypes, returns)
+	fl.Type.TypeParams, fl.Type.Constraints = typeParams, constraints
+	fl.Span = function.TokSpan.Join(last.TokSpan)
+	return fl
+}
+
+// ast.Visitable
+func (fl FunctionLiteral) Accept(v Visitor) {
+	v.VisitFunctionLiteral(v, &fl)
+}
+
+// `function Name is function ... done`, which declares Name as a constant
+// holding the function. The function can call itself by Name.
+type FunctionDecl struct {
+	Declaration
+	Node
+	Name     *Identifier
+	Function *FunctionLiteral
+}
+
+func NewFunctionDecl(keyword *lexer.TokItem, name *Identifier, function *FunctionLiteral) *FunctionDecl {
+	fd := &FunctionDecl{Name: name, Function: function}
+	fd.Span = keyword.TokSpan.Join(function.Span)
+	return fd
+}
+
+// ast.Visitable
+func (fd FunctionDecl) Accept(v Visitor) {
+	v.VisitFunctionDecl(v, &fd)
+}
+
+// `return Value`, or just `return` from a function that returns nothing
+type ReturnStmt struct {
+	Statement
+	Node
+	Value Visitable
+}
+
+func NewReturnStmt(keyword *lexer.TokItem, val Visitable) *ReturnStmt {
+	rs := &ReturnStmt{Value: val}
+	rs.Span = keyword.TokSpan.Join(SpanOf(val))
+	return rs
+}
+
+// ast.Visitable
+func (rs ReturnStmt) Accept(v Visitor) {
+	v.VisitReturnStmt(v, &rs)
+}
+
+// FunctionValue is what a function evaluates to: its literal, and the scope
+// it was defined in, which every call's scope sits inside. A method is called
+// with This, the struct it was called on. Bindings are what the type
+// parameters of the generic code it was defined in stand for.
+type FunctionValue struct {
+	Literal  *FunctionLiteral
+	Scope    *evaluator.Scope
+	This     *evaluator.NicerValue
+	Bindings map[string]evaluator.NicerType
+}
+
+// for interface fmt.Stringer
+func (fv *FunctionValue) String() string {
+	return string(fv.Literal.Type.NicerType())
+}
@@ -0,0 +1,81 @@
+package ast
+
+import "nicer-syntax/lexer"
+
+// `for number I from 0 to 5, loop ... done`. Source is a range, a slice, or a
+// whole list, string or map named by `from Collection`. The variable is
+// declared like a parameter, in Body's scope, which is made afresh every time
+// round the loop.
+type ForLoop struct {
+	Statement
+	Node
+	Variable *Parameter
+	Source   Visitable
+	Body     *Block
+}
+
+func NewForLoop(keyword *lexer.TokItem, variable *Parameter, source Visitable, body []Statement, last *lexer.TokItem) *ForLoop {
+	fl := &ForLoop{Variable: variable, Source: source, Body: NewBlock(body)}
+	fl.Span = keyword.TokSpan.Join(last.TokSpan)
+	return fl
+}
+
+// ast.Visitable
+func (fl ForLoop) Accept(v Visitor) {
+	v.VisitForLoop(v, &fl)
+}
+
+// `while Condition, loop ... done`, which checks Condition before every time
+// round the loop
+type WhileLoop struct {
+	Statement
+	Node
+	Condition Visitable
+	Body      *Block
+}
+
+func NewWhileLoop(keyword *lexer.TokItem, condition Visitable, body []Statement, last *lexer.TokItem) *WhileLoop {
+	wl := &WhileLoop{Condition: condition, Body: NewBlock(body)}
+	wl.Span = keyword.TokSpan.Join(last.TokSpan)
+	return wl
+}
+
+// ast.Visitable
+func (wl WhileLoop) Accept(v Visitor) {
+	v.VisitWhileLoop(v, &wl)
+}
+
+// `stop`, which leaves the innermost loop at once
+type StopStmt struct {
+	Statement
+	Node
+}
+
+func NewStopStmt(keyword *lexer.TokItem) *StopStmt {
+	ss := &StopStmt{}
+	ss.Span = keyword.TokSpan
+	return ss
+}
+
+// ast.Visitable
+func (ss StopStmt) Accept(v Visitor) {
+	v.VisitStopStmt(v, &ss)
+}
+
+// `skip`, which leaves the rest of the innermost loop's body for this time
+// round, and goes on to the next
+type SkipStmt struct {
+	Statement
+	Node
+}
+
+func NewSkipStmt(keyword *lexer.TokItem) *SkipStmt {
+	ss := &SkipStmt{}
+	ss.Span = keyword.TokSpan
+	return ss
+}
+
+// ast.Visitable
+func (ss SkipStmt) Accept(v Visitor) {
+	v.VisitSkipStmt(v, &ss)
+}
@@ -0,0 +1,487 @@
+package ast
+
+import (
+	"fmt"
+	"nicer-syntax/evaluator"
+)
+
+// Binding is a name declared in a scope, and the slot its value is kept in.
+type Binding struct {
+	Name     string
+	Type     TypeExpr
+	Constant bool
+	Slot     int
+}
+
+// ScopeError is a name used where no declaration of it can be seen, or
+// declared twice in one scope. Type names are resolved too.
+type ScopeError struct {
+	Reason string
+	Name   string
+	Node   interface{}
+}
+
+// for interface error.Error()
+func (se *ScopeError) Error() string {
+	return fmt.Sprintf("%v: %v %v (%v)", SpanOf(se.Node), evaluator.COLOR_ERROR("SCOPE ERROR:"), evaluator.COLOR_KEYWORD(se.Reason), evaluator.COLOR_TOKEN(se.Name))
+}
+
+// the names declared in one scope while resolving
+type resolverScope struct {
+	parent *resolverScope
+	names  map[string]*Binding
+	size   int
+}
+
+// Resolver is an ast.Visitor that works out ahead of time which declaration
+// each identifier refers to, and where that declaration keeps its value, so
+// evaluating an identifier never looks up its name.
+//
+// Blocks and functions each have a scope inside the one they appear in. A
+// name can be used after its declaration in its scope or any scope inside it.
+// Declaring a name again in the same scope is an error, but an inner scope
+// may declare a name again to shadow the outer one.
+//
+// Type names refer to the program's type declarations, or to the type
+// parameters of the generic struct or function they are used in.
+type Resolver struct {
+	DefaultVisitor
+	scope *resolverScope
+	// the types the program declares, and the names among them that are
+	// declared in terms of themselves
+	types  TypeTable
+	cyclic map[string]bool
+	// the type parameters in scope, innermost last
+	typeParams []*Identifier
+	// every mistake found, in source order
+	Errors []*ScopeError
+}
+
+// Resolve resolves every identifier in program. Resolving it again gives the
+// same result.
+func Resolve(program *Program) []*ScopeError {
+	r := new(Resolver)
+	r.VisitProgram(r, program)
+	return r.Errors
+}
+
+func (r *Resolver) report(reason, name string, node interface{}) {
+	r.Errors = append(r.Errors, &ScopeError{Reason: reason, Name: name, Node: node})
+}
+
+func (r *Resolver) enterScope() {
+	r.scope = &resolverScope{parent: r.scope, names: make(map[string]*Binding)}
+}
+
+// leave the current scope, returning how many slots it needs
+func (r *Resolver) exitScope() int {
+	size := r.scope.size
+	r.scope = r.scope.parent
+	return size
+}
+
+// declare name in the current scope, in the next free slot
+func (r *Resolver) declare(name *Identifier, typeName TypeExpr, constant bool) {
+	if _, ok := r.scope.names[name.Name]; ok {
+		r.report("Name is already declared in this scope", name.Name, name)
+	}
+	binding := &Binding{Name: name.Name, Type: typeName, Constant: constant, Slot: r.scope.size}
+	r.scope.size++
+	r.scope.names[name.Name] = binding
+	name.Binding, name.Depth = binding, 0
+}
+
+func (r *Resolver) Visit(vis Visitable) {
+	switch vis := vis.(type) {
+	case *Identifier:
+		r.VisitIdentifier(r, vis)
+	case *FunctionCall:
+		r.VisitFunctionCall(r, vis)
+	case *FunctionLiteral:
+		r.VisitFunctionLiteral(r, vis)
+	case *FieldExpr:
+		r.VisitFieldExpr(r, vis)
+	case *BinaryExpr:
+		r.VisitBinaryExpr(r, vis)
+	case *UnaryExpr:
+		r.VisitUnaryExpr(r, vis)
+	case *ComparisonChain:
+		r.VisitComparisonChain(r, vis)
+	case *NothingCheck:
+		r.VisitNothingCheck(r, vis)
+	case *GroupedExpr:
+		r.VisitGroupedExpr(r, vis)
+	case *ListLiteral:
+		r.VisitListLiteral(r, vis)
+	case *MapLiteral:
+		r.VisitMapLiteral(r, vis)
+	case *StructLiteral:
+		r.VisitStructLiteral(r, vis)
+	case *RangeLiteral:
+		r.VisitRangeLiteral(r, vis)
+	case *IndexExpr:
+		r.VisitIndexExpr(r, vis)
+	case *SliceExpr:
+		r.VisitSliceExpr(r, vis)
+	}
+}
+
+func (r *Resolver) VisitIdentifier(_ Visitor, id *Identifier) {
+	if r.lookup(id) == nil {
+		r.report("Use of undeclared identifier", id.Name, id)
+	}
+}
+
+// resolve id to the innermost declaration of its name, or to nil
+func (r *Resolver) lookup(id *Identifier) *Binding {
+	depth := 0
+	for scope := r.scope; scope != nil; scope = scope.parent {
+		if binding, ok := scope.names[id.Name]; ok {
+			id.Binding, id.Depth = binding, depth
+			return binding
+		}
+		depth++
+	}
+	id.Binding = nil
+	return nil
+}
+
+// built-in functions are not declared anywhere, so their names are left
+// unresolved unless a declaration hides them. Methods are found through the
+// struct they are called on, rather than by name.
+func (r *Resolver) VisitFunctionCall(_ Visitor, fc *FunctionCall) {
+	for _, arg := range fc.Args {
+		r.Visit(arg)
+	}
+	if fc.Receiver != nil {
+		r.Visit(fc.Receiver)
+		return
+	}
+	if _, builtin := evaluator.BuiltInFunctions[fc.FuncName.Name]; builtin && r.lookup(fc.FuncName) == nil {
+		return
+	}
+	r.VisitIdentifier(r, fc.FuncName)
+}
+
+// resolve each name in t to the type declaration or type parameter it refers
+// to. A generic type's name must be a declared type.
+func (r *Resolver) resolveType(t TypeExpr) {
+	switch t := t.(type) {
+	case *ListType:
+		r.resolveType(t.Element)
+	case *MapType:
+		r.resolveType(t.Key)
+		r.resolveType(t.Value)
+	case *FunctionType:
+		for _, param := range t.Params {
+			r.resolveType(param)
+		}
+		if t.Returns != nil {
+			r.resolveType(t.Returns)
+		}
+	case *NamedType:
+		t.Declared = nil
+		for i := len(r.typeParams) - 1; i >= 0; i-- {
+			if r.typeParams[i].Name == t.Name.Name {
+				return
+			}
+		}
+		decl, ok := r.types[t.Name.Name]
+		if !ok {
+			r.report("Use of undeclared type", t.Name.Name, t)
+		} else if !r.cyclic[t.Name.Name] {
+			t.Declared = decl
+		}
+	case *GenericType:
+		if _, ok := r.types[t.Name.Name]; !ok {
+			r.report("Use of undeclared type", t.Name.Name, t.Name)
+		}
+		for _, arg := range t.Args {
+			r.resolveType(arg)
+		}
+	}
+}
+
+// whether following the names of types declared as other types from t comes
+// back to the type called name. seen holds the names already followed.
+func (r *Resolver) refersTo(t TypeExpr, name string, seen map[string]bool) bool {
+	switch t := t.(type) {
+	case *ListType:
+		return r.refersTo(t.Element, name, seen)
+	case *MapType:
+		return r.refersTo(t.Key, name, seen) || r.refersTo(t.Value, name, seen)
+	case *FunctionType:
+		for _, param := range t.Params {
+			if r.refersTo(param, name, seen) {
+				return true
+			}
+		}
+		return t.Returns != nil && r.refersTo(t.Returns, name, seen)
+	case *GenericType:
+		for _, arg := range t.Args {
+			if r.refersTo(arg, name, seen) {
+				return true
+			}
+		}
+	case *NamedType:
+		decl, ok := r.types[t.Name.Name]
+		if !ok || decl.IsStruct() || seen[t.Name.Name] {
+			return false
+		}
+		if t.Name.Name == name {
+			return true
+		}
+		seen[t.Name.Name] = true
+		return r.refersTo(decl.Type, name, seen)
+	}
+	return false
+}
+
+// the parameters are declared in the function's own scope, before its body,
+// followed by `this` in a method. A generic function's type parameters can
+// be used as types in its parameters and body.
+func (r *Resolver) VisitFunctionLiteral(_ Visitor, fl *FunctionLiteral) {
+	outer := r.typeParams
+	r.typeParams = append(outer[:len(outer):len(outer)], fl.TypeParams...)
+	for _, constraint := range fl.Constraints {
+		r.resolveType(constraint.Type)
+	}
+	if fl.Returns != nil {
+		r.resolveType(fl.Returns)
+	}
+	r.enterScope()
+	for _, param := range fl.Params {
+		r.resolveType(param.TypeName)
+		r.declare(param.Name, param.TypeName, param.Constant)
+	}
+	if fl.This != nil {
+		r.declare(&Identifier{Name: "this"}, fl.This, true)
+	}
+	for _, stmt := range fl.Body {
+		r.VisitStatement(r, stmt)
+	}
+	fl.Slots = r.exitScope()
+	r.typeParams = outer
+}
+
+// the field's name is looked up in the struct, once its type is known
+func (r *Resolver) VisitFieldExpr(_ Visitor, fe *FieldExpr) {
+	r.Visit(fe.Object)
+}
+func (r *Resolver) VisitBinaryExpr(_ Visitor, be *BinaryExpr) {
+	r.Visit(be.Left)
+	r.Visit(be.Right)
+}
+func (r *Resolver) VisitUnaryExpr(_ Visitor, ue *UnaryExpr) {
+	r.Visit(ue.Operand)
+}
+func (r *Resolver) VisitComparisonChain(_ Visitor, cc *ComparisonChain) {
+	for _, operand := range cc.Operands {
+		r.Visit(operand)
+	}
+}
+func (r *Resolver) VisitNothingCheck(_ Visitor, nc *NothingCheck) {
+	r.Visit(nc.Operand)
+}
+func (r *Resolver) VisitGroupedExpr(_ Visitor, ge *GroupedExpr) {
+	r.Visit(ge.Inner)
+}
+func (r *Resolver) VisitListLiteral(_ Visitor, ll *ListLiteral) {
+	for _, element := range ll.Elements {
+		r.Visit(element)
+	}
+}
+
+// the fields are looked up in the struct, once its type is known
+func (r *Resolver) VisitStructLiteral(_ Visitor, sl *StructLiteral) {
+	r.resolveType(sl.Type)
+	for _, val := range sl.Values {
+		r.Visit(val)
+	}
+}
+func (r *Resolver) VisitMapLiteral(_ Visitor, ml *MapLiteral) {
+	for i := range ml.Keys {
+		r.Visit(ml.Keys[i])
+		r.Visit(ml.Values[i])
+	}
+}
+func (r *Resolver) VisitRangeLiteral(_ Visitor, rl *RangeLiteral) {
+	for _, node := range []Visitable{rl.Step, rl.Start, rl.End, rl.StartOf, rl.Of} {
+		r.Visit(node)
+	}
+}
+func (r *Resolver) VisitIndexExpr(_ Visitor, ie *IndexExpr) {
+	r.Visit(ie.Index)
+	r.Visit(ie.Collection)
+}
+func (r *Resolver) VisitSliceExpr(_ Visitor, se *SliceExpr) {
+	r.VisitRangeLiteral(r, se.Range)
+	r.Visit(se.Collection)
+}
+
+// top-level declarations go in the program's own scope. Types are gathered
+// first, so they can be used before they are declared. A type other than a
+// struct cannot be declared in terms of itself, as it would never end.
+func (r *Resolver) VisitProgram(_ Visitor, p *Program) {
+	p.Types = make(TypeTable)
+	for _, stmt := range p.Statements {
+		if td, ok := stmt.(*TypeDecl); ok {
+			if _, declared := p.Types[td.Name.Name]; declared {
+				r.report("Type is already declared", td.Name.Name, td.Name)
+				continue
+			}
+			p.Types[td.Name.Name] = td
+		}
+	}
+	r.types, r.cyclic = p.Types, make(map[string]bool)
+	for name, td := range p.Types {
+		if !td.IsStruct() && r.refersTo(td.Type, name, make(map[string]bool)) {
+			r.cyclic[name] = true
+		}
+	}
+	for _, stmt := range p.Statements {
+		if td, ok := stmt.(*TypeDecl); ok && r.cyclic[td.Name.Name] && p.Types[td.Name.Name] == td {
+			r.report("Type is declared in terms of itself", td.Name.Name, td.Name)
+		}
+	}
+	r.enterScope()
+	for _, stmt := range p.Statements {
+		r.VisitStatement(r, stmt)
+	}
+	p.Slots = r.exitScope()
+}
+
+func (r *Resolver) VisitStatement(_ Visitor, s Statement) {
+	switch s := s.(type) {
+	case *VarAssignment:
+		r.VisitVarAssignment(r, s)
+	case *IndexAssignment:
+		r.VisitIndexAssignment(r, s)
+	case *FieldAssignment:
+		r.VisitFieldAssignment(r, s)
+	case *FunctionCall:
+		r.VisitFunctionCall(r, s)
+	case *ReturnStmt:
+		r.VisitReturnStmt(r, s)
+	case *IfStmt:
+		r.VisitIfStmt(r, s)
+	case *ForLoop:
+		r.VisitForLoop(r, s)
+	case *WhileLoop:
+		r.VisitWhileLoop(r, s)
+	case Declaration:
+		r.VisitDeclaration(r, s)
+	}
+}
+
+func (r *Resolver) VisitDeclaration(_ Visitor, d Declaration) {
+	switch d := d.(type) {
+	case *VarDecl:
+		r.VisitVarDecl(r, d)
+	case *ConstDecl:
+		r.VisitConstDecl(r, d)
+	case *FunctionDecl:
+		r.VisitFunctionDecl(r, d)
+	case *TypeDecl:
+		r.VisitTypeDecl(r, d)
+	}
+}
+
+// the value is resolved before the name is declared, so it cannot refer to
+// the name it is declaring
+func (r *Resolver) VisitVarDecl(_ Visitor, vd *VarDecl) {
+	r.resolveType(vd.TypeName)
+	r.Visit(vd.Value)
+	r.declare(vd.VarName, vd.TypeName, false)
+}
+func (r *Resolver) VisitConstDecl(_ Visitor, cd *ConstDecl) {
+	r.resolveType(cd.TypeName)
+	r.Visit(cd.Value)
+	r.declare(cd.ConstName, cd.TypeName, true)
+}
+
+// the name is declared before the function, so the function can call itself
+func (r *Resolver) VisitFunctionDecl(_ Visitor, fd *FunctionDecl) {
+	r.declare(fd.Name, fd.Function.Type, true)
+	r.VisitFunctionLiteral(r, fd.Function)
+}
+
+// fields and methods share one set of names within a struct. Methods see the
+// names declared around the type, like any other function, and the struct's
+// type parameters can be used as types all through it.
+func (r *Resolver) VisitTypeDecl(_ Visitor, td *TypeDecl) {
+	st, ok := td.Type.(*StructType)
+	if !ok {
+		r.resolveType(td.Type)
+		return
+	}
+	r.typeParams = st.TypeParams
+	for _, constraint := range st.Constraints {
+		r.resolveType(constraint.Type)
+	}
+	members := make(map[string]bool)
+	for _, d := range st.Fields {
+		name, typeName, _ := field(d)
+		r.resolveType(typeName)
+		if members[name.Name] {
+			r.report("Field is already declared in this struct", name.Name, name)
+		}
+		members[name.Name] = true
+	}
+	for _, method := range st.Methods {
+		if members[method.Name.Name] {
+			r.report("Method is already declared in this struct", method.Name.Name, method.Name)
+		}
+		members[method.Name.Name] = true
+		r.VisitFunctionLiteral(r, method.Function)
+	}
+	r.typeParams = nil
+}
+
+func (r *Resolver) VisitVarAssignment(_ Visitor, va *VarAssignment) {
+	r.Visit(va.Value)
+	r.VisitIdentifier(r, va.Name)
+}
+func (r *Resolver) VisitIndexAssignment(_ Visitor, ia *IndexAssignment) {
+	r.VisitIndexExpr(r, ia.Target)
+	r.Visit(ia.Value)
+}
+func (r *Resolver) VisitFieldAssignment(_ Visitor, fa *FieldAssignment) {
+	r.VisitFieldExpr(r, fa.Target)
+	r.Visit(fa.Value)
+}
+func (r *Resolver) VisitReturnStmt(_ Visitor, rs *ReturnStmt) {
+	r.Visit(rs.Value)
+}
+func (r *Resolver) VisitIfStmt(_ Visitor, is *IfStmt) {
+	r.Visit(is.Condition)
+	r.block(is.Then)
+	if is.Else != nil {
+		r.block(is.Else)
+	}
+}
+
+// the variable is declared in the body's scope, before the body
+func (r *Resolver) VisitForLoop(_ Visitor, fl *ForLoop) {
+	r.Visit(fl.Source)
+	r.resolveType(fl.Variable.TypeName)
+	r.enterScope()
+	r.declare(fl.Variable.Name, fl.Variable.TypeName, fl.Variable.Constant)
+	for _, stmt := range fl.Body.Statements {
+		r.VisitStatement(r, stmt)
+	}
+	fl.Body.Slots = r.exitScope()
+}
+func (r *Resolver) VisitWhileLoop(_ Visitor, wl *WhileLoop) {
+	r.Visit(wl.Condition)
+	r.block(wl.Body)
+}
+
+// the statements of b, in a scope of its own
+func (r *Resolver) block(b *Block) {
+	r.enterScope()
+	for _, stmt := range b.Statements {
+		r.VisitStatement(r, stmt)
+	}
+	b.Slots = r.exitScope()
+}
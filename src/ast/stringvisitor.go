@@ -43,8 +43,52 @@ func (v *StringVisitor) Visit(vis Visitable) {
 		v.VisitBooleanLiteral(v, vis)
 	case *StringLiteral:
 		v.VisitStringLiteral(v, vis)
+	case *NothingLiteral:
+		v.VisitNothingLiteral(v, vis)
 	case *Identifier:
 		v.VisitIdentifier(v, vis)
+	case *BinaryExpr:
+		v.VisitBinaryExpr(v, vis)
+	case *UnaryExpr:
+		v.VisitUnaryExpr(v, vis)
+	case *ComparisonChain:
+		v.VisitComparisonChain(v, vis)
+	case *NothingCheck:
+		v.VisitNothingCheck(v, vis)
+	case *GroupedExpr:
+		v.VisitGroupedExpr(v, vis)
+	case *FunctionCall:
+		v.VisitFunctionCall(v, vis)
+	case *FunctionLiteral:
+		v.VisitFunctionLiteral(v, vis)
+	case *FieldExpr:
+		v.VisitFieldExpr(v, vis)
+	case *ListLiteral:
+		v.VisitListLiteral(v, vis)
+	case *MapLiteral:
+		v.VisitMapLiteral(v, vis)
+	case *StructLiteral:
+		v.VisitStructLiteral(v, vis)
+	case *RangeLiteral:
+		v.VisitRangeLiteral(v, vis)
+	case *IndexExpr:
+		v.VisitIndexExpr(v, vis)
+	case *SliceExpr:
+		v.VisitSliceExpr(v, vis)
+	case *PrimitiveType:
+		v.VisitPrimitiveType(v, vis)
+	case *ListType:
+		v.VisitListType(v, vis)
+	case *MapType:
+		v.VisitMapType(v, vis)
+	case *NamedType:
+		v.VisitNamedType(v, vis)
+	case *GenericType:
+		v.VisitGenericType(v, vis)
+	case *FunctionType:
+		v.VisitFunctionType(v, vis)
+	case *StructType:
+		v.VisitStructType(v, vis)
 	default:
 		v.strings.Push("nothing")
 	}
@@ -58,17 +102,268 @@ func (v *StringVisitor) VisitBooleanLiteral(_ Visitor, bl *BooleanLiteral) {
 func (v *StringVisitor) VisitStringLiteral(_ Visitor, sl *StringLiteral) {
 	v.strings.Push(fmt.Sprintf("\"%v\"", sl.Value))
 }
+func (v *StringVisitor) VisitNothingLiteral(_ Visitor, nl *NothingLiteral) {
+	v.strings.Push("nothing")
+}
 func (v *StringVisitor) VisitIdentifier(_ Visitor, id *Identifier) {
 	v.strings.Push(fmt.Sprintf("%v", id.Name))
 }
 func (v *StringVisitor) VisitFunctionCall(_ Visitor, fc *FunctionCall) {
-	v.builder.Reset()
 	v.VisitIdentifier(v, fc.FuncName)
 	ident := v.strings.Pop()
-	v.Visit(fc.FuncParams)
-	params := v.strings.Pop()
-	v.builder.WriteString(fmt.Sprintf("FunctionCall(%s %s)", ident, params))
-	v.strings.Push(v.builder.String())
+	if fc.Receiver != nil {
+		v.Visit(fc.Receiver)
+		ident += " of " + v.strings.Pop()
+	}
+	if len(fc.Args) == 0 {
+		v.strings.Push(fmt.Sprintf("FunctionCall(%s)", ident))
+		return
+	}
+	args := make([]string, 0, len(fc.Args))
+	for _, arg := range fc.Args {
+		v.Visit(arg)
+		args = append(args, v.strings.Pop())
+	}
+	v.strings.Push(fmt.Sprintf("FunctionCall(%s %s)", ident, strings.Join(args, ", ")))
+}
+func (v *StringVisitor) VisitFunctionLiteral(_ Visitor, fl *FunctionLiteral) {
+	var parts []string
+	if len(fl.TypeParams) > 0 {
+		parts = append(parts, "of "+andList(identNames(fl.TypeParams))+v.constraints(fl.Constraints))
+	}
+	if len(fl.Params) > 0 {
+		params := make([]string, 0, len(fl.Params))
+		for _, param := range fl.Params {
+			params = append(params, v.parameter(param))
+		}
+		parts = append(parts, "taking "+andList(params))
+	}
+	if fl.Returns != nil {
+		v.Visit(fl.Returns)
+		parts = append(parts, "returning "+v.strings.Pop())
+	}
+	parts = append(parts, "doing "+v.body(fl.Body))
+	v.strings.Push(fmt.Sprintf("FunctionLiteral(%s)", strings.Join(parts, ", ")))
+}
+
+func (v *StringVisitor) VisitFieldExpr(_ Visitor, fe *FieldExpr) {
+	v.Visit(fe.Object)
+	v.strings.Push(fmt.Sprintf("FieldExpr(%s of %s)", fe.Field.Name, v.strings.Pop()))
+}
+
+// `[constant] Type Name`
+func (v *StringVisitor) parameter(param *Parameter) string {
+	v.Visit(param.TypeName)
+	typeName := v.strings.Pop()
+	if param.Constant {
+		typeName = "constant " + typeName
+	}
+	return typeName + " " + param.Name.Name
+}
+
+// the statements of a body in turn, or `nothing` if there are none
+func (v *StringVisitor) body(stmts []Statement) string {
+	body := make([]string, 0, len(stmts))
+	for _, stmt := range stmts {
+		v.VisitStatement(v, stmt)
+		body = append(body, v.strings.Pop())
+	}
+	if len(body) == 0 {
+		return "nothing"
+	}
+	return strings.Join(body, " ")
+}
+
+func (v *StringVisitor) VisitBinaryExpr(_ Visitor, be *BinaryExpr) {
+	v.Visit(be.Left)
+	left := v.strings.Pop()
+	v.Visit(be.Right)
+	right := v.strings.Pop()
+	v.strings.Push(fmt.Sprintf("BinaryExpr(%s %s %s)", left, be.Operator, right))
+}
+func (v *StringVisitor) VisitUnaryExpr(_ Visitor, ue *UnaryExpr) {
+	v.Visit(ue.Operand)
+	operand := v.strings.Pop()
+	v.strings.Push(fmt.Sprintf("UnaryExpr(%s %s)", ue.Operator, operand))
+}
+func (v *StringVisitor) VisitComparisonChain(_ Visitor, cc *ComparisonChain) {
+	var chain strings.Builder
+	for i, operand := range cc.Operands {
+		if i > 0 {
+			chain.WriteString(fmt.Sprintf(" %s ", cc.Operators[i-1]))
+		}
+		v.Visit(operand)
+		chain.WriteString(v.strings.Pop())
+	}
+	v.strings.Push(fmt.Sprintf("ComparisonChain(%s)", chain.String()))
+}
+func (v *StringVisitor) VisitNothingCheck(_ Visitor, nc *NothingCheck) {
+	v.Visit(nc.Operand)
+	operand := v.strings.Pop()
+	check := "is nothing"
+	if nc.Negated {
+		check = "is not nothing"
+	}
+	v.strings.Push(fmt.Sprintf("NothingCheck(%s %s)", operand, check))
+}
+func (v *StringVisitor) VisitGroupedExpr(_ Visitor, ge *GroupedExpr) {
+	v.Visit(ge.Inner)
+	inner := v.strings.Pop()
+	v.strings.Push(fmt.Sprintf("GroupedExpr(%s)", inner))
+}
+func (v *StringVisitor) VisitListLiteral(_ Visitor, ll *ListLiteral) {
+	elements := make([]string, 0, len(ll.Elements))
+	for _, element := range ll.Elements {
+		v.Visit(element)
+		elements = append(elements, v.strings.Pop())
+	}
+	v.strings.Push(fmt.Sprintf("ListLiteral(%s)", strings.Join(elements, ", ")))
+}
+func (v *StringVisitor) VisitMapLiteral(_ Visitor, ml *MapLiteral) {
+	pairs := make([]string, 0, len(ml.Keys))
+	for i := range ml.Keys {
+		v.Visit(ml.Keys[i])
+		key := v.strings.Pop()
+		v.Visit(ml.Values[i])
+		pairs = append(pairs, fmt.Sprintf("%s as %s", key, v.strings.Pop()))
+	}
+	v.strings.Push(fmt.Sprintf("MapLiteral(%s)", strings.Join(pairs, ", ")))
+}
+
+func (v *StringVisitor) VisitStructLiteral(_ Visitor, sl *StructLiteral) {
+	fields := []string{string(sl.Type.NicerType())}
+	for i, name := range sl.Fields {
+		v.Visit(sl.Values[i])
+		fields = append(fields, fmt.Sprintf("%s is %s", name.Name, v.strings.Pop()))
+	}
+	v.strings.Push(fmt.Sprintf("StructLiteral(%s)", strings.Join(fields, ", ")))
+}
+func (v *StringVisitor) VisitRangeLiteral(_ Visitor, rl *RangeLiteral) {
+	var r strings.Builder
+	if rl.Step != nil {
+		v.Visit(rl.Step)
+		r.WriteString(fmt.Sprintf("every %s-th ", v.strings.Pop()))
+	}
+	start, end := "start", "end"
+	if !rl.FromStart {
+		v.Visit(rl.Start)
+		start = v.strings.Pop()
+	} else if rl.StartOf != nil {
+		v.Visit(rl.StartOf)
+		start = "start of " + v.strings.Pop()
+	}
+	if !rl.ToEnd {
+		v.Visit(rl.End)
+		end = v.strings.Pop()
+	}
+	r.WriteString(fmt.Sprintf("from %s to %s", start, end))
+	if rl.Of != nil {
+		v.Visit(rl.Of)
+		r.WriteString(" of " + v.strings.Pop())
+	}
+	v.strings.Push(fmt.Sprintf("RangeLiteral(%s)", r.String()))
+}
+func (v *StringVisitor) VisitIndexExpr(_ Visitor, ie *IndexExpr) {
+	index := "start"
+	if ie.AtEnd {
+		index = "end"
+	} else if !ie.AtStart {
+		v.Visit(ie.Index)
+		index = v.strings.Pop()
+	}
+	v.Visit(ie.Collection)
+	collection := v.strings.Pop()
+	v.strings.Push(fmt.Sprintf("IndexExpr(%s from %s)", index, collection))
+}
+func (v *StringVisitor) VisitSliceExpr(_ Visitor, se *SliceExpr) {
+	v.VisitRangeLiteral(v, se.Range)
+	indices := v.strings.Pop()
+	v.Visit(se.Collection)
+	collection := v.strings.Pop()
+	v.strings.Push(fmt.Sprintf("SliceExpr(%s from %s)", indices, collection))
+}
+
+// types are shown as they are written, so they parse back to the same type
+func (v *StringVisitor) VisitPrimitiveType(_ Visitor, pt *PrimitiveType) {
+	v.strings.Push(pt.Name)
+}
+func (v *StringVisitor) VisitListType(_ Visitor, lt *ListType) {
+	v.Visit(lt.Element)
+	v.strings.Push("list of " + v.strings.Pop())
+}
+func (v *StringVisitor) VisitMapType(_ Visitor, mt *MapType) {
+	v.Visit(mt.Key)
+	key := v.strings.Pop()
+	v.Visit(mt.Value)
+	value := v.strings.Pop()
+	v.strings.Push(fmt.Sprintf("map of %s to %s", key, value))
+}
+func (v *StringVisitor) VisitNamedType(_ Visitor, nt *NamedType) {
+	v.strings.Push(nt.Name.Name)
+}
+func (v *StringVisitor) VisitGenericType(_ Visitor, gt *GenericType) {
+	v.strings.Push(gt.Name.Name + " of " + andList(v.typeStrings(gt.Args)))
+}
+func (v *StringVisitor) VisitFunctionType(_ Visitor, ft *FunctionType) {
+	function := "function"
+	if len(ft.TypeParams) > 0 {
+		function += " of " + andList(identNames(ft.TypeParams))
+	}
+	if len(ft.Params) > 0 {
+		function += ", taking " + andList(v.typeStrings(ft.Params))
+	}
+	if ft.Returns != nil {
+		v.Visit(ft.Returns)
+		function += ", returning " + v.strings.Pop()
+	}
+	v.strings.Push(function)
+}
+func (v *StringVisitor) VisitStructType(_ Visitor, st *StructType) {
+	typeParams := identNames(st.TypeParams)
+	fields := make([]string, 0, len(st.Fields))
+	for _, field := range st.Fields {
+		v.VisitDeclaration(v, field)
+		fields = append(fields, v.strings.Pop())
+	}
+	structType := "struct"
+	if len(typeParams) > 0 {
+		structType += " of " + andList(typeParams) + v.constraints(st.Constraints)
+	}
+	if len(fields) == 0 {
+		fields = append(fields, "nothing")
+	}
+	structType += " containing " + strings.Join(fields, ", ")
+	if len(st.Methods) > 0 {
+		methods := make([]string, 0, len(st.Methods))
+		for _, method := range st.Methods {
+			v.VisitFunctionDecl(v, method)
+			methods = append(methods, v.strings.Pop())
+		}
+		structType += " and can do " + strings.Join(methods, " ")
+	}
+	v.strings.Push(structType + " done")
+}
+
+// ` where T can do Method is function, ...`, or nothing without constraints
+func (v *StringVisitor) constraints(constraints []*Constraint) string {
+	if len(constraints) == 0 {
+		return ""
+	}
+	strs := make([]string, 0, len(constraints))
+	for _, c := range constraints {
+		v.Visit(c.Type)
+		strs = append(strs, fmt.Sprintf("%s can do %s is %s", c.Param.Name, c.Method.Name, v.strings.Pop()))
+	}
+	return " where " + andList(strs)
+}
+
+func (v *StringVisitor) typeStrings(types []TypeExpr) []string {
+	strs := make([]string, 0, len(types))
+	for _, t := range types {
+		v.Visit(t)
+		strs = append(strs, v.strings.Pop())
+	}
+	return strs
 }
 
 func (v *StringVisitor) VisitConstDecl(_ Visitor, cd *ConstDecl) {
@@ -93,6 +388,14 @@ func (v *StringVisitor) VisitVarDecl(_ Visitor, vd *VarDecl) {
 	v.builder.WriteString(fmt.Sprintf("VarDecl(%s %s %s)", ident, typeName, value))
 	v.strings = append(v.strings, v.builder.String())
 }
+func (v *StringVisitor) VisitFunctionDecl(_ Visitor, fd *FunctionDecl) {
+	v.VisitFunctionLiteral(v, fd.Function)
+	v.strings.Push(fmt.Sprintf("FunctionDecl(%s %s)", fd.Name.Name, v.strings.Pop()))
+}
+func (v *StringVisitor) VisitTypeDecl(_ Visitor, td *TypeDecl) {
+	v.Visit(td.Type)
+	v.strings.Push(fmt.Sprintf("TypeDecl(%s %s)", td.Name.Name, v.strings.Pop()))
+}
 func (v *StringVisitor) VisitProgram(_ Visitor, p *Program) {
 	var strs = []string{
 		"Program(",
@@ -118,6 +421,24 @@ func (v *StringVisitor) VisitStatement(_ Visitor, s Statement) {
 	switch s := s.(type) {
 	case *VarAssignment:
 		v.VisitVarAssignment(v, s)
+	case *IndexAssignment:
+		v.VisitIndexAssignment(v, s)
+	case *FieldAssignment:
+		v.VisitFieldAssignment(v, s)
+	case *FunctionCall:
+		v.VisitFunctionCall(v, s)
+	case *ReturnStmt:
+		v.VisitReturnStmt(v, s)
+	case *IfStmt:
+		v.VisitIfStmt(v, s)
+	case *ForLoop:
+		v.VisitForLoop(v, s)
+	case *WhileLoop:
+		v.VisitWhileLoop(v, s)
+	case *StopStmt:
+		v.VisitStopStmt(v, s)
+	case *SkipStmt:
+		v.VisitSkipStmt(v, s)
 	case Declaration:
 		v.VisitDeclaration(v, s)
 	default:
@@ -140,6 +461,59 @@ func (v *StringVisitor) VisitVarAssignment(_ Visitor, va *VarAssignment) {
 	v.strings.Push(v.builder.String())
 }
 
+func (v *StringVisitor) VisitIndexAssignment(_ Visitor, ia *IndexAssignment) {
+	v.VisitIndexExpr(v, ia.Target)
+	target := v.strings.Pop()
+	v.Visit(ia.Value)
+	val := v.strings.Pop()
+	v.strings.Push(fmt.Sprintf("IndexAssignment(%s %s)", target, val))
+}
+
+func (v *StringVisitor) VisitFieldAssignment(_ Visitor, fa *FieldAssignment) {
+	v.VisitFieldExpr(v, fa.Target)
+	target := v.strings.Pop()
+	v.Visit(fa.Value)
+	val := v.strings.Pop()
+	v.strings.Push(fmt.Sprintf("FieldAssignment(%s %s)", target, val))
+}
+
+func (v *StringVisitor) VisitReturnStmt(_ Visitor, rs *ReturnStmt) {
+	if rs.Value == nil {
+		v.strings.Push("Return()")
+		return
+	}
+	v.Visit(rs.Value)
+	v.strings.Push(fmt.Sprintf("Return(%s)", v.strings.Pop()))
+}
+
+// If(Condition, then ..., else ...), with an `else if` as an If in the else
+func (v *StringVisitor) VisitIfStmt(_ Visitor, is *IfStmt) {
+	v.Visit(is.Condition)
+	parts := []string{v.strings.Pop(), "then " + v.body(is.Then.Statements)}
+	if is.Else != nil {
+		parts = append(parts, "else "+v.body(is.Else.Statements))
+	}
+	v.strings.Push(fmt.Sprintf("If(%s)", strings.Join(parts, ", ")))
+}
+
+func (v *StringVisitor) VisitForLoop(_ Visitor, fl *ForLoop) {
+	v.Visit(fl.Source)
+	source := v.strings.Pop()
+	v.strings.Push(fmt.Sprintf("For(%s from %s, loop %s)", v.parameter(fl.Variable), source, v.body(fl.Body.Statements)))
+}
+
+func (v *StringVisitor) VisitWhileLoop(_ Visitor, wl *WhileLoop) {
+	v.Visit(wl.Condition)
+	condition := v.strings.Pop()
+	v.strings.Push(fmt.Sprintf("While(%s, loop %s)", condition, v.body(wl.Body.Statements)))
+}
+func (v *StringVisitor) VisitStopStmt(_ Visitor, ss *StopStmt) {
+	v.strings.Push("Stop")
+}
+func (v *StringVisitor) VisitSkipStmt(_ Visitor, ss *SkipStmt) {
+	v.strings.Push("Skip")
+}
+
 func (v *StringVisitor) VisitDeclaration(_ Visitor, d Declaration) {
 	v.builder.Reset()
 	switch d := d.(type) {
@@ -147,6 +521,10 @@ func (v *StringVisitor) VisitDeclaration(_ Visitor, d Declaration) {
 		v.VisitVarDecl(v, d)
 	case *ConstDecl:
 		v.VisitConstDecl(v, d)
+	case *FunctionDecl:
+		v.VisitFunctionDecl(v, d)
+	case *TypeDecl:
+		v.VisitTypeDecl(v, d)
 	default:
 		v.strings.Push("UnknownDecl")
 	}
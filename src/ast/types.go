@@ -0,0 +1,450 @@
+package ast
+
+import (
+	"nicer-syntax/evaluator"
+	"nicer-syntax/lexer"
+	"strings"
+)
+
+// TypeExpr is a type as written in the source, like `number` or
+// `map of string to list of number`.
+type TypeExpr interface {
+	Visitable
+	Spanned
+	// the evaluator's name for the type, which is also how it is written
+	NicerType() evaluator.NicerType
+}
+
+// `number`, `boolean` or `string`
+type PrimitiveType struct {
+	Node
+	Name string
+}
+
+func NewPrimitiveType(tok *lexer.TokItem) *PrimitiveType {
+	pt := &PrimitiveType{Name: tok.TokValue.(string)}
+	pt.Span = tok.TokSpan
+	return pt
+}
+
+func (pt PrimitiveType) NicerType() evaluator.NicerType {
+	return evaluator.NicerType(pt.Name)
+}
+
+// ast.Visitable
+func (pt PrimitiveType) Accept(v Visitor) {
+	v.VisitPrimitiveType(v, &pt)
+}
+
+// `list of Element`
+type ListType struct {
+	Node
+	Element TypeExpr
+}
+
+func NewListType(list *lexer.TokItem, element TypeExpr) *ListType {
+	lt := &ListType{Element: element}
+	lt.Span = list.TokSpan.Join(element.Location())
+	return lt
+}
+
+func (lt ListType) NicerType() evaluator.NicerType {
+	return evaluator.ListOf(lt.Element.NicerType())
+}
+
+// ast.Visitable
+func (lt ListType) Accept(v Visitor) {
+	v.VisitListType(v, &lt)
+}
+
+// `map of Key to Value`
+type MapType struct {
+	Node
+	Key   TypeExpr
+	Value TypeExpr
+}
+
+func NewMapType(m *lexer.TokItem, key, value TypeExpr) *MapType {
+	mt := &MapType{Key: key, Value: value}
+	mt.Span = m.TokSpan.Join(value.Location())
+	return mt
+}
+
+func (mt MapType) NicerType() evaluator.NicerType {
+	return evaluator.MapOf(mt.Key.NicerType(), mt.Value.NicerType())
+}
+
+// ast.Visitable
+func (mt MapType) Accept(v Visitor) {
+	v.VisitMapType(v, &mt)
+}
+
+// a type known by its name, like a struct or a generic type parameter
+type NamedType struct {
+	Node
+	Name *Identifier
+	// the type declaration the name refers to, once resolved; nil for type
+	// parameters
+	Declared *TypeDecl
+}
+
+func NewNamedType(name *Identifier) *NamedType {
+	nt := &NamedType{Name: name}
+	nt.Span = name.Span
+	return nt
+}
+
+// a name declared for a type other than a struct is another name for that
+// type, so it stands for the type it was declared as
+func (nt NamedType) NicerType() evaluator.NicerType {
+	if nt.Declared != nil && !nt.Declared.IsStruct() {
+		return nt.Declared.Type.NicerType()
+	}
+	return evaluator.NicerType(nt.Name.Name)
+}
+
+// Underlying gives the type t stands for, seeing through the names of types
+// declared as other types. Structs are types of their own.
+func Underlying(t TypeExpr) TypeExpr {
+	for {
+		nt, ok := t.(*NamedType)
+		if !ok || nt.Declared == nil || nt.Declared.IsStruct() {
+			return t
+		}
+		t = nt.Declared.Type
+	}
+}
+
+// ast.Visitable
+func (nt NamedType) Accept(v Visitor) {
+	v.VisitNamedType(v, &nt)
+}
+
+// a generic type given its type arguments, like `Tuple of number, and string`
+type GenericType struct {
+	Node
+	Name *Identifier
+	Args []TypeExpr
+}
+
+func NewGenericType(name *Identifier, args []TypeExpr) *GenericType {
+	gt := &GenericType{Name: name, Args: args}
+	gt.Span = name.Span.Join(args[len(args)-1].Location())
+	return gt
+}
+
+func (gt GenericType) NicerType() evaluator.NicerType {
+	return evaluator.NicerType(gt.Name.Name + " of " + andList(typeNames(gt.Args)))
+}
+
+// ast.Visitable
+func (gt GenericType) Accept(v Visitor) {
+	v.VisitGenericType(v, &gt)
+}
+
+// `function, taking A, and B, returning C`; Returns is nil for functions
+// that return nothing. A generic function's type starts `function of T`.
+type FunctionType struct {
+	Node
+	TypeParams  []*Identifier
+	Constraints []*Constraint
+	Params      []TypeExpr
+	Returns     TypeExpr
+}
+
+func NewFunctionType(function *lexer.TokItem, params []TypeExpr, returns TypeExpr) *FunctionType {
+	ft := &FunctionType{Params: params, Returns: returns}
+	ft.Span = function.TokSpan
+	if len(params) > 0 {
+		ft.Span = ft.Span.Join(params[len(params)-1].Location())
+	}
+	ft.Span = ft.Span.Join(SpanOf(returns))
+	return ft
+}
+
+func (ft FunctionType) NicerType() evaluator.NicerType {
+	name := "function"
+	if len(ft.TypeParams) > 0 {
+		name += " of " + andList(identNames(ft.TypeParams))
+	}
+	if len(ft.Params) > 0 {
+		name += ", taking " + andList(typeNames(ft.Params))
+	}
+	if ft.Returns != nil {
+		name += ", returning " + string(ft.Returns.NicerType())
+	}
+	return evaluator.NicerType(name)
+}
+
+// ast.Visitable
+func (ft FunctionType) Accept(v Visitor) {
+	v.VisitFunctionType(v, &ft)
+}
+
+// `struct of A, and B containing Fields, and can do Methods, done`, the body
+// of a struct's `type` declaration. Structs are told apart by the name they
+// are declared with.
+type StructType struct {
+	Node
+	TypeParams  []*Identifier
+	Constraints []*Constraint
+	Fields      []Declaration // a VarDecl or ConstDecl without a value for each
+	Methods     []*FunctionDecl
+}
+
+func NewStructType(structTok *lexer.TokItem, typeParams []*Identifier, constraints []*Constraint, fields []Declaration, methods []*FunctionDecl, done *lexer.TokItem) *StructType {
+	st := &StructType{TypeParams: typeParams, Constraints: constraints, Fields: fields, Methods: methods}
+	st.Span = structTok.TokSpan.Join(done.TokSpan)
+	return st
+}
+
+func (st StructType) NicerType() evaluator.NicerType {
+	return "struct"
+}
+
+// ast.Visitable
+func (st StructType) Accept(v Visitor) {
+	v.VisitStructType(v, &st)
+}
+
+// `T can do Method is function, ...` after `where`: the type parameter T can
+// only stand for a type with a method of that name and type. Generic code can
+// call the method on values of type T.
+type Constraint struct {
+	Node
+	Param  *Identifier
+	Method *Identifier
+	Type   *FunctionType
+}
+
+func NewConstraint(param, method *Identifier, function *FunctionType) *Constraint {
+	c := &Constraint{Param: param, Method: method, Type: function}
+	c.Span = param.Span.Join(function.Span)
+	return c
+}
+
+// the name and type of a field, and whether it is a constant
+func field(d Declaration) (*Identifier, TypeExpr, bool) {
+	switch d := d.(type) {
+	case *VarDecl:
+		return d.VarName, d.TypeName, false
+	case *ConstDecl:
+		return d.ConstName, d.TypeName, true
+	}
+	return nil, nil, false
+}
+
+// Field gives the type of the field called name, and whether it is a
+// constant, if the struct has one.
+func (st *StructType) Field(name string) (TypeExpr, bool, bool) {
+	for _, d := range st.Fields {
+		if fieldName, typeName, constant := field(d); fieldName != nil && fieldName.Name == name {
+			return typeName, constant, true
+		}
+	}
+	return nil, false, false
+}
+
+// Method gives the method called name, or nil if the struct has none.
+func (st *StructType) Method(name string) *FunctionDecl {
+	for _, method := range st.Methods {
+		if method.Name.Name == name {
+			return method
+		}
+	}
+	return nil
+}
+
+// `type Name is Type`. Types are only declared at the top level, and can be
+// used all through the program, even before their declaration. Each struct
+// declared is a type of its own, while any other type declared is only
+// another name for the type it is declared as.
+type TypeDecl struct {
+	Declaration
+	Node
+	Name *Identifier
+	Type TypeExpr
+}
+
+// the methods of a struct get to know which struct they belong to
+func NewTypeDecl(keyword *lexer.TokItem, name *Identifier, typeExpr TypeExpr) *TypeDecl {
+	td := &TypeDecl{Name: name, Type: typeExpr}
+	td.Span = keyword.TokSpan.Join(typeExpr.Location())
+	if st, ok := typeExpr.(*StructType); ok {
+		// `this` in a generic struct's method is an instance of it with its
+		// own type parameters, like `Node of E`
+		var this TypeExpr = NewNamedType(name)
+		if len(st.TypeParams) > 0 {
+			args := make([]TypeExpr, 0, len(st.TypeParams))
+			for _, param := range st.TypeParams {
+				args = append(args, NewNamedType(param))
+			}
+			this = NewGenericType(name, args)
+		}
+		for _, method := range st.Methods {
+			method.Function.This = this
+		}
+	}
+	return td
+}
+
+// whether td declares a struct, rather than another name for a type
+func (td *TypeDecl) IsStruct() bool {
+	_, ok := td.Type.(*StructType)
+	return ok
+}
+
+// ast.Visitable
+func (td TypeDecl) Accept(v Visitor) {
+	v.VisitTypeDecl(v, &td)
+}
+
+// TypeTable holds the types a program declares, by name.
+type TypeTable map[string]*TypeDecl
+
+// the struct type t names, if it names one, and what each of its type
+// parameters stands for in t: `Node of number` is the Node struct with E
+// standing for number. A generic struct must be given all of its arguments.
+func (tt TypeTable) Struct(t evaluator.NicerType) (*StructType, map[string]evaluator.NicerType, bool) {
+	name, args, _ := t.Generic()
+	decl, ok := tt[string(name)]
+	if !ok {
+		return nil, nil, false
+	}
+	st, ok := decl.Type.(*StructType)
+	if !ok || len(args) != len(st.TypeParams) {
+		return nil, nil, false
+	}
+	bindings := make(map[string]evaluator.NicerType, len(args))
+	for i, param := range st.TypeParams {
+		bindings[param.Name] = args[i]
+	}
+	return st, bindings, true
+}
+
+// Substitute gives the type t names once each type parameter in it is
+// replaced by what bindings says it stands for. Parameters bindings does not
+// mention are left as they are.
+func Substitute(t TypeExpr, bindings map[string]evaluator.NicerType) evaluator.NicerType {
+	if len(bindings) == 0 {
+		return t.NicerType()
+	}
+	switch t := t.(type) {
+	case *ListType:
+		return evaluator.ListOf(Substitute(t.Element, bindings))
+	case *MapType:
+		return evaluator.MapOf(Substitute(t.Key, bindings), Substitute(t.Value, bindings))
+	case *NamedType:
+		if bound, ok := bindings[t.Name.Name]; ok {
+			return bound
+		}
+	case *GenericType:
+		return evaluator.NicerType(t.Name.Name + " of " + andList(substituteAll(t.Args, bindings)))
+	case *FunctionType:
+		name := "function"
+		if len(t.TypeParams) > 0 {
+			name += " of " + andList(identNames(t.TypeParams))
+		}
+		if len(t.Params) > 0 {
+			name += ", taking " + andList(substituteAll(t.Params, bindings))
+		}
+		if t.Returns != nil {
+			name += ", returning " + string(Substitute(t.Returns, bindings))
+		}
+		return evaluator.NicerType(name)
+	}
+	return t.NicerType()
+}
+
+func substituteAll(types []TypeExpr, bindings map[string]evaluator.NicerType) []string {
+	names := make([]string, 0, len(types))
+	for _, t := range types {
+		names = append(names, string(Substitute(t, bindings)))
+	}
+	return names
+}
+
+// Bind works out what the type parameters params stand for from a value of
+// type actual being used where t is expected, adding each one bindings does
+// not have yet. Types that say nothing about their elements, like that of
+// `nothing` or an empty list, bind nothing.
+func Bind(t TypeExpr, actual evaluator.NicerType, params []*Identifier, bindings map[string]evaluator.NicerType) {
+	if actual == evaluator.NT_range {
+		actual = evaluator.ListOf(evaluator.NT_number)
+	}
+	switch t := t.(type) {
+	case *NamedType:
+		switch actual {
+		case "", evaluator.NT_nothing, evaluator.NT_list:
+			return
+		}
+		for _, param := range params {
+			if _, bound := bindings[param.Name]; param.Name == t.Name.Name && !bound {
+				bindings[param.Name] = actual
+			}
+		}
+	case *ListType:
+		if element, ok := actual.ElementType(); ok {
+			Bind(t.Element, element, params, bindings)
+		}
+	case *MapType:
+		if key, value, ok := actual.KeyValueTypes(); ok {
+			Bind(t.Key, key, params, bindings)
+			Bind(t.Value, value, params, bindings)
+		}
+	case *GenericType:
+		if name, args, ok := actual.Generic(); ok && string(name) == t.Name.Name && len(args) == len(t.Args) {
+			for i, arg := range t.Args {
+				Bind(arg, args[i], params, bindings)
+			}
+		}
+	}
+}
+
+func identNames(idents []*Identifier) []string {
+	names := make([]string, 0, len(idents))
+	for _, ident := range idents {
+		names = append(names, ident.Name)
+	}
+	return names
+}
+
+func typeNames(types []TypeExpr) []string {
+	names := make([]string, 0, len(types))
+	for _, t := range types {
+		names = append(names, string(t.NicerType()))
+	}
+	return names
+}
+
+// `A`, `A, and B` or `A, B, and C`
+func andList(items []string) string {
+	if len(items) < 2 {
+		return strings.Join(items, "")
+	}
+	return strings.Join(items[:len(items)-1], ", ") + ", and " + items[len(items)-1]
+}
+
+// conform returns val as a value of type t, if it is one. Empty lists of
+// unknown type take on the declared list or map type, and ranges stand in for
+// lists of numbers.
+func conform(t evaluator.NicerType, val *evaluator.NicerValue) (*evaluator.NicerValue, bool) {
+	if val == nil || val.Type == evaluator.NT_nothing {
+		return evaluator.DefaultValue(t), true
+	}
+	if val.Type == t {
+		return val, true
+	}
+	if l, ok := val.AsList(); ok && val.Type == evaluator.NT_list && len(l.Elements) == 0 {
+		if element, ok := t.ElementType(); ok {
+			return evaluator.NewList(element, nil), true
+		}
+		if key, value, ok := t.KeyValueTypes(); ok {
+			return evaluator.NewMap(key, value), true
+		}
+	}
+	if _, ok := val.AsRange(); ok {
+		return val, t == evaluator.ListOf(evaluator.NT_number)
+	}
+	return val, false
+}
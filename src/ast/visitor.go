@@ -9,27 +9,89 @@ type Visitor interface {
 	VisitNumberLiteral(v Visitor, nl *NumberLiteral)
 	VisitBooleanLiteral(v Visitor, bl *BooleanLiteral)
 	VisitStringLiteral(v Visitor, sl *StringLiteral)
+	VisitNothingLiteral(v Visitor, nl *NothingLiteral)
 	VisitIdentifier(v Visitor, id *Identifier)
 	VisitFunctionCall(v Visitor, fc *FunctionCall)
+	VisitFieldExpr(v Visitor, fe *FieldExpr)
+	VisitFunctionLiteral(v Visitor, fl *FunctionLiteral)
+	VisitBinaryExpr(v Visitor, be *BinaryExpr)
+	VisitUnaryExpr(v Visitor, ue *UnaryExpr)
+	VisitComparisonChain(v Visitor, cc *ComparisonChain)
+	VisitNothingCheck(v Visitor, nc *NothingCheck)
+	VisitGroupedExpr(v Visitor, ge *GroupedExpr)
+	VisitListLiteral(v Visitor, ll *ListLiteral)
+	VisitMapLiteral(v Visitor, ml *MapLiteral)
+	VisitStructLiteral(v Visitor, sl *StructLiteral)
+	VisitRangeLiteral(v Visitor, rl *RangeLiteral)
+	VisitIndexExpr(v Visitor, ie *IndexExpr)
+	VisitSliceExpr(v Visitor, se *SliceExpr)
+	VisitPrimitiveType(v Visitor, pt *PrimitiveType)
+	VisitListType(v Visitor, lt *ListType)
+	VisitMapType(v Visitor, mt *MapType)
+	VisitNamedType(v Visitor, nt *NamedType)
+	VisitGenericType(v Visitor, gt *GenericType)
+	VisitFunctionType(v Visitor, ft *FunctionType)
+	VisitStructType(v Visitor, st *StructType)
 	VisitDeclaration(v Visitor, d Declaration)
 	VisitVarDecl(v Visitor, vd *VarDecl)
 	VisitConstDecl(v Visitor, cd *ConstDecl)
+	VisitFunctionDecl(v Visitor, fd *FunctionDecl)
+	VisitTypeDecl(v Visitor, td *TypeDecl)
 	VisitProgram(v Visitor, p *Program)
 	VisitStatement(v Visitor, s Statement)
 	VisitVarAssignment(v Visitor, va *VarAssignment)
+	VisitIndexAssignment(v Visitor, ia *IndexAssignment)
+	VisitFieldAssignment(v Visitor, fa *FieldAssignment)
+	VisitReturnStmt(v Visitor, rs *ReturnStmt)
+	VisitIfStmt(v Visitor, is *IfStmt)
+	VisitForLoop(v Visitor, fl *ForLoop)
+	VisitWhileLoop(v Visitor, wl *WhileLoop)
+	VisitStopStmt(v Visitor, ss *StopStmt)
+	VisitSkipStmt(v Visitor, ss *SkipStmt)
 }
 
 type DefaultVisitor struct{}
 
-func (v *DefaultVisitor) Visit(vis Visitable)                             {}
-func (*DefaultVisitor) VisitNumberLiteral(v Visitor, nl *NumberLiteral)   {}
-func (*DefaultVisitor) VisitBooleanLiteral(v Visitor, bl *BooleanLiteral) {}
-func (*DefaultVisitor) VisitStringLiteral(v Visitor, sl *StringLiteral)   {}
-func (*DefaultVisitor) VisitIdentifier(v Visitor, id *Identifier)         {}
-func (*DefaultVisitor) VisitFunctionCall(v Visitor, fc *FunctionCall)     {}
-func (*DefaultVisitor) VisitDeclaration(v Visitor, d Declaration)         {}
-func (*DefaultVisitor) VisitVarDecl(v Visitor, vd *VarDecl)               {}
-func (*DefaultVisitor) VisitConstDecl(v Visitor, cd *ConstDecl)           {}
-func (*DefaultVisitor) VisitProgram(v Visitor, p *Program)                {}
-func (*DefaultVisitor) VisitStatement(v Visitor, s Statement)             {}
-func (*DefaultVisitor) VisitVarAssignment(v Visitor, va *VarAssignment)   {}
+func (v *DefaultVisitor) Visit(vis Visitable)                               {}
+func (*DefaultVisitor) VisitNumberLiteral(v Visitor, nl *NumberLiteral)     {}
+func (*DefaultVisitor) VisitBooleanLiteral(v Visitor, bl *BooleanLiteral)   {}
+func (*DefaultVisitor) VisitStringLiteral(v Visitor, sl *StringLiteral)     {}
+func (*DefaultVisitor) VisitNothingLiteral(v Visitor, nl *NothingLiteral)   {}
+func (*DefaultVisitor) VisitIdentifier(v Visitor, id *Identifier)           {}
+func (*DefaultVisitor) VisitFunctionCall(v Visitor, fc *FunctionCall)       {}
+func (*DefaultVisitor) VisitFieldExpr(v Visitor, fe *FieldExpr)             {}
+func (*DefaultVisitor) VisitFunctionLiteral(v Visitor, fl *FunctionLiteral) {}
+func (*DefaultVisitor) VisitBinaryExpr(v Visitor, be *BinaryExpr)           {}
+func (*DefaultVisitor) VisitUnaryExpr(v Visitor, ue *UnaryExpr)             {}
+func (*DefaultVisitor) VisitComparisonChain(v Visitor, cc *ComparisonChain) {}
+func (*DefaultVisitor) VisitNothingCheck(v Visitor, nc *NothingCheck)       {}
+func (*DefaultVisitor) VisitGroupedExpr(v Visitor, ge *GroupedExpr)         {}
+func (*DefaultVisitor) VisitListLiteral(v Visitor, ll *ListLiteral)         {}
+func (*DefaultVisitor) VisitMapLiteral(v Visitor, ml *MapLiteral)           {}
+func (*DefaultVisitor) VisitStructLiteral(v Visitor, sl *StructLiteral)     {}
+func (*DefaultVisitor) VisitRangeLiteral(v Visitor, rl *RangeLiteral)       {}
+func (*DefaultVisitor) VisitIndexExpr(v Visitor, ie *IndexExpr)             {}
+func (*DefaultVisitor) VisitSliceExpr(v Visitor, se *SliceExpr)             {}
+func (*DefaultVisitor) VisitPrimitiveType(v Visitor, pt *PrimitiveType)     {}
+func (*DefaultVisitor) VisitListType(v Visitor, lt *ListType)               {}
+func (*DefaultVisitor) VisitMapType(v Visitor, mt *MapType)                 {}
+func (*DefaultVisitor) VisitNamedType(v Visitor, nt *NamedType)             {}
+func (*DefaultVisitor) VisitGenericType(v Visitor, gt *GenericType)         {}
+func (*DefaultVisitor) VisitFunctionType(v Visitor, ft *FunctionType)       {}
+func (*DefaultVisitor) VisitStructType(v Visitor, st *StructType)           {}
+func (*DefaultVisitor) VisitDeclaration(v Visitor, d Declaration)           {}
+func (*DefaultVisitor) VisitVarDecl(v Visitor, vd *VarDecl)                 {}
+func (*DefaultVisitor) VisitConstDecl(v Visitor, cd *ConstDecl)             {}
+func (*DefaultVisitor) VisitFunctionDecl(v Visitor, fd *FunctionDecl)       {}
+func (*DefaultVisitor) VisitTypeDecl(v Visitor, td *TypeDecl)               {}
+func (*DefaultVisitor) VisitProgram(v Visitor, p *Program)                  {}
+func (*DefaultVisitor) VisitStatement(v Visitor, s Statement)               {}
+func (*DefaultVisitor) VisitVarAssignment(v Visitor, va *VarAssignment)     {}
+func (*DefaultVisitor) VisitIndexAssignment(v Visitor, ia *IndexAssignment) {}
+func (*DefaultVisitor) VisitFieldAssignment(v Visitor, fa *FieldAssignment) {}
+func (*DefaultVisitor) VisitReturnStmt(v Visitor, rs *ReturnStmt)           {}
+func (*DefaultVisitor) VisitIfStmt(v Visitor, is *IfStmt)                   {}
+func (*DefaultVisitor) VisitForLoop(v Visitor, fl *ForLoop)                 {}
+func (*DefaultVisitor) VisitWhileLoop(v Visitor, wl *WhileLoop)             {}
+func (*DefaultVisitor) VisitStopStmt(v Visitor, ss *StopStmt)               {}
+func (*DefaultVisitor) VisitSkipStmt(v Visitor, ss *SkipStmt)               {}
@@ -2,27 +2,40 @@ package evaluator
 
 import "fmt"
 
-type NicerFunction func(parameters []NicerValue) *NicerValue
+// a built-in function, given every argument of a call. A nil result is
+// nothing.
+type NicerFunction func(parameters []*NicerValue) (*NicerValue, *RuntimeError)
 
 var BuiltInFunctions = map[string]NicerFunction{
 	"Print":     Print,
 	"PrintLine": PrintLine,
+	"Append":    BuiltInAppend,
 }
 
-// TODO: Proper Expr
-func Print(parameters []NicerValue) *NicerValue {
+// writes each argument in turn
+func Print(parameters []*NicerValue) (*NicerValue, *RuntimeError) {
 	for _, param := range parameters {
-		v := param.Value
-		fmt.Print(v)
+		fmt.Print(param.String())
 	}
-	return nil
+	return nil, nil
 }
 
-// TODO: Proper Expr
-func PrintLine(parameters []NicerValue) *NicerValue {
-	for _, param := range parameters {
-		v := param.Value
-		fmt.Println(v)
+// writes each argument in turn, then ends the line
+func PrintLine(parameters []*NicerValue) (*NicerValue, *RuntimeError) {
+	Print(parameters)
+	fmt.Println()
+	return nil, nil
+}
+
+// `do Append to List, A, and B` adds A and then B to the end of List
+func BuiltInAppend(parameters []*NicerValue) (*NicerValue, *RuntimeError) {
+	if len(parameters) < 2 {
+		return nil, &RuntimeError{Reason: "Append needs a list, and something to add to it"}
+	}
+	for _, param := range parameters[1:] {
+		if err := Append(parameters[0], param); err != nil {
+			return nil, err
+		}
 	}
-	return nil
+	return nil, nil
 }
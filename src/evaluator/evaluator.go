@@ -2,6 +2,8 @@ package evaluator
 
 import (
 	"fmt"
+	"nicer-syntax/lexer"
+	"unicode/utf8"
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/fatih/color"
@@ -16,6 +18,133 @@ type NicerValue struct {
 	Value interface{}
 }
 
+func NewNumber(n float64) *NicerValue {
+	return &NicerValue{Type: NT_number, Value: n}
+}
+
+func NewBoolean(b bool) *NicerValue {
+	return &NicerValue{Type: NT_boolean, Value: b}
+}
+
+func NewString(s string) *NicerValue {
+	return &NicerValue{Type: NT_string, Value: s}
+}
+
+// the value of `nothing` before it is given a type, by being declared or
+// assigned as a value of that type
+func NewNothing() *NicerValue {
+	return &NicerValue{Type: NT_nothing}
+}
+
+// whether the value is nothing, either untyped or a struct reference that
+// refers to nothing. Empty lists, maps and strings and zeroes are not.
+func (nv *NicerValue) IsNothing() bool {
+	return nv == nil || nv.Value == nil
+}
+
+func (nv *NicerValue) AsNumber() (float64, bool) {
+	if nv == nil {
+		return 0, false
+	}
+	n, ok := nv.Value.(float64)
+	return n, ok && nv.Type == NT_number
+}
+
+func (nv *NicerValue) AsBoolean() (bool, bool) {
+	if nv == nil {
+		return false, false
+	}
+	b, ok := nv.Value.(bool)
+	return b, ok && nv.Type == NT_boolean
+}
+
+func (nv *NicerValue) AsString() (string, bool) {
+	if nv == nil {
+		return "", false
+	}
+	s, ok := nv.Value.(string)
+	return s, ok && nv.Type == NT_string
+}
+
+// how many elements a collection has; strings count characters, not bytes
+func (nv *NicerValue) Len() (int, bool) {
+	if l, ok := nv.AsList(); ok {
+		return len(l.Elements), true
+	}
+	if r, ok := nv.AsRange(); ok {
+		return r.Len(), true
+	}
+	if s, ok := nv.AsString(); ok {
+		return utf8.RuneCountInString(s), true
+	}
+	if m, ok := nv.AsMap(); ok {
+		return m.Len(), true
+	}
+	return 0, false
+}
+
+// the name of the value's type, for error messages
+func (nv *NicerValue) TypeName() string {
+	if nv == nil {
+		return "nothing"
+	}
+	return string(nv.Type)
+}
+
+func (nv *NicerValue) Equals(other *NicerValue) bool {
+	if nv.IsNothing() || other.IsNothing() {
+		return nv.IsNothing() == other.IsNothing()
+	}
+	if nv.Type != other.Type {
+		return false
+	}
+	if l, ok := nv.AsList(); ok {
+		r, ok := other.AsList()
+		return ok && l.equals(r)
+	}
+	if l, ok := nv.AsRange(); ok {
+		r, ok := other.AsRange()
+		return ok && l.equals(r)
+	}
+	if l, ok := nv.AsMap(); ok {
+		r, ok := other.AsMap()
+		return ok && l.equals(r)
+	}
+	return nv.Value == other.Value
+}
+
+// Constant returns val as a constant holds it. Lists and maps are copied,
+// along with every list and map inside them, and the copies refuse changes;
+// whatever else shares the original can still change it.
+func Constant(val *NicerValue) *NicerValue {
+	if l, ok := val.AsList(); ok && !l.Constant {
+		elements := make([]*NicerValue, 0, len(l.Elements))
+		for _, element := range l.Elements {
+			elements = append(elements, Constant(element))
+		}
+		return &NicerValue{Type: val.Type, Value: &NicerList{Elements: elements, Constant: true}}
+	}
+	if m, ok := val.AsMap(); ok && !m.Constant {
+		copied := NewMap(m.KeyType, m.ValueType)
+		c, _ := copied.AsMap()
+		for _, key := range m.Keys() {
+			element, _ := m.Get(key)
+			c.Set(key, Constant(element))
+		}
+		c.Constant = true
+		return copied
+	}
+	return val
+}
+
+// for String() string, the value as PrintLine shows it
+func (nv *NicerValue) String() string {
+	if nv.IsNothing() {
+		return "nothing"
+	}
+	return fmt.Sprint(nv.Value)
+}
+
 type NicerType string
 
 // built-in types
@@ -23,20 +152,36 @@ const (
 	NT_number  NicerType = "number"
 	NT_boolean NicerType = "boolean"
 	NT_string  NicerType = "string"
+	// the type of `nothing` itself, which takes the type of wherever it goes
+	NT_nothing NicerType = "nothing"
 )
 
-// maps a typename to whether it's defined
-// lists and structs will need to be run-time defined
-var NicerTypeList = map[NicerType]bool{
-	NT_number:  true,
-	NT_boolean: true,
-	NT_string:  true,
+// the value a variable of type t starts out with, which is also what
+// `nothing` means as a value of type t. Lists and maps start out empty, and
+// anything else, like a struct, as a reference to nothing.
+func DefaultValue(t NicerType) *NicerValue {
+	switch t {
+	case NT_number:
+		return NewNumber(0)
+	case NT_boolean:
+		return NewBoolean(false)
+	case NT_string:
+		return NewString("")
+	}
+	if element, ok := t.ElementType(); ok {
+		return NewList(element, nil)
+	}
+	if key, value, ok := t.KeyValueTypes(); ok {
+		return NewMap(key, value)
+	}
+	return &NicerValue{Type: t}
 }
 
 type RuntimeError struct {
 	Reason       string
 	VariableName string
 	Node         interface{}
+	Span         lexer.Span // where Node is in the source
 }
 
 var COLOR_ERROR = color.New(color.FgHiRed).Add(color.Underline).Add(color.Bold).Sprintf
@@ -45,6 +190,14 @@ var COLOR_TOKEN = color.New(color.FgCyan).Sprintf
 
 // for interface error.Error()
 func (re *RuntimeError) Error() string {
+	if re.Span.IsValid() {
+		message := fmt.Sprintf("%v: %v %v", re.Span, COLOR_ERROR("RUNTIME ERROR:"), COLOR_KEYWORD(re.Reason))
+		if re.VariableName != "" {
+			message += fmt.Sprintf(" (%v)", COLOR_TOKEN(re.VariableName))
+		}
+		return message
+	}
+	// nodes built by hand have no position, show the node itself instead
 	return fmt.Sprintf("%v %v (%v) at `%v`",
 		COLOR_ERROR("RUNTIME ERROR:"),
 		COLOR_KEYWORD(re.Reason),
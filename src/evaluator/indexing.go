@@ -0,0 +1,147 @@
+package evaluator
+
+import (
+	"fmt"
+	"math"
+)
+
+// Index returns the element of collection at index, counting from 0. Strings
+// are indexed by character, giving a one-character string.
+func Index(collection, index *NicerValue) (*NicerValue, *RuntimeError) {
+	if m, ok := collection.AsMap(); ok {
+		// missing keys read as the default value, without being added
+		if err := m.check(index, nil, false); err != nil {
+			return nil, err
+		}
+		if val, ok := m.Get(index); ok {
+			return val, nil
+		}
+		return DefaultValue(m.ValueType), nil
+	}
+	length, ok := collection.Len()
+	if !ok {
+		return nil, &RuntimeError{Reason: fmt.Sprintf("Cannot index %s", collection.TypeName())}
+	}
+	n, ok := index.AsNumber()
+	if !ok {
+		return nil, &RuntimeError{Reason: fmt.Sprintf("Indexes of %s must be numbers, got %s", collection.TypeName(), index.TypeName())}
+	}
+	i, err := checkIndex(collection, length, n)
+	if err != nil {
+		return nil, err
+	}
+	return elementAt(collection, i), nil
+}
+
+// Slice returns the elements of collection at each index of indices, in the
+// order indices gives them. Slicing a string gives a string, anything else a
+// list.
+func Slice(collection *NicerValue, indices *NicerRange) (*NicerValue, *RuntimeError) {
+	length, ok := collection.Len()
+	if _, isMap := collection.AsMap(); !ok || isMap {
+		return nil, &RuntimeError{Reason: fmt.Sprintf("Cannot slice %s", collection.TypeName())}
+	}
+	// a range only ever runs one way, so once its first and last index are in
+	// the collection every index between is, and there are at most length
+	if last := indices.Len() - 1; last >= 0 {
+		for _, n := range []int{0, last} {
+			if _, err := checkIndex(collection, length, indices.At(n)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	size := indices.Len()
+	if size > length {
+		size = length
+	}
+	positions := make([]int, 0, size)
+	for n := 0; n < indices.Len(); n++ {
+		i, err := checkIndex(collection, length, indices.At(n))
+		if err != nil {
+			return nil, err
+		}
+		positions = append(positions, i)
+	}
+	if s, ok := collection.AsString(); ok {
+		runes := []rune(s)
+		sliced := make([]rune, 0, len(positions))
+		for _, i := range positions {
+			sliced = append(sliced, runes[i])
+		}
+		return NewString(string(sliced)), nil
+	}
+	elements := make([]*NicerValue, 0, len(positions))
+	for _, i := range positions {
+		elements = append(elements, elementAt(collection, i))
+	}
+	elementType, _ := collection.Type.ElementType()
+	if _, isRange := collection.AsRange(); isRange {
+		elementType = NT_number
+	}
+	return NewList(elementType, elements), nil
+}
+
+// SetIndex replaces the element of a list at index, or sets the value of a
+// map's key, adding the key if it is new. Assigning nothing stores the
+// default value of the element type.
+func SetIndex(collection, index, val *NicerValue) *RuntimeError {
+	if m, ok := collection.AsMap(); ok {
+		if m.Constant {
+			return &RuntimeError{Reason: fmt.Sprintf("Cannot change an element of a constant %s", collection.TypeName())}
+		}
+		if val == nil || val.Type == NT_nothing {
+			val = DefaultValue(m.ValueType)
+		}
+		if err := m.check(index, val, true); err != nil {
+			return err
+		}
+		m.Set(index, val)
+		return nil
+	}
+	l, ok := collection.AsList()
+	if !ok {
+		return &RuntimeError{Reason: fmt.Sprintf("Cannot assign to an element of %s", collection.TypeName())}
+	}
+	if l.Constant {
+		return &RuntimeError{Reason: fmt.Sprintf("Cannot change an element of a constant %s", collection.TypeName())}
+	}
+	n, ok := index.AsNumber()
+	if !ok {
+		return &RuntimeError{Reason: fmt.Sprintf("Indexes of %s must be numbers, got %s", collection.TypeName(), index.TypeName())}
+	}
+	i, err := checkIndex(collection, len(l.Elements), n)
+	if err != nil {
+		return err
+	}
+	elementType, _ := collection.Type.ElementType()
+	if val == nil || val.Type == NT_nothing {
+		val = DefaultValue(elementType)
+	}
+	if val.Type != elementType {
+		return &RuntimeError{Reason: fmt.Sprintf("Elements of %s must be %s, got %s", collection.TypeName(), elementType, val.TypeName())}
+	}
+	l.Elements[i] = val
+	return nil
+}
+
+func checkIndex(collection *NicerValue, length int, n float64) (int, *RuntimeError) {
+	if n != math.Floor(n) {
+		return 0, &RuntimeError{Reason: fmt.Sprintf("Indexes must be whole numbers, got %v", n)}
+	}
+	if n < 0 || n >= float64(length) {
+		return 0, &RuntimeError{Reason: fmt.Sprintf("Index %v is out of range for %s of length %d", n, collection.TypeName(), length)}
+	}
+	return int(n), nil
+}
+
+// the i-th element, for an index already checked against the length
+func elementAt(collection *NicerValue, i int) *NicerValue {
+	if l, ok := collection.AsList(); ok {
+		return l.Elements[i]
+	}
+	if r, ok := collection.AsRange(); ok {
+		return NewNumber(r.At(i))
+	}
+	s, _ := collection.AsString()
+	return NewString(string([]rune(s)[i]))
+}
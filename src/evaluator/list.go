@@ -0,0 +1,90 @@
+package evaluator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// the type of a list whose element type is not known, like an empty literal
+const NT_list NicerType = "list"
+
+// list types are named after their elements, e.g. `list of number`
+func ListOf(element NicerType) NicerType {
+	return NicerType("list of " + string(element))
+}
+
+// the element type of a list type, if it is one
+func (nt NicerType) ElementType() (NicerType, bool) {
+	if !strings.HasPrefix(string(nt), "list of ") {
+		return "", false
+	}
+	return NicerType(strings.TrimPrefix(string(nt), "list of ")), true
+}
+
+// NicerList holds a list's elements. Values holding the same list share it.
+type NicerList struct {
+	Elements []*NicerValue
+	// refuses changes, like every list a constant holds
+	Constant bool
+}
+
+// a list of elements of elementType, or of unknown type when that is empty
+func NewList(elementType NicerType, elements []*NicerValue) *NicerValue {
+	listType := NT_list
+	if elementType != "" {
+		listType = ListOf(elementType)
+	}
+	return &NicerValue{Type: listType, Value: &NicerList{Elements: elements}}
+}
+
+func (nv *NicerValue) AsList() (*NicerList, bool) {
+	if nv == nil {
+		return nil, false
+	}
+	l, ok := nv.Value.(*NicerList)
+	return l, ok
+}
+
+// Append adds val to the end of a list. An empty list of unknown type takes
+// on the type of its first element.
+func Append(collection, val *NicerValue) *RuntimeError {
+	l, ok := collection.AsList()
+	if !ok {
+		return &RuntimeError{Reason: fmt.Sprintf("Cannot append to %s", collection.TypeName())}
+	}
+	if l.Constant {
+		return &RuntimeError{Reason: fmt.Sprintf("Cannot append to a constant %s", collection.TypeName())}
+	}
+	if val == nil || val.Type == NT_nothing {
+		return &RuntimeError{Reason: "Lists cannot contain nothing"}
+	}
+	if collection.Type == NT_list {
+		collection.Type = ListOf(val.Type)
+	}
+	if elementType, _ := collection.Type.ElementType(); val.Type != elementType {
+		return &RuntimeError{Reason: fmt.Sprintf("Elements of %s must be %s, got %s", collection.TypeName(), elementType, val.TypeName())}
+	}
+	l.Elements = append(l.Elements, val)
+	return nil
+}
+
+func (nl *NicerList) equals(other *NicerList) bool {
+	if len(nl.Elements) != len(other.Elements) {
+		return false
+	}
+	for i, element := range nl.Elements {
+		if !element.Equals(other.Elements[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// for String() string, like `[69,420,"a string"]`
+func (nl *NicerList) String() string {
+	elements := make([]string, 0, len(nl.Elements))
+	for _, element := range nl.Elements {
+		elements = append(elements, quoted(element))
+	}
+	return "[" + strings.Join(elements, ",") + "]"
+}
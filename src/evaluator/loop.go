@@ -0,0 +1,71 @@
+package evaluator
+
+import "fmt"
+
+// Iterator goes through what a for loop gives its variable: the numbers of a
+// range, the elements of a list, the characters of a string or the keys of a
+// map. It goes through the collection as it was when the loop started, so
+// elements added, replaced or removed while looping are not seen; ranges
+// never change, and are not expanded to go through them.
+type Iterator struct {
+	values []*NicerValue // nil for a range
+	r      *NicerRange
+	next   int
+}
+
+func NewIterator(collection *NicerValue) (*Iterator, *RuntimeError) {
+	if r, ok := collection.AsRange(); ok {
+		return &Iterator{r: r}, nil
+	}
+	if l, ok := collection.AsList(); ok {
+		return &Iterator{values: append([]*NicerValue(nil), l.Elements...)}, nil
+	}
+	if m, ok := collection.AsMap(); ok {
+		return &Iterator{values: append([]*NicerValue(nil), m.Keys()...)}, nil
+	}
+	if s, ok := collection.AsString(); ok {
+		values := make([]*NicerValue, 0, len(s))
+		for _, c := range s {
+			values = append(values, NewString(string(c)))
+		}
+		return &Iterator{values: values}, nil
+	}
+	return nil, &RuntimeError{Reason: fmt.Sprintf("Cannot loop over %s", collection.TypeName())}
+}
+
+// Next gives the next value, or false once there are none left.
+func (it *Iterator) Next() (*NicerValue, bool) {
+	i := it.next
+	if it.r != nil {
+		if i >= it.r.Len() {
+			return nil, false
+		}
+		it.next++
+		return NewNumber(it.r.At(i)), true
+	}
+	if i >= len(it.values) {
+		return nil, false
+	}
+	it.next++
+	return it.values[i], true
+}
+
+// IterationType is the type a for loop over a collection of type nt gives its
+// variable. An empty list of unknown type gives the empty type.
+func (nt NicerType) IterationType() (NicerType, bool) {
+	switch nt {
+	case NT_range:
+		return NT_number, true
+	case NT_string:
+		return NT_string, true
+	case NT_list:
+		return "", true
+	}
+	if element, ok := nt.ElementType(); ok {
+		return element, true
+	}
+	if key, _, ok := nt.KeyValueTypes(); ok {
+		return key, true
+	}
+	return "", false
+}
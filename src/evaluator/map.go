@@ -0,0 +1,133 @@
+package evaluator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// map types are named after their keys and values, e.g. `map of string to number`
+func MapOf(key, value NicerType) NicerType {
+	return NicerType(fmt.Sprintf("map of %s to %s", key, value))
+}
+
+// the key and value types of a map type, if it is one. Every nested `map of`
+// has its own `to`, so the key type ends at the first unmatched one.
+func (nt NicerType) KeyValueTypes() (NicerType, NicerType, bool) {
+	if !strings.HasPrefix(string(nt), "map of ") {
+		return "", "", false
+	}
+	words := strings.Split(strings.TrimPrefix(string(nt), "map of "), " ")
+	depth := 0
+	for i, word := range words {
+		switch {
+		case word == "map" && i+1 < len(words) && words[i+1] == "of":
+			depth++
+		case word == "to" && depth > 0:
+			depth--
+		case word == "to":
+			key := strings.Join(words[:i], " ")
+			value := strings.Join(words[i+1:], " ")
+			return NicerType(key), NicerType(value), true
+		}
+	}
+	return "", "", false
+}
+
+// NicerMap maps keys to values, remembering the order keys were added in.
+// Values holding the same map share it.
+type NicerMap struct {
+	KeyType   NicerType
+	ValueType NicerType
+	keys      []*NicerValue
+	values    map[string]*NicerValue // by mapKey
+	// refuses changes, like every map a constant holds
+	Constant bool
+}
+
+func NewMap(keyType, valueType NicerType) *NicerValue {
+	return &NicerValue{Type: MapOf(keyType, valueType), Value: &NicerMap{
+		KeyType:   keyType,
+		ValueType: valueType,
+		values:    make(map[string]*NicerValue),
+	}}
+}
+
+func (nv *NicerValue) AsMap() (*NicerMap, bool) {
+	if nv == nil {
+		return nil, false
+	}
+	m, ok := nv.Value.(*NicerMap)
+	return m, ok
+}
+
+// keys of any type are told apart by their type and how they print
+func mapKey(key *NicerValue) string {
+	if s, ok := key.AsString(); ok {
+		return fmt.Sprintf("%s:%q", key.Type, s)
+	}
+	return fmt.Sprintf("%s:%s", key.Type, key)
+}
+
+func (nm *NicerMap) Len() int {
+	return len(nm.keys)
+}
+
+// the keys in the order they were added
+func (nm *NicerMap) Keys() []*NicerValue {
+	return nm.keys
+}
+
+func (nm *NicerMap) Get(key *NicerValue) (*NicerValue, bool) {
+	val, ok := nm.values[mapKey(key)]
+	return val, ok
+}
+
+// add or replace the value of key; new keys go last
+func (nm *NicerMap) Set(key, val *NicerValue) {
+	k := mapKey(key)
+	if _, ok := nm.values[k]; !ok {
+		nm.keys = append(nm.keys, key)
+	}
+	nm.values[k] = val
+}
+
+// the type errors of using key and val with this map, if any
+func (nm *NicerMap) check(key, val *NicerValue, checkValue bool) *RuntimeError {
+	if key == nil || key.Type != nm.KeyType {
+		return &RuntimeError{Reason: fmt.Sprintf("Keys of %s must be %s, got %s", MapOf(nm.KeyType, nm.ValueType), nm.KeyType, key.TypeName())}
+	}
+	if checkValue && (val == nil || val.Type != nm.ValueType) {
+		return &RuntimeError{Reason: fmt.Sprintf("Values of %s must be %s, got %s", MapOf(nm.KeyType, nm.ValueType), nm.ValueType, val.TypeName())}
+	}
+	return nil
+}
+
+func (nm *NicerMap) equals(other *NicerMap) bool {
+	if nm.Len() != other.Len() {
+		return false
+	}
+	for k, val := range nm.values {
+		if !val.Equals(other.values[k]) {
+			return false
+		}
+	}
+	return true
+}
+
+// for String() string, like `{"bob":123,"pat":345}`
+func (nm *NicerMap) String() string {
+	pairs := make([]string, 0, nm.Len())
+	for _, key := range nm.keys {
+		val, _ := nm.Get(key)
+		pairs = append(pairs, quoted(key)+":"+quoted(val))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// strings inside collections print with their quotes
+func quoted(nv *NicerValue) string {
+	if s, ok := nv.AsString(); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	return nv.String()
+}
@@ -0,0 +1,97 @@
+package evaluator
+
+import (
+	"fmt"
+	"math"
+)
+
+// ApplyUnary evaluates `-X` and `not X`.
+func ApplyUnary(operator string, operand *NicerValue) (*NicerValue, *RuntimeError) {
+	switch operator {
+	case "-":
+		if n, ok := operand.AsNumber(); ok {
+			return NewNumber(-n), nil
+		}
+	case "not":
+		if b, ok := operand.AsBoolean(); ok {
+			return NewBoolean(!b), nil
+		}
+	}
+	return nil, &RuntimeError{Reason: fmt.Sprintf("Cannot apply `%s` to %s", operator, operand.TypeName())}
+}
+
+// ApplyBinary evaluates every binary operator except `and` and `or`, which
+// short-circuit and so are handled by the caller.
+func ApplyBinary(operator string, left, right *NicerValue) (*NicerValue, *RuntimeError) {
+	switch operator {
+	case "==", "!=":
+		// anything can be compared with nothing
+		if left.TypeName() != right.TypeName() && left.Type != NT_nothing && right.Type != NT_nothing {
+			return nil, mismatchedOperands(operator, left, right)
+		}
+		return NewBoolean(left.Equals(right) == (operator == "==")), nil
+	}
+	if l, ok := left.AsNumber(); ok {
+		if r, ok := right.AsNumber(); ok {
+			return applyNumeric(operator, l, r)
+		}
+	}
+	if l, ok := left.AsString(); ok {
+		if r, ok := right.AsString(); ok {
+			return applyString(operator, l, r)
+		}
+	}
+	return nil, mismatchedOperands(operator, left, right)
+}
+
+func applyNumeric(operator string, l, r float64) (*NicerValue, *RuntimeError) {
+	switch operator {
+	case "+":
+		return NewNumber(l + r), nil
+	case "-":
+		return NewNumber(l - r), nil
+	case "*":
+		return NewNumber(l * r), nil
+	case "/":
+		if r == 0 {
+			return nil, &RuntimeError{Reason: "Division by zero"}
+		}
+		return NewNumber(l / r), nil
+	case "%":
+		if r == 0 {
+			return nil, &RuntimeError{Reason: "Modulo by zero"}
+		}
+		return NewNumber(math.Mod(l, r)), nil
+	case "^":
+		return NewNumber(math.Pow(l, r)), nil
+	case ">":
+		return NewBoolean(l > r), nil
+	case ">=":
+		return NewBoolean(l >= r), nil
+	case "<":
+		return NewBoolean(l < r), nil
+	case "<=":
+		return NewBoolean(l <= r), nil
+	}
+	return nil, &RuntimeError{Reason: fmt.Sprintf("Cannot apply `%s` to numbers", operator)}
+}
+
+func applyString(operator string, l, r string) (*NicerValue, *RuntimeError) {
+	switch operator {
+	case "+":
+		return NewString(l + r), nil
+	case ">":
+		return NewBoolean(l > r), nil
+	case ">=":
+		return NewBoolean(l >= r), nil
+	case "<":
+		return NewBoolean(l < r), nil
+	case "<=":
+		return NewBoolean(l <= r), nil
+	}
+	return nil, &RuntimeError{Reason: fmt.Sprintf("Cannot apply `%s` to strings", operator)}
+}
+
+func mismatchedOperands(operator string, left, right *NicerValue) *RuntimeError {
+	return &RuntimeError{Reason: fmt.Sprintf("Cannot apply `%s` to %s and %s", operator, left.TypeName(), right.TypeName())}
+}
@@ -0,0 +1,102 @@
+package evaluator
+
+import (
+	"fmt"
+	"math"
+)
+
+const NT_range NicerType = "range"
+
+// the most numbers a range can have, past which numbers are too far apart
+// to count them one by one
+const MaxRangeLength = 1 << 53
+
+// the most numbers a range can put into a list literal, as each of them then
+// takes up memory, unlike in a loop or slice
+const MaxListedRangeLength = 1 << 24
+
+// NicerRange is an inclusive run of whole numbers. Its numbers are worked
+// out when asked for, so a range costs the same however long it is.
+type NicerRange struct {
+	Start float64
+	Step  float64 // negative for descending ranges
+	Count int
+}
+
+// the numbers from start to end, both included, taking every step-th one.
+// Bounds and step are floored first; start > end counts down. A range can
+// have at most MaxRangeLength numbers.
+func NewRange(start, end, step float64) (*NicerValue, *RuntimeError) {
+	if math.IsNaN(start) || math.IsInf(start, 0) || math.IsNaN(end) || math.IsInf(end, 0) {
+		return nil, &RuntimeError{Reason: fmt.Sprintf("Range bounds must be finite, got %v and %v", start, end)}
+	}
+	step = math.Floor(step)
+	if !(step >= 1) || math.IsInf(step, 0) {
+		return nil, &RuntimeError{Reason: fmt.Sprintf("Ranges must take every N-th number for a whole N of at least 1, got %v", step)}
+	}
+	start, end = math.Floor(start), math.Floor(end)
+	if start > end {
+		step = -step
+	}
+	// checked before converting, as a count too big for an int wraps around
+	count := math.Floor((end-start)/step) + 1
+	if !(count >= 0 && count <= MaxRangeLength) {
+		return nil, &RuntimeError{Reason: fmt.Sprintf("Ranges can have at most %d numbers, got one from %v to %v", MaxRangeLength, start, end)}
+	}
+	return &NicerValue{Type: NT_range, Value: &NicerRange{Start: start, Step: step, Count: int(count)}}, nil
+}
+
+// a range with no numbers in it, e.g. `from start to end` of an empty list
+func NewEmptyRange() *NicerValue {
+	return &NicerValue{Type: NT_range, Value: &NicerRange{Step: 1}}
+}
+
+func (nv *NicerValue) AsRange() (*NicerRange, bool) {
+	if nv == nil {
+		return nil, false
+	}
+	r, ok := nv.Value.(*NicerRange)
+	return r, ok
+}
+
+func (nr *NicerRange) Len() int {
+	return nr.Count
+}
+
+// the i-th number of the range, counting from 0
+func (nr *NicerRange) At(i int) float64 {
+	return nr.Start + float64(i)*nr.Step
+}
+
+// every number of the range as a number value, for a list literal. Only a
+// range of at most MaxListedRangeLength numbers can be listed.
+func (nr *NicerRange) Values() ([]*NicerValue, *RuntimeError) {
+	if nr.Count > MaxListedRangeLength {
+		return nil, &RuntimeError{Reason: fmt.Sprintf("Lists can take at most %d numbers from a range, got %d", MaxListedRangeLength, nr.Count)}
+	}
+	values := make([]*NicerValue, 0, nr.Count)
+	for i := 0; i < nr.Count; i++ {
+		values = append(values, NewNumber(nr.At(i)))
+	}
+	return values, nil
+}
+
+// for String() string, as the range would be written
+func (nr *NicerRange) String() string {
+	if nr.Count == 0 {
+		return "from start to end of nothing"
+	}
+	r := fmt.Sprintf("from %v to %v", nr.Start, nr.At(nr.Count-1))
+	if step := math.Abs(nr.Step); step != 1 {
+		r = fmt.Sprintf("every %v-th %s", step, r)
+	}
+	return r
+}
+
+func (nr *NicerRange) equals(other *NicerRange) bool {
+	if nr.Count != other.Count {
+		return false
+	}
+	// the step does not matter when there is no second number
+	return nr.Count == 0 || (nr.Start == other.Start && (nr.Count == 1 || nr.Step == other.Step))
+}
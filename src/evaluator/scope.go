@@ -0,0 +1,70 @@
+package evaluator
+
+// Scope holds the values of the names declared in a block, a function call
+// or the whole program, each in the slot it was given ahead of time. Names
+// are kept only for looking up values whose slot is not known.
+type Scope struct {
+	Parent    *Scope
+	names     []string
+	values    []*NicerValue
+	constants []bool
+}
+
+// a scope inside parent, with room for size names
+func NewScope(parent *Scope, size int) *Scope {
+	return &Scope{
+		Parent:    parent,
+		names:     make([]string, size),
+		values:    make([]*NicerValue, size),
+		constants: make([]bool, size),
+	}
+}
+
+// Declare gives name its value in slot, or in a new slot after every other
+// when slot is negative.
+func (s *Scope) Declare(slot int, name string, val *NicerValue, constant bool) {
+	if slot < 0 || slot >= len(s.values) {
+		slot = len(s.values)
+		s.names = append(s.names, name)
+		s.values = append(s.values, val)
+		s.constants = append(s.constants, constant)
+		return
+	}
+	s.names[slot] = name
+	s.values[slot] = val
+	s.constants[slot] = constant
+}
+
+// the scope depth scopes out from this one
+func (s *Scope) outer(depth int) *Scope {
+	for ; depth > 0; depth-- {
+		s = s.Parent
+	}
+	return s
+}
+
+func (s *Scope) Get(depth, slot int) *NicerValue {
+	return s.outer(depth).values[slot]
+}
+
+func (s *Scope) Set(depth, slot int, val *NicerValue) {
+	s.outer(depth).values[slot] = val
+}
+
+func (s *Scope) IsConstant(depth, slot int) bool {
+	return s.outer(depth).constants[slot]
+}
+
+// Lookup finds the innermost declaration of name, as the depth and slot to
+// pass to Get. The latest declaration in a scope wins.
+func (s *Scope) Lookup(name string) (int, int, bool) {
+	for depth := 0; s != nil; depth++ {
+		for slot := len(s.names) - 1; slot >= 0; slot-- {
+			if s.names[slot] == name {
+				return depth, slot, true
+			}
+		}
+		s = s.Parent
+	}
+	return 0, 0, false
+}
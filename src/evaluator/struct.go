@@ -0,0 +1,167 @@
+package evaluator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NicerStruct holds the fields of a struct, in the order they were declared.
+// Structs are references: values holding the same struct share it, and see
+// each other's changes to its fields.
+type NicerStruct struct {
+	names     []string
+	values    map[string]*NicerValue
+	constants map[string]bool
+}
+
+// a struct of type t without any fields yet, which Declare gives it
+func NewStruct(t NicerType) *NicerValue {
+	return &NicerValue{Type: t, Value: &NicerStruct{
+		values:    make(map[string]*NicerValue),
+		constants: make(map[string]bool),
+	}}
+}
+
+func (nv *NicerValue) AsStruct() (*NicerStruct, bool) {
+	if nv == nil {
+		return nil, false
+	}
+	s, ok := nv.Value.(*NicerStruct)
+	return s, ok
+}
+
+// Declare adds a field with its first value.
+func (ns *NicerStruct) Declare(name string, val *NicerValue, constant bool) {
+	if _, ok := ns.values[name]; !ok {
+		ns.names = append(ns.names, name)
+	}
+	ns.values[name] = val
+	ns.constants[name] = constant
+}
+
+func (ns *NicerStruct) Get(name string) (*NicerValue, bool) {
+	val, ok := ns.values[name]
+	return val, ok
+}
+
+// Set replaces the value of a field, unless it is a constant or there is no
+// such field.
+func (ns *NicerStruct) Set(name string, val *NicerValue) *RuntimeError {
+	if _, ok := ns.values[name]; !ok {
+		return &RuntimeError{Reason: fmt.Sprintf("There is no field %s", name), VariableName: name}
+	}
+	if ns.constants[name] {
+		return &RuntimeError{Reason: "Cannot assign to a constant field", VariableName: name}
+	}
+	ns.values[name] = val
+	return nil
+}
+
+// for String() string, like `{Name:"bob",Age:30}`
+func (ns *NicerStruct) String() string {
+	fields := make([]string, 0, len(ns.names))
+	for _, name := range ns.names {
+		fields = append(fields, name+":"+quoted(ns.values[name]))
+	}
+	return "{" + strings.Join(fields, ",") + "}"
+}
+
+// the name and type arguments of a generic type, like Tuple and [number,
+// string] for `Tuple of number, and string`, if it is one. The arguments are
+// split up the way the parser reads them, so only the last can itself have
+// arguments.
+func (nt NicerType) Generic() (NicerType, []NicerType, bool) {
+	words := strings.Fields(strings.ReplaceAll(string(nt), ",", " ,"))
+	if len(words) < 3 || words[1] != "of" || !isTypeWord(words[0]) || builtInTypeWords[words[0]] {
+		return nt, nil, false
+	}
+	name, args := NicerType(words[0]), []NicerType{}
+	for i := 2; ; {
+		end := typeEnd(words, i)
+		args = append(args, joinType(words[i:end]))
+		switch {
+		case end+1 < len(words) && words[end] == "and" && isTypeWord(words[end+1]):
+			i = end + 1
+		case end+2 < len(words) && words[end] == "," && words[end+1] == "and" && isTypeWord(words[end+2]):
+			i = end + 2
+		case end+1 < len(words) && words[end] == "," && isTypeWord(words[end+1]):
+			i = end + 1
+			continue
+		default:
+			return name, args, end == len(words)
+		}
+		// the last argument, after `and`
+		end = typeEnd(words, i)
+		return name, append(args, joinType(words[i:end])), end == len(words)
+	}
+}
+
+// the words that start a type without being the name of one of the program's
+var builtInTypeWords = map[string]bool{
+	"number": true, "boolean": true, "string": true, "list": true, "map": true, "function": true,
+}
+
+// whether a type can start with word, rather than it being part of the type
+// around it
+func isTypeWord(word string) bool {
+	switch word {
+	case ",", "and", "of", "to", "taking", "returning":
+		return false
+	}
+	return true
+}
+
+// the index just past the type that starts at words[i]
+func typeEnd(words []string, i int) int {
+	if i >= len(words) {
+		return i
+	}
+	at := func(j int, word string) bool { return j < len(words) && words[j] == word }
+	switch words[i] {
+	case "list":
+		return typeEnd(words, i+2)
+	case "map":
+		key := typeEnd(words, i+2)
+		return typeEnd(words, key+1)
+	case "function":
+		i++
+		if at(i, ",") && at(i+1, "taking") {
+			i = typeListEnd(words, i+2)
+		}
+		if at(i, ",") && at(i+1, "returning") {
+			i = typeEnd(words, i+2)
+		} else if at(i, ",") && at(i+1, "and") && at(i+2, "returning") {
+			i = typeEnd(words, i+3)
+		}
+		return i
+	case "number", "boolean", "string":
+		return i + 1
+	}
+	if at(i+1, "of") {
+		return typeListEnd(words, i+2)
+	}
+	return i + 1
+}
+
+// the index just past the types that start at words[i], which are separated
+// by commas, with `and` before the last
+func typeListEnd(words []string, i int) int {
+	for {
+		i = typeEnd(words, i)
+		switch {
+		case i+1 < len(words) && words[i] == "and" && isTypeWord(words[i+1]):
+			return typeEnd(words, i+1)
+		case i+2 < len(words) && words[i] == "," && words[i+1] == "and" && isTypeWord(words[i+2]):
+			return typeEnd(words, i+2)
+		case i+1 < len(words) && words[i] == "," && isTypeWord(words[i+1]):
+			i++
+		default:
+			return i
+		}
+	}
+}
+
+// words back into a type, with each comma against the word before it
+func joinType(words []string) NicerType {
+	return NicerType(strings.ReplaceAll(strings.Join(words, " "), " ,", ","))
+}
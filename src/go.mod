@@ -3,12 +3,12 @@ module nicer-syntax
 go 1.18
 
 require (
+	github.com/davecgh/go-spew v1.1.1
 	github.com/db47h/lex v1.2.1
 	github.com/fatih/color v1.13.0
 )
 
 require (
-	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/mattn/go-colorable v0.1.9 // indirect
 	github.com/mattn/go-isatty v0.0.14 // indirect
 	golang.org/x/sys v0.0.0-20210630005230-0f9fa26af87c // indirect
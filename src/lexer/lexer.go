@@ -3,40 +3,134 @@ package lexer
 // https://pkg.go.dev/github.com/db47h/lex/state#example-package-Go
 
 import (
+	"strings"
 	"unicode"
+	"unicode/utf8"
 
 	"github.com/db47h/lex"
 	"github.com/db47h/lex/state"
 )
 
+// a first-line comment that switches a file over to indentation mode
+const IndentationPragma = "# nicer: indentation"
+
 type NicerLexer struct {
 	lex.Lexer
+	// In indentation mode, the lexer emits ItemIndent when a line is indented
+	// further than the one before, and ItemDedent (followed by ItemSemicolon)
+	// for every level it returns by. Set it before lexing, or start the file
+	// with IndentationPragma.
+	Indentation bool
+	indents     []string  // indentation of each open level
+	indentRune  rune      // whether the file indents with spaces or tabs
+	lineStart   bool      // whether the next token starts a line
+	last        lex.Token // the last token handed out by Lex
+	parens      int       // how many parentheses are open
+	tokenEnd    int       // offset just past the last token lexed
+}
+
+// A newline ends the statement, unless the line stops somewhere the statement
+// obviously carries on: after one of these tokens, or inside parentheses.
+var continuesLine = map[lex.Token]bool{
+	OP_Comma:      true,
+	OP_Lparen:     true,
+	KW_Containing: true,
+	KW_Where:      true,
+	KW_And:        true,
+	KW_Or:         true,
+	KW_Not:        true,
+	OP_Eq:         true,
+	OP_Neq:        true,
+	OP_Gt:         true,
+	OP_Lt:         true,
+	OP_GtEq:       true,
+	OP_LtEq:       true,
+	OP_Plus:       true,
+	OP_Minus:      true,
+	OP_Star:       true,
+	OP_Slash:      true,
+	OP_Percent:    true,
+	OP_Caret:      true,
 }
 
 func NewLexer(file *lex.File) *NicerLexer {
-	l := &NicerLexer{}
-	l.Lexer = *lex.NewLexer(file, l.program)
+	l := &NicerLexer{lineStart: true, last: ItemSemicolon}
+	l.Lexer = *lex.NewLexer(file, l.tracking(l.program))
 	return l
 }
 
+// Lex wraps lex.Lexer.Lex, keeping track of the last token.
+func (nl *NicerLexer) Lex() (lex.Token, int, interface{}) {
+	tok, pos, v := nl.Lexer.Lex()
+	nl.last = tok
+	return tok, pos, v
+}
+
 func (nl *NicerLexer) LexAll() []TokItem {
 	var tokens []TokItem
+	var ends []int
 	for tok, pos, v := nl.Lex(); tok != ItemEOF; tok, pos, v = nl.Lex() {
-		tokens = append(tokens, TokItem{tok, TokenString[tok], pos, v})
+		tokens = append(tokens, TokItem{tok, TokenString[tok], pos, v, Span{}})
+		ends = append(ends, nl.tokenEnd)
+	}
+	file := nl.File()
+	for i := range tokens {
+		// a state can emit more than one token at once (like `-` and `true`
+		// from `-true`), so stop each token where the next one starts
+		end := ends[i]
+		if i+1 < len(tokens) && tokens[i+1].TokPosition > tokens[i].TokPosition && tokens[i+1].TokPosition < end {
+			end = tokens[i+1].TokPosition
+		}
+		start, stop := file.Position(tokens[i].TokPosition), file.Position(end)
+		tokens[i].TokSpan = Span{file.Name(), start.Line, start.Column, stop.Line, stop.Column}
 	}
 	return tokens
 }
 
+// wraps a state function so that whenever it finishes a token, the offset
+// just past that token is kept for the token's span.
+func (nl *NicerLexer) tracking(fn lex.StateFn) lex.StateFn {
+	return func(s *lex.State) lex.StateFn {
+		if next := fn(s); next != nil {
+			return nl.tracking(next)
+		}
+		nl.tokenEnd = s.Pos()
+		if r := s.Current(); r != lex.EOF {
+			nl.tokenEnd += utf8.RuneLen(r)
+		}
+		return nil
+	}
+}
+
 func (nl *NicerLexer) program(s *lex.State) lex.StateFn {
+	if nl.lineStart && nl.Indentation {
+		return nl.indentation
+	}
+	nl.lineStart = false
 	r := s.Next()
 	pos := s.Pos()
+	// state.Number emits at the token start
+	s.StartToken(pos)
 	switch r { // single-character tokens
 	case lex.EOF:
-		s.Emit(pos, ItemSemicolon, nil)
+		if nl.last != ItemSemicolon {
+			s.Emit(pos, ItemSemicolon, nil)
+		}
+		for range nl.indents { // close every block still open
+			s.Emit(pos, ItemDedent, nil)
+			s.Emit(pos, ItemSemicolon, nil)
+		}
+		nl.indents = nil
 		s.Emit(pos, ItemEOF, nil)
 		return nil
 	case '\n': // newlines separate statements
-		s.Emit(pos, ItemSemicolon, ";")
+		if nl.parens > 0 || continuesLine[nl.last] {
+			return nil
+		}
+		nl.lineStart = true
+		if nl.last != ItemSemicolon { // no empty statements for blank lines
+			s.Emit(pos, ItemSemicolon, ";")
+		}
 		return nil
 	case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
 		return state.Number(LT_Number, LT_Number, '.')
@@ -78,7 +172,7 @@ func (nl *NicerLexer) program(s *lex.State) lex.StateFn {
 	case '!':
 		r := s.Next()
 		if r == '=' {
-			s.Emit(pos, OP_Eq, "!=")
+			s.Emit(pos, OP_Neq, "!=")
 		} else {
 			s.Emit(pos, ItemError, string(r))
 			s.Backup()
@@ -103,9 +197,13 @@ func (nl *NicerLexer) program(s *lex.State) lex.StateFn {
 		}
 		return nil
 	case '(':
+		nl.parens++
 		s.Emit(pos, OP_Lparen, "(")
 		return nil
 	case ')':
+		if nl.parens > 0 {
+			nl.parens--
+		}
 		s.Emit(pos, OP_Rparen, ")")
 		return nil
 	case '#': // comments
@@ -113,8 +211,8 @@ func (nl *NicerLexer) program(s *lex.State) lex.StateFn {
 	}
 	switch {
 	case unicode.IsSpace(r):
-		// consume spaces
-		for r = s.Next(); unicode.IsSpace(r); r = s.Next() {
+		// consume spaces, but leave newlines to end the statement
+		for r = s.Next(); r != '\n' && unicode.IsSpace(r); r = s.Next() {
 			// nop
 		}
 		s.Backup()
@@ -127,6 +225,56 @@ func (nl *NicerLexer) program(s *lex.State) lex.StateFn {
 	return nil
 }
 
+// measure the indentation at the start of a line, and emit an ItemIndent or
+// ItemDedents if it changed. Blank and comment-only lines are skipped over.
+func (nl *NicerLexer) indentation(s *lex.State) lex.StateFn {
+	nl.lineStart = false
+	pos := s.Pos() + 1 // just past the newline
+	indent := make([]rune, 0, 16)
+	r := s.Next()
+	for ; r == ' ' || r == '\t'; r = s.Next() {
+		indent = append(indent, r)
+	}
+	s.Backup()
+	if r == '\n' || r == '\r' || r == '#' || r == lex.EOF {
+		return nil
+	}
+	pos += len(indent) // indentation is always ASCII
+	for _, ir := range indent {
+		if nl.indentRune == 0 {
+			nl.indentRune = ir
+		} else if ir != nl.indentRune {
+			s.Emit(pos, ItemError, "inconsistent use of tabs and spaces in indentation")
+			return nil
+		}
+	}
+	// every line indents with the same rune, so comparing the strings
+	// compares their widths
+	line := string(indent)
+	if line > nl.currentIndent() {
+		nl.indents = append(nl.indents, line)
+		s.Emit(pos, ItemIndent, nil)
+		return nil
+	}
+	for line < nl.currentIndent() {
+		nl.indents = nl.indents[:len(nl.indents)-1]
+		s.Emit(pos, ItemDedent, nil)
+		s.Emit(pos, ItemSemicolon, nil)
+	}
+	if line != nl.currentIndent() {
+		s.Emit(pos, ItemError, "unindent does not match any outer indentation level")
+	}
+	return nil
+}
+
+// the indentation of the innermost open level
+func (nl *NicerLexer) currentIndent() string {
+	if len(nl.indents) == 0 {
+		return ""
+	}
+	return nl.indents[len(nl.indents)-1]
+}
+
 func (nl *NicerLexer) ident(s *lex.State) lex.StateFn {
 	identName := make([]rune, 0, 64)
 	return func(l *lex.State) lex.StateFn {
@@ -145,10 +293,13 @@ func (nl *NicerLexer) comment(s *lex.State) lex.StateFn {
 	comment := make([]rune, 0, 64)
 	return func(l *lex.State) lex.StateFn {
 		comment = append(comment[:0], l.Current())
-		for r := l.Next(); r != '\n'; r = l.Next() {
+		for r := l.Next(); r != '\n' && r != lex.EOF; r = l.Next() {
 			comment = append(comment, r)
 		}
 		l.Backup()
+		if strings.TrimSpace(string(comment)) == IndentationPragma && nl.File().Position(l.Pos()).Line == 1 {
+			nl.Indentation = true
+		}
 		// completely ignore comments
 		return nil
 	}
@@ -163,6 +314,11 @@ func (nl *NicerLexer) keyword(s *lex.State) lex.StateFn {
 			kw = append(kw, r)
 		}
 		l.Backup()
+		if kw[0] == '-' && string(kw) != "-th" {
+			// a minus sign directly before a keyword, like `-true`
+			l.Emit(pos, OP_Minus, "-")
+			pos, kw = pos+1, kw[1:]
+		}
 		l.Emit(pos, keywords[string(kw)], string(kw))
 		return nil
 	}
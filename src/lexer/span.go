@@ -0,0 +1,42 @@
+package lexer
+
+import "fmt"
+
+// Span is a stretch of source text: where it starts, and where it ends
+// (exclusive). Lines and columns are 1-based, columns count bytes.
+type Span struct {
+	File      string
+	Line      int
+	Column    int
+	EndLine   int
+	EndColumn int
+}
+
+// a zero Span is one that was never filled in, e.g. for a synthesized token
+func (s Span) IsValid() bool {
+	return s.Line > 0
+}
+
+// Join returns the smallest span covering both s and other. Invalid spans
+// are ignored.
+func (s Span) Join(other Span) Span {
+	if !s.IsValid() {
+		return other
+	}
+	if !other.IsValid() {
+		return s
+	}
+	joined := s
+	if other.Line < joined.Line || (other.Line == joined.Line && other.Column < joined.Column) {
+		joined.Line, joined.Column = other.Line, other.Column
+	}
+	if other.EndLine > joined.EndLine || (other.EndLine == joined.EndLine && other.EndColumn > joined.EndColumn) {
+		joined.EndLine, joined.EndColumn = other.EndLine, other.EndColumn
+	}
+	return joined
+}
+
+// for String() string, in the usual `file:line:column` form
+func (s Span) String() string {
+	return fmt.Sprintf("%s:%d:%d", s.File, s.Line, s.Column)
+}
@@ -12,6 +12,8 @@ const (
 	ItemComment
 	ItemIdent
 	ItemSemicolon
+	ItemIndent
+	ItemDedent
 	// literals
 	LT_Number
 	LT_String
@@ -27,6 +29,7 @@ const (
 	// struct-specific keywords
 	KW_Containing
 	KW_Can
+	KW_This
 	// declaration keywords
 	KW_Variable
 	KW_Constant
@@ -39,14 +42,18 @@ const (
 	KW_Do
 	KW_Done
 	KW_Does
+	KW_Doing
 	KW_Th
 	KW_Taking
 	KW_Returning
 	KW_Return
+	KW_As
 	// loops
 	KW_For
 	KW_While
 	KW_Loop
+	KW_Stop
+	KW_Skip
 	// conditionals
 	KW_If
 	KW_Then
@@ -100,10 +107,12 @@ var keywords = map[string]lex.Token{
 	"is":         KW_Is,
 	"of":         KW_Of,
 	"can":        KW_Can,
+	"this":       KW_This,
 	"where":      KW_Where,
 	"do":         KW_Do,
 	"done":       KW_Done,
 	"does":       KW_Does,
+	"doing":      KW_Doing,
 	"-th":        KW_Th,
 	"containing": KW_Containing,
 	"from":       KW_From,
@@ -114,10 +123,13 @@ var keywords = map[string]lex.Token{
 	"taking":     KW_Taking,
 	"returning":  KW_Returning,
 	"return":     KW_Return,
+	"as":         KW_As,
 	// loops
 	"for":   KW_For,
 	"while": KW_While,
 	"loop":  KW_Loop,
+	"stop":  KW_Stop,
+	"skip":  KW_Skip,
 	// conditionals
 	"if":   KW_If,
 	"then": KW_Then,
@@ -134,6 +146,8 @@ var TokenString = map[lex.Token]string{
 	ItemComment:   "ItemComment",
 	ItemIdent:     "ItemIdent",
 	ItemSemicolon: "ItemSemicolon",
+	ItemIndent:    "ItemIndent",
+	ItemDedent:    "ItemDedent",
 	// literals
 	LT_Number:  "LT_Number",
 	LT_String:  "LT_String",
@@ -155,19 +169,24 @@ var TokenString = map[lex.Token]string{
 	KW_Is:         "KW_Is",
 	KW_Of:         "KW_Of",
 	KW_Can:        "KW_Can",
+	KW_This:       "KW_This",
 	KW_Where:      "KW_Where",
 	KW_Do:         "KW_Do",
 	KW_Done:       "KW_Done",
 	KW_Does:       "KW_Does",
+	KW_Doing:      "KW_Doing",
 	KW_Th:         "KW_Th",
 	KW_Containing: "KW_Containing",
 	KW_Taking:     "KW_Taking",
 	KW_Returning:  "KW_Returning",
 	KW_Return:     "KW_Return",
+	KW_As:         "KW_As",
 	// loops
 	KW_For:   "KW_For",
 	KW_While: "KW_While",
 	KW_Loop:  "KW_Loop",
+	KW_Stop:  "KW_Stop",
+	KW_Skip:  "KW_Skip",
 	// conditionals
 	KW_If:   "KW_If",
 	KW_Then: "KW_Then",
@@ -205,9 +224,13 @@ type TokItem struct {
 	TokName     string
 	TokPosition int
 	TokValue    interface{}
+	TokSpan     Span
 }
 
 // for String() string
 func (ti TokItem) String() string {
+	if ti.TokSpan.IsValid() {
+		return fmt.Sprintf("{%s @ %v: %#v}", ti.TokName, ti.TokSpan, ti.TokValue)
+	}
 	return fmt.Sprintf("{%s @ %v: %#v}", ti.TokName, ti.TokPosition, ti.TokValue)
 }
@@ -7,6 +7,7 @@ import (
 	"nicer-syntax/ast"
 	"nicer-syntax/lexer"
 	"nicer-syntax/parser"
+	"nicer-syntax/typecheck"
 	"os"
 
 	"github.com/db47h/lex"
@@ -43,14 +44,28 @@ func main() {
 	// }
 
 	p := parser.NewParser(tokens)
-	result, err, prog := p.Parse()
+	p.Indentation = nicerLexer.Indentation
+	result, parseErr, prog := p.Parse()
 	fmt.Printf("result: %v\n", result)
-	fmt.Printf("%v\n", err)
+	if !result {
+		fmt.Printf("%v\n", parseErr)
+		return
+	}
 	visitor := ast.NewEvaluatingVisitor()
 	stringvisitor := ast.StringVisitor{}
 	prog.Accept(&stringvisitor)
 	fmt.Println(stringvisitor)
+	// nothing runs until the whole program is well-typed
+	if typeErrs := typecheck.Check(prog); len(typeErrs) > 0 {
+		for _, typeErr := range typeErrs {
+			fmt.Println(typeErr)
+		}
+		return
+	}
 	prog.Accept(visitor)
+	if visitor.Err != nil {
+		fmt.Println(visitor.Err)
+	}
 	// ast.Evaluate()
 
 }
@@ -36,20 +36,47 @@ func (pe *ParseError) addRule(rule string) *ParseError {
 
 // for interface error.Error()
 func (pe *ParseError) Error() string {
+	if pe.Token.TokSpan.IsValid() {
+		return fmt.Sprintf("%v: %v", pe.Token.TokSpan, pe.message())
+	}
+	return pe.message()
+}
+
+func (pe *ParseError) message() string {
 	return fmt.Sprintf("%v %v because of token `%v` within rule trace `%v`", COLOR_ERROR("PARSE ERROR:"), COLOR_KEYWORD(pe.Reason), COLOR_TOKEN("%v", pe.Token), COLOR_RULE(pe.LastRule))
 }
 
 type Parser struct {
 	Tokens    []lexer.TokItem
 	lastToken *lexer.TokItem
+	// close blocks by dedenting rather than with `done`; the tokens must come
+	// from a lexer in indentation mode
+	Indentation bool
+	// how many function bodies the parser is inside, as `return` can only be
+	// used in one
+	functions int
+	// how many loop bodies the parser is inside within the innermost function,
+	// as `stop` and `skip` can only be used in one
+	loops int
+	// how many methods the parser is inside, as `this` can only be used in one
+	methods int
+	// how many blocks the parser is inside, as types can only be declared
+	// outside of every one
+	blocks int
 }
 
 func NewParser(tokens []lexer.TokItem) Parser {
-	return Parser{tokens, &lexer.TokItem{TokType: lexer.ItemEOF, TokName: "nothing", TokPosition: -1, TokValue: ""}}
+	return Parser{tokens, &lexer.TokItem{TokType: lexer.ItemEOF, TokName: "nothing", TokPosition: -1, TokValue: ""}, false, 0, 0, 0, 0}
 }
 
+// what the parser sees once it runs out of tokens
+var eofToken = lexer.TokItem{TokType: lexer.ItemEOF, TokName: lexer.TokenString[lexer.ItemEOF], TokPosition: -1}
+
 // consume and return the next token in the token queue.
 func (p *Parser) getNextToken() lexer.TokItem {
+	if len(p.Tokens) == 0 {
+		return eofToken
+	}
 	tok := p.Tokens[0]
 	p.Tokens = p.Tokens[1:]
 	p.lastToken = &tok
@@ -58,9 +85,22 @@ func (p *Parser) getNextToken() lexer.TokItem {
 
 // peek at the front of the token queue.
 func (p *Parser) peekToken() *lexer.TokItem {
+	if len(p.Tokens) == 0 {
+		eof := eofToken
+		return &eof
+	}
 	return &(p.Tokens[0])
 }
 
+// peek n tokens past the front of the token queue.
+func (p *Parser) peekTokenAt(n int) *lexer.TokItem {
+	if n >= len(p.Tokens) {
+		eof := eofToken
+		return &eof
+	}
+	return &(p.Tokens[n])
+}
+
 // put the last-consumed token back onto the front of the token queue.
 func (p *Parser) putBackToken() {
 	p.Tokens = append([]lexer.TokItem{*p.lastToken}, p.Tokens...)
@@ -75,6 +115,13 @@ func (p *Parser) expectToken(tokType lex.Token, lastRule string) (bool, *ParseEr
 	return true, nil, &token
 }
 
+// consume any number of semicolons, as left by blank lines.
+func (p *Parser) skipSemicolons() {
+	for p.peekToken().TokType == lexer.ItemSemicolon {
+		p.getNextToken()
+	}
+}
+
 // peek at a token and determine if it is of a desired token type.
 func (p *Parser) maybeToken(tokType lex.Token, lastRule string) (bool, *ParseError) {
 	token := p.peekToken()
@@ -90,6 +137,12 @@ func (p *Parser) maybeToken(tokType lex.Token, lastRule string) (bool, *ParseErr
 //! - When going to a nested rule, peek to check for the starting token
 
 func (p *Parser) Parse() (bool, *ParseError, *ast.Program) {
+	// report what the lexer could not make sense of before anything else
+	for _, token := range p.Tokens {
+		if token.TokType == lexer.ItemError || token.TokType == lex.Error {
+			return false, NewParseError(fmt.Sprintf("Invalid token: %v", token.TokValue), token, "Parse"), nil
+		}
+	}
 	ok, err, prog := p.Program()
 	if !ok {
 		return false, err, nil
@@ -100,6 +153,10 @@ func (p *Parser) Parse() (bool, *ParseError, *ast.Program) {
 func (p *Parser) Program() (bool, *ParseError, *ast.Program) {
 	program := ast.NewProgram()
 	for len(p.Tokens) > 0 {
+		if p.peekToken().TokType == lexer.ItemSemicolon { // empty statement
+			p.getNextToken()
+			continue
+		}
 		ok, err, stmt := p.Stmt()
 		if !ok {
 			return false, err.addRule("Program-Stmt"), nil
@@ -108,29 +165,181 @@ func (p *Parser) Program() (bool, *ParseError, *ast.Program) {
 			return false, err, nil
 		}
 		program.Statements = append(program.Statements, stmt)
+		program.Span = program.Span.Join(ast.SpanOf(stmt))
 	}
 	return true, nil, program
 }
 
 func (p *Parser) Stmt() (bool, *ParseError, ast.Statement) {
 	switch p.peekToken().TokType {
-	case lexer.ItemIdent:
-		return p.IdentAssignment()
-	default:
+	case lexer.KW_Constant, lexer.KW_Variable:
 		return p.IdentDeclaration()
+	case lexer.KW_Function:
+		return p.FunctionDecl()
+	case lexer.KW_Type:
+		if p.blocks > 0 {
+			return false, NewParseError("Types can only be declared at the top level", *p.peekToken(), "Stmt"), nil
+		}
+		return p.TypeDecl()
+	case lexer.KW_Do:
+		ok, err, call := p.FunctionCall()
+		return ok, err, call
+	case lexer.KW_Return:
+		return p.ReturnStmt()
+	case lexer.KW_If:
+		return p.IfStmt()
+	case lexer.KW_For:
+		return p.ForLoop()
+	case lexer.KW_While:
+		return p.WhileLoop()
+	case lexer.KW_Stop, lexer.KW_Skip:
+		return p.LoopControl()
+	case lexer.ItemIndent:
+		return false, NewParseError("Unexpected indentation", *p.peekToken(), "Stmt"), nil
+	default:
+		return p.Assignment()
 	}
 }
 
-func (p *Parser) IdentAssignment() (bool, *ParseError, *ast.VarAssignment) {
-	ok, err, name := p.Ident()
+// the statements making up the body of a conditional, loop or function.
+// Normally a block runs until `done` or one of the continuations (like
+// `else`), which are left for the caller. With Indentation, it is instead
+// every line indented past the one that opened it; the caller still sees a
+// continuation on the line after.
+func (p *Parser) Block(rule string, continuations ...lex.Token) (bool, *ParseError, []ast.Statement) {
+	p.blocks++
+	defer func() { p.blocks-- }()
+	return p.statements(rule, p.Stmt, continuations)
+}
+
+// the statements of a block, each parsed by stmt
+func (p *Parser) statements(rule string, stmt func() (bool, *ParseError, ast.Statement), continuations []lex.Token) (bool, *ParseError, []ast.Statement) {
+	if p.Indentation {
+		return p.indentedBlock(rule, stmt, continuations)
+	}
+	var stmts []ast.Statement
+	for {
+		p.skipSemicolons()
+		token := p.peekToken()
+		if token.TokType == lexer.KW_Done || isOneOf(token.TokType, continuations) {
+			return true, nil, stmts
+		}
+		if token.TokType == lexer.ItemEOF {
+			return false, NewParseError("Expected `done` to close the block", *token, rule), nil
+		}
+		if p.emptyBody() {
+			continue
+		}
+		ok, err, stmt := stmt()
+		if !ok {
+			return false, err.addRule(rule), nil
+		}
+		stmts = append(stmts, stmt)
+		// the last statement may be followed directly by `done`
+		if next := p.peekToken().TokType; next != lexer.KW_Done && !isOneOf(next, continuations) {
+			if ok, err, _ := p.expectToken(lexer.ItemSemicolon, rule+"-Semicolon"); !ok {
+				return false, err, nil
+			}
+		}
+	}
+}
+
+func (p *Parser) indentedBlock(rule string, stmt func() (bool, *ParseError, ast.Statement), continuations []lex.Token) (bool, *ParseError, []ast.Statement) {
+	p.skipSemicolons()
+	if ok, err, _ := p.expectToken(lexer.ItemIndent, rule+"-Indent"); !ok {
+		err.Reason = "Expected an indented block"
+		return false, err, nil
+	}
+	var stmts []ast.Statement
+	for {
+		p.skipSemicolons()
+		if p.peekToken().TokType == lexer.ItemDedent {
+			p.getNextToken()
+			break
+		}
+		if p.emptyBody() {
+			continue
+		}
+		ok, err, stmt := stmt()
+		if !ok {
+			return false, err.addRule(rule), nil
+		}
+		stmts = append(stmts, stmt)
+		if ok, err, _ := p.expectToken(lexer.ItemSemicolon, rule+"-Semicolon"); !ok {
+			return false, err, nil
+		}
+	}
+	// a dedent ends the line, but a continuation carries on the statement
+	if len(p.Tokens) > 1 && p.Tokens[0].TokType == lexer.ItemSemicolon && isOneOf(p.Tokens[1].TokType, continuations) {
+		p.getNextToken()
+	}
+	return true, nil, stmts
+}
+
+// a body can be just `nothing` on a line of its own, which is consumed
+func (p *Parser) emptyBody() bool {
+	if p.peekToken().TokType != lexer.LT_Nothing {
+		return false
+	}
+	switch p.peekTokenAt(1).TokType {
+	case lexer.ItemSemicolon, lexer.ItemDedent, lexer.KW_Done, lexer.ItemEOF:
+		p.getNextToken()
+		return true
+	}
+	return false
+}
+
+// close a block opened by Block: `done`, or nothing at all with Indentation.
+func (p *Parser) EndBlock(rule string) (bool, *ParseError) {
+	if p.Indentation {
+		return true, nil
+	}
+	ok, err, _ := p.expectToken(lexer.KW_Done, rule+"-Done")
+	return ok, err
+}
+
+func isOneOf(tokType lex.Token, tokTypes []lex.Token) bool {
+	for _, t := range tokTypes {
+		if tokType == t {
+			return true
+		}
+	}
+	return false
+}
+
+// `Name is Value`, `Index-th of Collection is Value`, or `Field of Object is
+// Value`. Assigning `nothing` stores the default value of the target's type.
+func (p *Parser) Assignment() (bool, *ParseError, ast.Statement) {
+	targetToken := *p.peekToken()
+	ok, err, target := p.Postfix()
 	if !ok {
-		return false, err.addRule("IdentAssignment-Ident"), nil
+		return false, err.addRule("Assignment-Target"), nil
 	}
-	if ok, err, _ := p.expectToken(lexer.KW_Is, "IdentAssignment-Is"); !ok {
+	if ok, err, _ := p.expectToken(lexer.KW_Is, "Assignment-Is"); !ok {
 		return false, err, nil
 	}
-	ok, err, val := p.Value()
-	return ok, err, ast.NewVarAssignment(name, val)
+	switch target := target.(type) {
+	case *ast.Identifier:
+		ok, err, val := p.Expression()
+		if !ok {
+			return false, err.addRule("Assignment-Expression"), nil
+		}
+		return true, nil, ast.NewVarAssignment(target, val)
+	case *ast.IndexExpr:
+		ok, err, val := p.Expression()
+		if !ok {
+			return false, err.addRule("Assignment-Expression"), nil
+		}
+		return true, nil, ast.NewIndexAssignment(target, val, p.lastToken)
+	case *ast.FieldExpr:
+		ok, err, val := p.Expression()
+		if !ok {
+			return false, err.addRule("Assignment-Expression"), nil
+		}
+		return true, nil, ast.NewFieldAssignment(target, val, p.lastToken)
+	default:
+		return false, NewParseError("Can only assign to a variable, an element or a field", targetToken, "Assignment"), nil
+	}
 }
 
 func (p *Parser) IdentDeclaration() (bool, *ParseError, ast.Declaration) {
@@ -145,7 +354,7 @@ func (p *Parser) IdentDeclaration() (bool, *ParseError, ast.Declaration) {
 }
 
 func (p *Parser) VarDecl() (bool, *ParseError, *ast.VarDecl) {
-	ok, err, _ := p.expectToken(lexer.KW_Variable, "VarDecl-Variable")
+	ok, err, keyword := p.expectToken(lexer.KW_Variable, "VarDecl-Variable")
 	if !ok {
 		return false, err, nil
 	}
@@ -154,29 +363,122 @@ func (p *Parser) VarDecl() (bool, *ParseError, *ast.VarDecl) {
 		return false, err.addRule("VarDecl-IdentType"), nil
 	}
 	// optional value, ended with semicolon
-	if p.peekToken().TokType == lexer.ItemSemicolon {
-		return true, nil, ast.NewVarDecl(name, typeName, nil)
+	if p.peekToken().TokType == lexer.ItemSemicolon || p.peekToken().TokType == lexer.ItemEOF {
+		return true, nil, ast.NewVarDecl(keyword, name, typeName, nil)
+	}
+	ok, err, val := p.declaredValue(typeName)
+	if !ok {
+		return false, err.addRule("VarDecl-Expression"), nil
 	}
-	ok, err, val := p.Value()
-	return ok, err, ast.NewVarDecl(name, typeName, val)
+	return true, nil, ast.NewVarDecl(keyword, name, typeName, val)
 }
 
 func (p *Parser) ConstDecl() (bool, *ParseError, *ast.ConstDecl) {
-	if ok, err, _ := p.expectToken(lexer.KW_Constant, "ConstDecl-Constant"); !ok {
+	ok, err, keyword := p.expectToken(lexer.KW_Constant, "ConstDecl-Constant")
+	if !ok {
 		return false, err, nil
 	}
 	ok, err, name, typeName := p.IdentType()
 	if !ok {
 		return false, err.addRule("ConstDecl-IdentType"), nil
 	}
-	ok, err, val := p.Value()
+	ok, err, val := p.declaredValue(typeName)
+	if !ok {
+		return false, err.addRule("ConstDecl-Expression"), nil
+	}
+	return true, nil, ast.NewConstDecl(keyword, name, typeName, val)
+}
+
+// the value a name is declared with, which is a struct literal of its type
+// when the type is a named one and a literal follows
+func (p *Parser) declaredValue(typeName ast.TypeExpr) (bool, *ParseError, ast.Visitable) {
+	switch typeName.(type) {
+	case *ast.NamedType, *ast.GenericType:
+		if p.isStructLiteral() {
+			ok, err, literal := p.StructLiteral(typeName)
+			return ok, err, literal
+		}
+	}
+	return p.Expression()
+}
+
+// whether a struct literal comes next: `where`, or `containing` followed by
+// a field being given its value
+func (p *Parser) isStructLiteral() bool {
+	switch p.peekToken().TokType {
+	case lexer.KW_Where:
+		return true
+	case lexer.KW_Containing:
+		return p.peekTokenAt(1).TokType == lexer.ItemIdent && p.peekTokenAt(2).TokType == lexer.KW_Is
+	}
+	return false
+}
+
+// the type a struct literal used as a value starts with, as in `return Node
+// of T where Value is 1 done`, or nil with the tokens left as they were, as
+// `Node of T` could just as well be a field
+func (p *Parser) structLiteralType() ast.TypeExpr {
+	if p.peekToken().TokType != lexer.ItemIdent {
+		return nil
+	}
+	tokens, lastToken := p.Tokens, p.lastToken
+	if ok, _, typeName := p.TypeName(); ok && p.isStructLiteral() {
+		return typeName
+	}
+	p.Tokens, p.lastToken = tokens, lastToken
+	return nil
+}
+
+// `where Field is Value, ..., Field is Value done`, or `where nothing done`,
+// giving a new struct of typeName. `containing` can stand in for `where`. The
+// fields are separated by commas, and the last may be followed by one.
+func (p *Parser) StructLiteral(typeName ast.TypeExpr) (bool, *ParseError, *ast.StructLiteral) {
+	opener := p.getNextToken()
+	if opener.TokType != lexer.KW_Where && opener.TokType != lexer.KW_Containing {
+		return false, NewParseError("Expected `where` or `containing` before the fields", opener, "StructLiteral"), nil
+	}
+	var fields []*ast.Identifier
+	var values []ast.Visitable
+	given := make(map[string]bool)
+	p.skipSemicolons()
+	if p.peekToken().TokType == lexer.LT_Nothing {
+		p.getNextToken()
+	} else {
+		for {
+			ok, err, field := p.expectToken(lexer.ItemIdent, "StructLiteral-Field")
+			if !ok {
+				return false, err, nil
+			}
+			if given[field.TokValue.(string)] {
+				return false, NewParseError("Field is already given a value in this literal", *field, "StructLiteral-Field"), nil
+			}
+			given[field.TokValue.(string)] = true
+			if ok, err, _ := p.expectToken(lexer.KW_Is, "StructLiteral-Is"); !ok {
+				return false, err, nil
+			}
+			ok, err, val := p.Expression()
+			if !ok {
+				return false, err.addRule("StructLiteral-Value"), nil
+			}
+			fields, values = append(fields, ast.NewIdentifier(field)), append(values, val)
+			if p.peekToken().TokType != lexer.OP_Comma {
+				break
+			}
+			p.getNextToken() // consume `,`
+			if p.peekToken().TokType != lexer.ItemIdent {
+				break
+			}
+		}
+	}
+	p.skipSemicolons()
+	ok, err, done := p.expectToken(lexer.KW_Done, "StructLiteral-Done")
 	if !ok {
-		return false, err.addRule("ConstDecl-Value"), nil
+		return false, err, nil
 	}
-	return true, nil, ast.NewConstDecl(name, typeName, val)
+	return true, nil, ast.NewStructLiteral(&opener, typeName, fields, values, done)
 }
 
-func (p *Parser) IdentType() (bool, *ParseError, *ast.Identifier, *ast.Identifier) {
+func (p *Parser) IdentType() (bool, *ParseError, *ast.Identifier, ast.TypeExpr) {
 	ok, err, name := p.expectToken(lexer.ItemIdent, "IdentType-Ident")
 	if !ok {
 		return false, err, nil, nil
@@ -191,211 +493,1284 @@ func (p *Parser) IdentType() (bool, *ParseError, *ast.Identifier, *ast.Identifie
 	return true, nil, ast.NewIdentifier(name), typeName
 }
 
-func (p *Parser) TypeName() (bool, *ParseError, *ast.Identifier) {
+// a type, like `number`, `map of string to list of number`,
+// `Tuple of number, and string` or `function, taking E, and returning boolean`
+func (p *Parser) TypeName() (bool, *ParseError, ast.TypeExpr) {
 	typeName := p.getNextToken()
 	switch typeName.TokType {
 	case lexer.TN_Number, lexer.TN_String, lexer.TN_Boolean:
-		return true, nil, ast.NewIdentifier(&typeName)
+		return true, nil, ast.NewPrimitiveType(&typeName)
 	case lexer.TN_List:
 		if ok, err, _ := p.expectToken(lexer.KW_Of, "TypeName-ListOf"); !ok {
 			return false, err, nil
 		}
-		return p.TypeName()
+		ok, err, element := p.TypeName()
+		if !ok {
+			return false, err.addRule("TypeName-ListElement"), nil
+		}
+		return true, nil, ast.NewListType(&typeName, element)
 	case lexer.TN_Map:
 		if ok, err, _ := p.expectToken(lexer.KW_Of, "TypeName-MapOfKey"); !ok {
 			return false, err, nil
 		}
-		if ok, err, _ := p.TypeName(); !ok {
+		ok, err, key := p.TypeName()
+		if !ok {
 			return false, err.addRule("TypeName-MapKey"), nil
 		}
 		if ok, err, _ := p.expectToken(lexer.KW_To, "TypeName-MapToValue"); !ok {
 			return false, err, nil
 		}
-		return p.TypeName()
+		ok, err, value := p.TypeName()
+		if !ok {
+			return false, err.addRule("TypeName-MapValue"), nil
+		}
+		return true, nil, ast.NewMapType(&typeName, key, value)
+	case lexer.KW_Function:
+		p.putBackToken()
+		return p.FunctionType()
 	case lexer.ItemIdent: // possibly undeclared typename
-		return true, nil, ast.NewIdentifier(&typeName)
+		name := ast.NewIdentifier(&typeName)
+		if p.peekToken().TokType != lexer.KW_Of {
+			return true, nil, ast.NewNamedType(name)
+		}
+		p.getNextToken() // consume `of`
+		ok, err, args := p.TypeList("TypeName-GenericArgs")
+		if !ok {
+			return false, err, nil
+		}
+		return true, nil, ast.NewGenericType(name, args)
 	default:
 		return false, NewParseError("Expected type name", typeName, "TypeName"), nil
 	}
 }
 
-func (p *Parser) Value() (bool, *ParseError, ast.Visitable) {
-	switch p.peekToken().TokType {
-	case lexer.LT_Number:
-		ok, err, val := p.NumberLiteral()
-		return ok, err, val
-	case lexer.LT_Boolean:
-		ok, err, val := p.BooleanLiteral()
-		return ok, err, val
-	case lexer.LT_String:
-		ok, err, val := p.StringLiteral()
-		return ok, err, val
-	case lexer.KW_Containing:
-		// TODO: Evaluating list literals
-		// return p.ListLiteral()
-		return true, nil, nil
-	default:
-		return false, NewParseError("Expected value", *p.peekToken(), "Value"), nil
+// `function`, then optionally `, taking` the types of its parameters and
+// `, returning` the type of its result
+func (p *Parser) FunctionType() (bool, *ParseError, ast.TypeExpr) {
+	ok, err, function := p.expectToken(lexer.KW_Function, "FunctionType-Function")
+	if !ok {
+		return false, err, nil
+	}
+	var params []ast.TypeExpr
+	if p.peekToken().TokType == lexer.OP_Comma && p.peekTokenAt(1).TokType == lexer.KW_Taking {
+		p.getNextToken() // consume `,`
+		p.getNextToken() // consume `taking`
+		if ok, err, params = p.TypeList("FunctionType-Taking"); !ok {
+			return false, err, nil
+		}
+	}
+	ok, err, returns := p.Returning("FunctionType-Returning")
+	if !ok {
+		return false, err, nil
 	}
+	return true, nil, ast.NewFunctionType(function, params, returns)
 }
 
-func (p *Parser) PrimitiveLiteral() (bool, *ParseError, ast.Visitable) {
-	switch p.peekToken().TokType {
-	case lexer.LT_Number:
-		return p.NumberLiteral()
-	case lexer.LT_Boolean:
-		return p.BooleanLiteral()
-	case lexer.LT_String:
-		return p.StringLiteral()
-	default:
-		return false, NewParseError("Expected primitive literal", *p.peekToken(), "PrimitiveLiteral"), nil
+// `, returning Type`, or `, and returning Type` when it ends a list of
+// parameters. A function that returns nothing has no return type.
+func (p *Parser) Returning(rule string) (bool, *ParseError, ast.TypeExpr) {
+	returning := 1
+	if p.peekTokenAt(1).TokType == lexer.KW_And {
+		returning = 2
+	}
+	if p.peekToken().TokType != lexer.OP_Comma || p.peekTokenAt(returning).TokType != lexer.KW_Returning {
+		return true, nil, nil
+	}
+	for i := 0; i <= returning; i++ {
+		p.getNextToken()
+	}
+	ok, err, returns := p.TypeName()
+	if !ok {
+		return false, err.addRule(rule), nil
 	}
+	return true, nil, returns
 }
 
-func (p *Parser) NumberLiteral() (bool, *ParseError, *ast.NumberLiteral) {
-	if ok, err, token := p.expectToken(lexer.LT_Number, "NumberLiteral"); !ok {
+// `function Name is function ... done`
+func (p *Parser) FunctionDecl() (bool, *ParseError, ast.Statement) {
+	ok, err, keyword := p.expectToken(lexer.KW_Function, "FunctionDecl-Function")
+	if !ok {
 		return false, err, nil
-	} else {
-		return true, nil, ast.NewNumberLiteral(token)
 	}
-}
-
-func (p *Parser) BooleanLiteral() (bool, *ParseError, *ast.BooleanLiteral) {
-	if ok, err, token := p.expectToken(lexer.LT_Boolean, "BooleanLiteral"); !ok {
+	ok, err, name := p.Ident()
+	if !ok {
+		return false, err.addRule("FunctionDecl-Name"), nil
+	}
+	if ok, err, _ := p.expectToken(lexer.KW_Is, "FunctionDecl-Is"); !ok {
 		return false, err, nil
-	} else {
-		return true, nil, ast.NewBooleanLiteral(token)
 	}
+	ok, err, function := p.FunctionLiteral()
+	if !ok {
+		return false, err.addRule("FunctionDecl"), nil
+	}
+	return true, nil, ast.NewFunctionDecl(keyword, name, function)
 }
 
-func (p *Parser) StringLiteral() (bool, *ParseError, *ast.StringLiteral) {
-	if ok, err, token := p.expectToken(lexer.LT_String, "StringLiteral"); !ok {
+// `type Name is struct ...`, or `type Name is Type` to give a type another name
+func (p *Parser) TypeDecl() (bool, *ParseError, ast.Statement) {
+	ok, err, keyword := p.expectToken(lexer.KW_Type, "TypeDecl-Type")
+	if !ok {
 		return false, err, nil
-	} else {
-		return true, nil, ast.NewStringLiteral(token)
 	}
-}
-
-func (p *Parser) ListLiteral() (bool, *ParseError) {
-	if ok, err, _ := p.expectToken(lexer.KW_Containing, "ListLiteral-Containing"); !ok {
-		return false, err
+	ok, err, name := p.Ident()
+	if !ok {
+		return false, err.addRule("TypeDecl-Name"), nil
 	}
-	element := p.peekToken()
-	if element.TokType == lexer.LT_Nothing {
-		p.getNextToken() // consume `nothing`
-		if ok, err, _ := p.expectToken(lexer.KW_Done, "ListLiteral-NothingDone"); !ok {
-			return false, err
-		}
-		return true, nil
+	if ok, err, _ := p.expectToken(lexer.KW_Is, "TypeDecl-Is"); !ok {
+		return false, err, nil
 	}
-	if ok, err := p.ListElements(); !ok {
-		return false, err.addRule("ListLiteral")
+	if p.peekToken().TokType != lexer.TN_Struct {
+		ok, err, typeName := p.TypeName()
+		if !ok {
+			return false, err.addRule("TypeDecl"), nil
+		}
+		return true, nil, ast.NewTypeDecl(keyword, name, typeName)
 	}
-	if ok, err, _ := p.expectToken(lexer.KW_Done, "ListLiteral-SomethingDone"); !ok {
-		return false, err
+	ok, err, structType := p.StructType()
+	if !ok {
+		return false, err.addRule("TypeDecl"), nil
 	}
-	return true, nil
+	return true, nil, ast.NewTypeDecl(keyword, name, structType)
 }
 
-func (p *Parser) ListElements() (bool, *ParseError) {
-	if ok, err := p.ListValue(); !ok {
-		return false, err.addRule("ListElements-One")
+// `struct`, optionally `of` its type parameters, `containing` its fields, or
+// `nothing`, then optionally `and can do` and its methods, and finally
+// `done`. The fields are separated by commas, and the last may be followed by
+// one. In indentation mode, the methods are indented below `and can do`, and
+// `done` still closes the struct.
+func (p *Parser) StructType() (bool, *ParseError, *ast.StructType) {
+	ok, err, structTok := p.expectToken(lexer.TN_Struct, "StructType-Struct")
+	if !ok {
+		return false, err, nil
 	}
-	if ok, err, _ := p.expectToken(lexer.OP_Comma, "ListElements-OneComma"); !ok {
-		return false, err
+	ok, err, typeParams, constraints := p.TypeParams("StructType-TypeParams")
+	if !ok {
+		return false, err, nil
 	}
-	if p.peekToken().TokType == lexer.KW_Done {
-		// single element, exit
-		return true, nil
+	if ok, err, _ := p.expectToken(lexer.KW_Containing, "StructType-Containing"); !ok {
+		return false, err, nil
 	}
-	for {
-		if p.peekToken().TokType == lexer.KW_And { // exit when see the last element
-			p.getNextToken() // consume `and``
-			break
+	var fields []ast.Declaration
+	p.skipSemicolons()
+	if p.peekToken().TokType == lexer.LT_Nothing {
+		p.getNextToken()
+	} else {
+		for {
+			ok, err, field := p.Field()
+			if !ok {
+				return false, err.addRule("StructType-Fields"), nil
+			}
+			fields = append(fields, field)
+			if p.peekToken().TokType != lexer.OP_Comma {
+				break
+			}
+			p.getNextToken() // consume `,`
+			if next := p.peekToken().TokType; next != lexer.KW_Variable && next != lexer.KW_Constant {
+				break
+			}
+		}
+	}
+	p.skipSemicolons()
+	var methods []*ast.FunctionDecl
+	if p.peekToken().TokType == lexer.KW_And && p.peekTokenAt(1).TokType == lexer.KW_Can {
+		p.getNextToken() // consume `and`
+		p.getNextToken() // consume `can`
+		if ok, err, _ := p.expectToken(lexer.KW_Do, "StructType-CanDo"); !ok {
+			return false, err, nil
 		}
-		if ok, err := p.ListValue(); !ok {
-			return false, err.addRule("ListElements-MoreThan1")
+		p.methods++
+		ok, err, stmts := p.statements("StructType-Methods", p.Method, []lex.Token{lexer.KW_Done})
+		p.methods--
+		if !ok {
+			return false, err, nil
 		}
-		if ok, err, _ := p.expectToken(lexer.OP_Comma, "ListElements-TwoComma"); !ok {
-			return false, err
+		for _, stmt := range stmts {
+			methods = append(methods, stmt.(*ast.FunctionDecl))
 		}
+		p.skipSemicolons()
 	}
-	if ok, err := p.ListValue(); !ok {
-		// last element
-		return false, err.addRule("ListElements-LastElement")
-	}
-	if ok, err, _ := p.expectToken(lexer.OP_Comma, "ListElements-LastComma"); !ok {
-		return false, err
-	}
-	return true, nil
-}
-
-func (p *Parser) ListValue() (bool, *ParseError) {
-	switch p.peekToken().TokType {
-	case lexer.LT_Number:
-		ok, err, _ := p.NumberLiteral()
-		return ok, err
-	case lexer.LT_Boolean:
-		ok, err, _ := p.BooleanLiteral()
-		return ok, err
-	case lexer.LT_String:
-		ok, err, _ := p.StringLiteral()
-		return ok, err
-	case lexer.ItemIdent:
-		return true, nil
-	case lexer.KW_From, lexer.KW_Every:
-		return p.RangeLiteral()
-	default:
-		return false, NewParseError("TODO", *p.peekToken(), "ListValue")
+	ok, err, done := p.expectToken(lexer.KW_Done, "StructType-Done")
+	if !ok {
+		return false, err, nil
 	}
+	return true, nil, ast.NewStructType(structTok, typeParams, constraints, fields, methods, done)
 }
 
-func (p *Parser) RangeLiteral() (bool, *ParseError) {
-	if ok, _ := p.maybeToken(lexer.KW_Every, "RangeLiteral-Every"); ok {
-		// consume `every`
-		p.getNextToken()
-		if ok, err := p.Nth(); !ok {
-			return false, err.addRule("RangeLiteral-EveryNth")
-		}
+// `of` and the names of a generic declaration's type parameters, like
+// `of A, and B`, if it has any, then optionally `where` and the constraints
+// on them. Constraints are listed like types, with the last one after `and`.
+func (p *Parser) TypeParams(rule string) (bool, *ParseError, []*ast.Identifier, []*ast.Constraint) {
+	if p.peekToken().TokType != lexer.KW_Of {
+		return true, nil, nil, nil
 	}
-	if ok, err, _ := p.expectToken(lexer.KW_From, "RangeLiteral-From"); !ok {
-		return false, err
+	p.getNextToken() // consume `of`
+	first := *p.peekToken()
+	ok, err, types := p.TypeList(rule)
+	if !ok {
+		return false, err, nil, nil
 	}
-	if ok, err := p.RangeStart(); !ok {
-		return false, err.addRule("RangeLiteral-Start")
+	params := make([]*ast.Identifier, 0, len(types))
+	for _, t := range types {
+		named, ok := t.(*ast.NamedType)
+		if !ok {
+			return false, NewParseError("Type parameters are names, like `T`", first, rule), nil, nil
+		}
+		params = append(params, named.Name)
 	}
-	if ok, err, _ := p.expectToken(lexer.KW_To, "RangeLiteral-To"); !ok {
-		return false, err
+	if p.peekToken().TokType != lexer.KW_Where {
+		return true, nil, params, nil
 	}
-	if ok, err := p.RangeEnd(); !ok {
-		return false, err.addRule("RangeLiteral-End")
+	p.getNextToken() // consume `where`
+	var constraints []*ast.Constraint
+	for {
+		ok, err, constraint := p.Constraint(params)
+		if !ok {
+			return false, err.addRule(rule), nil, nil
+		}
+		constraints = append(constraints, constraint)
+		// the next constraint follows `,`, `and` or `, and`
+		next := 0
+		if p.peekTokenAt(next).TokType == lexer.OP_Comma {
+			next++
+		}
+		if p.peekTokenAt(next).TokType == lexer.KW_And {
+			next++
+		}
+		if next == 0 || !p.isConstraintAt(next) {
+			return true, nil, params, constraints
+		}
+		for ; next > 0; next-- {
+			p.getNextToken()
+		}
 	}
-	return true, nil
 }
 
-func (p *Parser) RangeStart() (bool, *ParseError) {
-	if ok, _ := p.maybeToken(lexer.KW_Start, "RangeStart"); ok {
-		p.getNextToken() // consume start
-		return true, nil
+// `T can do Method is function, ...`, where T is one of params
+func (p *Parser) Constraint(params []*ast.Identifier) (bool, *ParseError, *ast.Constraint) {
+	paramTok := *p.peekToken()
+	ok, err, param := p.Ident()
+	if !ok {
+		return false, err.addRule("Constraint-Param"), nil
 	}
-	if ok, err, _ := p.Number(); !ok {
-		return false, err.addRule("RangeStart-StartN")
+	known := false
+	for _, typeParam := range params {
+		known = known || typeParam.Name == param.Name
 	}
-	return true, nil
-
-}
-
-func (p *Parser) RangeEnd() (bool, *ParseError) {
-	if ok, _ := p.maybeToken(lexer.KW_End, "RangeEnd"); ok {
-		p.getNextToken() // consume end
-		return true, nil
+	if !known {
+		return false, NewParseError("Only type parameters can have constraints", paramTok, "Constraint-Param"), nil
 	}
-	if ok, err, _ := p.Number(); !ok {
-		return false, err.addRule("RangeEnd")
+	if ok, err, _ := p.expectToken(lexer.KW_Can, "Constraint-Can"); !ok {
+		return false, err, nil
+	}
+	if ok, err, _ := p.expectToken(lexer.KW_Do, "Constraint-Do"); !ok {
+		return false, err, nil
+	}
+	ok, err, method := p.Ident()
+	if !ok {
+		return false, err.addRule("Constraint-Method"), nil
+	}
+	if ok, err, _ := p.expectToken(lexer.KW_Is, "Constraint-Is"); !ok {
+		return false, err, nil
+	}
+	ok, err, function := p.FunctionType()
+	if !ok {
+		return false, err.addRule("Constraint-Type"), nil
+	}
+	return true, nil, ast.NewConstraint(param, method, function.(*ast.FunctionType))
+}
+
+// whether the constraint `T can do ...` starts n tokens ahead
+func (p *Parser) isConstraintAt(n int) bool {
+	return p.peekTokenAt(n).TokType == lexer.ItemIdent && p.peekTokenAt(n+1).TokType == lexer.KW_Can
+}
+
+// `variable Name is Type` or `constant Name is Type`. A field starts out with
+// the default value of its type.
+func (p *Parser) Field() (bool, *ParseError, ast.Declaration) {
+	keyword := p.getNextToken()
+	if keyword.TokType != lexer.KW_Variable && keyword.TokType != lexer.KW_Constant {
+		return false, NewParseError("Expected a field, declared with `variable` or `constant`", keyword, "Field"), nil
+	}
+	ok, err, name, typeName := p.IdentType()
+	if !ok {
+		return false, err.addRule("Field"), nil
+	}
+	if keyword.TokType == lexer.KW_Constant {
+		return true, nil, ast.NewConstDecl(&keyword, name, typeName, nil)
+	}
+	return true, nil, ast.NewVarDecl(&keyword, name, typeName, nil)
+}
+
+// `function Name is function ... done` after `and can do`
+func (p *Parser) Method() (bool, *ParseError, ast.Statement) {
+	if p.peekToken().TokType != lexer.KW_Function {
+		return false, NewParseError("Expected a method, declared with `function`", *p.peekToken(), "Method"), nil
+	}
+	return p.FunctionDecl()
+}
+
+// `function`, then its type parameters after `of` if it is generic, its
+// parameters after `, taking`, the type it returns after `, returning`, and
+// its body after `doing`. `does` can stand in for `doing`.
+func (p *Parser) FunctionLiteral() (bool, *ParseError, *ast.FunctionLiteral) {
+	ok, err, function := p.expectToken(lexer.KW_Function, "FunctionLiteral-Function")
+	if !ok {
+		return false, err, nil
+	}
+	ok, err, typeParams, constraints := p.TypeParams("FunctionLiteral-TypeParams")
+	if !ok {
+		return false, err, nil
+	}
+	var params []*ast.Parameter
+	if p.peekToken().TokType == lexer.OP_Comma && p.peekTokenAt(1).TokType == lexer.KW_Taking {
+		p.getNextToken() // consume `,`
+		p.getNextToken() // consume `taking`
+		if ok, err, params = p.Parameters(); !ok {
+			return false, err, nil
+		}
+	}
+	ok, err, returns := p.Returning("FunctionLiteral-Returning")
+	if !ok {
+		return false, err, nil
+	}
+	if p.peekToken().TokType == lexer.OP_Comma {
+		p.getNextToken()
+	}
+	if doing := p.getNextToken(); doing.TokType != lexer.KW_Doing && doing.TokType != lexer.KW_Does {
+		return false, NewParseError("Expected `doing` before the function's body", doing, "FunctionLiteral-Doing"), nil
+	}
+	// loops around the function cannot be stopped from inside it
+	loops := p.loops
+	p.functions, p.loops = p.functions+1, 0
+	ok, err, body := p.Block("FunctionLiteral-Body")
+	p.functions, p.loops = p.functions-1, loops
+	if !ok {
+		return false, err, nil
+	}
+	if ok, err := p.EndBlock("FunctionLiteral"); !ok {
+		return false, err, nil
+	}
+	return true, nil, ast.NewFunctionLiteral(function, typeParams, constraints, params, returns, body, p.lastToken)
+}
+
+// one or more parameters, listed like the types in TypeList:
+// `number A`, `number A and string B`, or `number A, string B, and boolean C`
+func (p *Parser) Parameters() (bool, *ParseError, []*ast.Parameter) {
+	var params []*ast.Parameter
+	for {
+		ok, err, param := p.Parameter()
+		if !ok {
+			return false, err.addRule("Parameters"), nil
+		}
+		params = append(params, param)
+		next := p.peekToken().TokType
+		switch {
+		case next == lexer.KW_And && isParameterStart(p.peekTokenAt(1)):
+			p.getNextToken() // consume `and`
+		case next == lexer.OP_Comma && p.peekTokenAt(1).TokType == lexer.KW_And && isParameterStart(p.peekTokenAt(2)):
+			p.getNextToken() // consume `,`
+			p.getNextToken() // consume `and`
+		case next == lexer.OP_Comma && isParameterStart(p.peekTokenAt(1)):
+			p.getNextToken() // consume `,`
+			continue
+		default:
+			return true, nil, params
+		}
+		// the last parameter, after `and`
+		ok, err, param = p.Parameter()
+		if !ok {
+			return false, err.addRule("Parameters-Last"), nil
+		}
+		return true, nil, append(params, param)
+	}
+}
+
+// `number N`, or `constant number N` for one the function cannot change. A
+// function type is followed by a comma before the name, as in
+// `function, taking E, and returning boolean, Keep`.
+func (p *Parser) Parameter() (bool, *ParseError, *ast.Parameter) {
+	first := *p.peekToken()
+	constant := first.TokType == lexer.KW_Constant
+	if constant {
+		p.getNextToken()
+	}
+	ok, err, typeName := p.TypeName()
+	if !ok {
+		return false, err.addRule("Parameter-Type"), nil
+	}
+	if _, isFunction := typeName.(*ast.FunctionType); isFunction && p.peekToken().TokType == lexer.OP_Comma && p.peekTokenAt(1).TokType == lexer.ItemIdent {
+		p.getNextToken() // consume `,`
+	}
+	ok, err, name := p.Ident()
+	if !ok {
+		return false, err.addRule("Parameter-Name"), nil
+	}
+	return true, nil, ast.NewParameter(&first, typeName, name, constant)
+}
+
+func isParameterStart(token *lexer.TokItem) bool {
+	return token.TokType == lexer.KW_Constant || isTypeStart(token)
+}
+
+// `return`, with a value unless the function returns nothing
+func (p *Parser) ReturnStmt() (bool, *ParseError, ast.Statement) {
+	keyword := p.getNextToken()
+	if p.functions == 0 {
+		return false, NewParseError("`return` can only be used inside a function", keyword, "ReturnStmt"), nil
+	}
+	switch p.peekToken().TokType {
+	case lexer.ItemSemicolon, lexer.ItemDedent, lexer.KW_Done, lexer.KW_Else, lexer.ItemEOF:
+		return true, nil, ast.NewReturnStmt(&keyword, nil)
+	}
+	ok, err, val := p.Expression()
+	if !ok {
+		return false, err.addRule("ReturnStmt"), nil
+	}
+	return true, nil, ast.NewReturnStmt(&keyword, val)
+}
+
+// `if Condition, then ... done`, with any number of `else if Condition, then`
+// branches and at most one `else, then` branch before the one `done`
+func (p *Parser) IfStmt() (bool, *ParseError, ast.Statement) {
+	ok, err, is := p.ifBranch()
+	if !ok {
+		return false, err, nil
+	}
+	if ok, err := p.EndBlock("IfStmt"); !ok {
+		return false, err, nil
+	}
+	is.Span = is.Span.Join(p.lastToken.TokSpan)
+	return true, nil, is
+}
+
+// `if Condition, then` and its block, and whatever follows its `else`
+func (p *Parser) ifBranch() (bool, *ParseError, *ast.IfStmt) {
+	ok, err, keyword := p.expectToken(lexer.KW_If, "IfStmt-If")
+	if !ok {
+		return false, err, nil
+	}
+	ok, err, condition := p.Expression()
+	if !ok {
+		return false, err.addRule("IfStmt-Condition"), nil
+	}
+	if ok, err := p.opener(lexer.KW_Then, "then", "IfStmt-Then"); !ok {
+		return false, err, nil
+	}
+	ok, err, then := p.Block("IfStmt-Body", lexer.KW_Else)
+	if !ok {
+		return false, err, nil
+	}
+	var otherwise *ast.Block
+	if p.peekToken().TokType == lexer.KW_Else {
+		p.getNextToken()
+		if p.peekToken().TokType == lexer.KW_If {
+			ok, err, elseIf := p.ifBranch()
+			if !ok {
+				return false, err.addRule("IfStmt-ElseIf"), nil
+			}
+			otherwise = ast.NewBlock([]ast.Statement{elseIf})
+		} else {
+			if ok, err := p.opener(lexer.KW_Then, "then", "IfStmt-Else"); !ok {
+				return false, err, nil
+			}
+			ok, err, stmts := p.Block("IfStmt-Else")
+			if !ok {
+				return false, err, nil
+			}
+			otherwise = ast.NewBlock(stmts)
+		}
+	}
+	return true, nil, ast.NewIfStmt(keyword, condition, ast.NewBlock(then), otherwise, p.lastToken)
+}
+
+// the keyword before a body, like `then` or `loop`, which can follow a comma
+func (p *Parser) opener(keyword lex.Token, word, rule string) (bool, *ParseError) {
+	if p.peekToken().TokType == lexer.OP_Comma {
+		p.getNextToken()
+	}
+	if token := p.getNextToken(); token.TokType != keyword {
+		return false, NewParseError(fmt.Sprintf("Expected `%s` before the body", word), token, rule)
 	}
 	return true, nil
 }
 
+// `for Type Name from ..., loop ... done`, going through a range, a slice, or
+// all of a list, string or map with `from Collection`. The variable is
+// written like a parameter.
+func (p *Parser) ForLoop() (bool, *ParseError, ast.Statement) {
+	ok, err, keyword := p.expectToken(lexer.KW_For, "ForLoop-For")
+	if !ok {
+		return false, err, nil
+	}
+	ok, err, variable := p.Parameter()
+	if !ok {
+		return false, err.addRule("ForLoop-Variable"), nil
+	}
+	if next := p.peekToken(); next.TokType != lexer.KW_From && next.TokType != lexer.KW_Every {
+		return false, NewParseError("Expected `from` and what to loop over", *next, "ForLoop-From"), nil
+	}
+	ok, err, source := p.RangeOrSlice()
+	if !ok {
+		return false, err.addRule("ForLoop-Source"), nil
+	}
+	// all of a collection is looped over as it is, which works for maps too
+	if se, isSlice := source.(*ast.SliceExpr); isSlice && wholeRange(se.Range) {
+		source = se.Collection
+	}
+	ok, err, body := p.loopBody("ForLoop")
+	if !ok {
+		return false, err, nil
+	}
+	return true, nil, ast.NewForLoop(keyword, variable, source, body, p.lastToken)
+}
+
+// `while Condition, loop ... done`
+func (p *Parser) WhileLoop() (bool, *ParseError, ast.Statement) {
+	ok, err, keyword := p.expectToken(lexer.KW_While, "WhileLoop-While")
+	if !ok {
+		return false, err, nil
+	}
+	ok, err, condition := p.Expression()
+	if !ok {
+		return false, err.addRule("WhileLoop-Condition"), nil
+	}
+	ok, err, body := p.loopBody("WhileLoop")
+	if !ok {
+		return false, err, nil
+	}
+	return true, nil, ast.NewWhileLoop(keyword, condition, body, p.lastToken)
+}
+
+// `, loop`, then the block a loop repeats, up to and including its `done`
+func (p *Parser) loopBody(rule string) (bool, *ParseError, []ast.Statement) {
+	if ok, err := p.opener(lexer.KW_Loop, "loop", rule+"-Loop"); !ok {
+		return false, err, nil
+	}
+	p.loops++
+	ok, err, body := p.Block(rule + "-Body")
+	p.loops--
+	if !ok {
+		return false, err, nil
+	}
+	if ok, err := p.EndBlock(rule); !ok {
+		return false, err, nil
+	}
+	return true, nil, body
+}
+
+// `stop`, which leaves the innermost loop, or `skip`, which goes on to the
+// next time round it
+func (p *Parser) LoopControl() (bool, *ParseError, ast.Statement) {
+	keyword := p.getNextToken()
+	word := "stop"
+	if keyword.TokType == lexer.KW_Skip {
+		word = "skip"
+	}
+	if p.loops == 0 {
+		return false, NewParseError(fmt.Sprintf("`%s` can only be used inside a loop", word), keyword, "LoopControl"), nil
+	}
+	if keyword.TokType == lexer.KW_Skip {
+		return true, nil, ast.NewSkipStmt(&keyword)
+	}
+	return true, nil, ast.NewStopStmt(&keyword)
+}
+
+// whether rl is `from start to end` of the collection it slices, every one
+func wholeRange(rl *ast.RangeLiteral) bool {
+	return rl.Step == nil && rl.FromStart && rl.ToEnd && rl.StartOf == nil && rl.Of == nil
+}
+
+// one or more types, like `number`, `number and string` or
+// `number, string, and boolean`. The list ends after the type following
+// `and`, or at the first comma that is not followed by another type.
+func (p *Parser) TypeList(rule string) (bool, *ParseError, []ast.TypeExpr) {
+	var types []ast.TypeExpr
+	for {
+		ok, err, t := p.TypeName()
+		if !ok {
+			return false, err.addRule(rule), nil
+		}
+		types = append(types, t)
+		next := p.peekToken().TokType
+		switch {
+		case next == lexer.KW_And && p.isTypeAt(1):
+			p.getNextToken() // consume `and`
+		case next == lexer.OP_Comma && p.peekTokenAt(1).TokType == lexer.KW_And && p.isTypeAt(2):
+			p.getNextToken() // consume `,`
+			p.getNextToken() // consume `and`
+		case next == lexer.OP_Comma && p.isTypeAt(1):
+			p.getNextToken() // consume `,`
+			continue
+		default:
+			return true, nil, types
+		}
+		// the last type, after `and`
+		ok, err, t = p.TypeName()
+		if !ok {
+			return false, err.addRule(rule + "-Last"), nil
+		}
+		return true, nil, append(types, t)
+	}
+}
+
+// whether a type starts n tokens ahead, rather than the next constraint
+// after `where`
+func (p *Parser) isTypeAt(n int) bool {
+	return isTypeStart(p.peekTokenAt(n)) && !p.isConstraintAt(n)
+}
+
+func isTypeStart(token *lexer.TokItem) bool {
+	switch token.TokType {
+	case lexer.TN_Number, lexer.TN_String, lexer.TN_Boolean, lexer.TN_List, lexer.TN_Map, lexer.KW_Function, lexer.ItemIdent:
+		return true
+	}
+	return false
+}
+
+// binding power of each binary operator, loosest first, following
+// docs/operators.md. `not` and unary minus are prefix operators that sit
+// between these levels, see Unary.
+const (
+	precLogical        = iota + 1 // and or
+	precNot                       // not
+	precComparison                // > >= < <= == !=
+	precAdditive                  // + -
+	precMultiplicative            // * / %
+	precExponent                  // ^
+)
+
+var binaryPrecedence = map[lex.Token]int{
+	lexer.KW_And:     precLogical,
+	lexer.KW_Or:      precLogical,
+	lexer.OP_Gt:      precComparison,
+	lexer.OP_GtEq:    precComparison,
+	lexer.OP_Lt:      precComparison,
+	lexer.OP_LtEq:    precComparison,
+	lexer.OP_Eq:      precComparison,
+	lexer.OP_Neq:     precComparison,
+	lexer.OP_Plus:    precAdditive,
+	lexer.OP_Minus:   precAdditive,
+	lexer.OP_Star:    precMultiplicative,
+	lexer.OP_Slash:   precMultiplicative,
+	lexer.OP_Percent: precMultiplicative,
+	lexer.OP_Caret:   precExponent,
+}
+
+func (p *Parser) Expression() (bool, *ParseError, ast.Visitable) {
+	ok, err, expr := p.BinaryExpr(precLogical)
+	if !ok {
+		return false, err.addRule("Expression"), nil
+	}
+	return true, nil, expr
+}
+
+// precedence climbing: parse operands joined by binary operators that bind at
+// least as tightly as minPrec. Operators of equal precedence are
+// left-associative.
+func (p *Parser) BinaryExpr(minPrec int) (bool, *ParseError, ast.Visitable) {
+	ok, err, left := p.Unary()
+	if !ok {
+		return false, err.addRule("BinaryExpr-Left"), nil
+	}
+	for {
+		if minPrec <= precComparison && p.isNothingCheck() {
+			p.getNextToken() // consume `is`
+			negated := p.peekToken().TokType == lexer.KW_Not
+			if negated {
+				p.getNextToken()
+			}
+			nothing := p.getNextToken()
+			left = ast.NewNothingCheck(left, negated, &nothing)
+			continue
+		}
+		prec, isBinary := binaryPrecedence[p.peekToken().TokType]
+		if !isBinary || prec < minPrec {
+			return true, nil, left
+		}
+		if prec == precComparison {
+			ok, err, comparison := p.Comparison(left)
+			if !ok {
+				return false, err.addRule("BinaryExpr-Comparison"), nil
+			}
+			left = comparison
+			continue
+		}
+		operator := p.getNextToken()
+		ok, err, right := p.BinaryExpr(prec + 1)
+		if !ok {
+			return false, err.addRule("BinaryExpr-Right"), nil
+		}
+		left = ast.NewBinaryExpr(left, &operator, right)
+	}
+}
+
+// whether the next tokens are `is nothing` or `is not nothing`
+func (p *Parser) isNothingCheck() bool {
+	if p.peekToken().TokType != lexer.KW_Is {
+		return false
+	}
+	next := 1
+	if p.peekTokenAt(next).TokType == lexer.KW_Not {
+		next++
+	}
+	return p.peekTokenAt(next).TokType == lexer.LT_Nothing
+}
+
+// which way a comparison points; `==` goes either way
+var comparisonDirection = map[lex.Token]int{
+	lexer.OP_Lt:   -1,
+	lexer.OP_LtEq: -1,
+	lexer.OP_Eq:   0,
+	lexer.OP_Gt:   1,
+	lexer.OP_GtEq: 1,
+}
+
+// one or more comparisons following an already-parsed left operand. A single
+// comparison is a plain BinaryExpr; longer chains like `0 < N < Q` must all
+// point the same way and become a ComparisonChain.
+func (p *Parser) Comparison(left ast.Visitable) (bool, *ParseError, ast.Visitable) {
+	operands := []ast.Visitable{left}
+	var operators []lexer.TokItem
+	for binaryPrecedence[p.peekToken().TokType] == precComparison {
+		operators = append(operators, p.getNextToken())
+		ok, err, right := p.BinaryExpr(precComparison + 1)
+		if !ok {
+			return false, err.addRule("Comparison-Right"), nil
+		}
+		operands = append(operands, right)
+	}
+	if len(operators) == 1 {
+		return true, nil, ast.NewBinaryExpr(operands[0], &operators[0], operands[1])
+	}
+	direction := 0
+	operatorNames := make([]string, 0, len(operators))
+	for _, operator := range operators {
+		dir, chainable := comparisonDirection[operator.TokType]
+		if !chainable {
+			return false, NewParseError(fmt.Sprintf("`%v` cannot be part of a comparison chain, join the comparisons with `and` instead", operator.TokValue), operator, "Comparison"), nil
+		}
+		if dir != 0 && direction != 0 && dir != direction {
+			return false, NewParseError(fmt.Sprintf("Comparison chains must point one way, but `%v` turns this one around; join the comparisons with `and` instead", operator.TokValue), operator, "Comparison"), nil
+		}
+		if dir != 0 {
+			direction = dir
+		}
+		operatorNames = append(operatorNames, operator.TokValue.(string))
+	}
+	return true, nil, ast.NewComparisonChain(operands, operatorNames)
+}
+
+// `not` applies to a whole comparison, unary minus only to the operand
+// directly after it.
+func (p *Parser) Unary() (bool, *ParseError, ast.Visitable) {
+	switch p.peekToken().TokType {
+	case lexer.KW_Not:
+		operator := p.getNextToken()
+		ok, err, operand := p.BinaryExpr(precComparison)
+		if !ok {
+			return false, err.addRule("Unary-Not"), nil
+		}
+		return true, nil, ast.NewUnaryExpr(&operator, operand)
+	case lexer.OP_Minus:
+		if p.isNegativeIndex() {
+			return p.Postfix()
+		}
+		operator := p.getNextToken()
+		ok, err, operand := p.Unary()
+		if !ok {
+			return false, err.addRule("Unary-Minus"), nil
+		}
+		return true, nil, ast.NewUnaryExpr(&operator, operand)
+	default:
+		return p.Postfix()
+	}
+}
+
+// `-1-th` and `-N-th` negate the index rather than the element, so they are
+// caught as out of range instead of reading as `-(1-th from Collection)`
+func (p *Parser) isNegativeIndex() bool {
+	if p.peekToken().TokType != lexer.OP_Minus || p.peekTokenAt(2).TokType != lexer.KW_Th {
+		return false
+	}
+	tokType := p.peekTokenAt(1).TokType
+	return tokType == lexer.LT_Number || tokType == lexer.ItemIdent
+}
+
+// a value, possibly indexed as `N-th from Collection` or sliced as `A-th to
+// B-th from Collection`. `start` and `end` stand in for the first and last
+// index. `of` works as well as `from`, and the collection may itself be
+// indexed, so `1-th from 2-th from Nested` indexes twice.
+func (p *Parser) Postfix() (bool, *ParseError, ast.Visitable) {
+	var index ast.Visitable
+	var keyword *lexer.TokItem
+	switch p.peekToken().TokType {
+	case lexer.KW_Start, lexer.KW_End:
+		token := p.getNextToken()
+		keyword = &token
+	case lexer.OP_Minus:
+		operator := p.getNextToken()
+		ok, err, value := p.Value()
+		if !ok {
+			return false, err.addRule("Postfix-NegativeIndex"), nil
+		}
+		p.getNextToken() // consume `-th`, checked by isNegativeIndex
+		index = ast.NewUnaryExpr(&operator, value)
+	default:
+		ok, err, value := p.FieldOrValue()
+		if !ok {
+			return false, err, nil
+		}
+		if p.peekToken().TokType != lexer.KW_Th {
+			return true, nil, value
+		}
+		p.getNextToken() // consume `-th`
+		index = value
+	}
+	if p.peekToken().TokType == lexer.KW_To {
+		to := p.getNextToken()
+		if keyword != nil && keyword.TokType == lexer.KW_End {
+			return false, NewParseError("Slices cannot start at `end`", *keyword, "Postfix-SliceStart"), nil
+		}
+		var end ast.Visitable
+		if ok, _ := p.maybeToken(lexer.KW_End, "Postfix-SliceEnd"); ok {
+			p.getNextToken() // consume `end`
+		} else {
+			ok, err, value := p.Value()
+			if !ok {
+				return false, err.addRule("Postfix-SliceEnd"), nil
+			}
+			if ok, err, _ := p.expectToken(lexer.KW_Th, "Postfix-SliceEndTh"); !ok {
+				return false, err, nil
+			}
+			end = value
+		}
+		last := p.lastToken
+		ok, err, collection := p.Collection()
+		if !ok {
+			return false, err.addRule("Postfix-Slice"), nil
+		}
+		return true, nil, ast.NewSliceExpr(ast.NewRangeLiteral(&to, nil, index, nil, end, nil, last), collection)
+	}
+	ok, err, collection := p.Collection()
+	if !ok {
+		return false, err.addRule("Postfix-Index"), nil
+	}
+	return true, nil, ast.NewIndexExpr(index, keyword, collection)
+}
+
+// `from Collection` or `of Collection` after an index or slice
+func (p *Parser) Collection() (bool, *ParseError, ast.Visitable) {
+	if tokType := p.peekToken().TokType; tokType != lexer.KW_From && tokType != lexer.KW_Of {
+		return false, NewParseError("Expected `from` or `of` and a collection", *p.peekToken(), "Collection"), nil
+	}
+	p.getNextToken() // consume `from` or `of`
+	ok, err, collection := p.Postfix()
+	if !ok {
+		return false, err.addRule("Collection"), nil
+	}
+	return true, nil, collection
+}
+
+// a value, or a field of one: `Field of Object`, where Object can itself be
+// a field, as in `Next of Next of Current`, or an element
+func (p *Parser) FieldOrValue() (bool, *ParseError, ast.Visitable) {
+	if typeName := p.structLiteralType(); typeName != nil {
+		ok, err, literal := p.StructLiteral(typeName)
+		return ok, err, literal
+	}
+	if p.peekToken().TokType != lexer.ItemIdent || p.peekTokenAt(1).TokType != lexer.KW_Of {
+		return p.Value()
+	}
+	ok, err, field := p.Ident()
+	if !ok {
+		return false, err, nil
+	}
+	p.getNextToken() // consume `of`
+	ok, err, object := p.Postfix()
+	if !ok {
+		return false, err.addRule("FieldOrValue-Object"), nil
+	}
+	return true, nil, ast.NewFieldExpr(field, object)
+}
+
+func (p *Parser) Value() (bool, *ParseError, ast.Visitable) {
+	switch p.peekToken().TokType {
+	case lexer.ItemIdent:
+		ok, err, ident := p.Ident()
+		return ok, err, ident
+	case lexer.KW_This:
+		this := p.getNextToken()
+		if p.methods == 0 {
+			return false, NewParseError("`this` can only be used inside a method", this, "Value-This"), nil
+		}
+		return true, nil, ast.NewIdentifier(&this)
+	case lexer.OP_Lparen:
+		lparen := p.getNextToken()
+		ok, err, inner := p.Expression()
+		if !ok {
+			return false, err.addRule("Value-Grouped"), nil
+		}
+		ok, err, rparen := p.expectToken(lexer.OP_Rparen, "Value-Rparen")
+		if !ok {
+			return false, err, nil
+		}
+		return true, nil, ast.NewGroupedExpr(&lparen, inner, rparen)
+	case lexer.LT_Number:
+		ok, err, val := p.NumberLiteral()
+		return ok, err, val
+	case lexer.LT_Boolean:
+		ok, err, val := p.BooleanLiteral()
+		return ok, err, val
+	case lexer.LT_String:
+		ok, err, val := p.StringLiteral()
+		return ok, err, val
+	case lexer.LT_Nothing:
+		nothing := p.getNextToken()
+		return true, nil, ast.NewNothingLiteral(&nothing)
+	case lexer.KW_Containing:
+		if p.isMapLiteral() {
+			ok, err, mapLiteral := p.MapLiteral()
+			return ok, err, mapLiteral
+		}
+		ok, err, list := p.ListLiteral()
+		return ok, err, list
+	case lexer.KW_From, lexer.KW_Every:
+		return p.RangeOrSlice()
+	case lexer.KW_Do:
+		ok, err, call := p.FunctionCall()
+		return ok, err, call
+	case lexer.KW_Function:
+		start := *p.peekToken()
+		ok, err, function := p.FunctionLiteral()
+		if ok && len(function.TypeParams) > 0 {
+			return false, NewParseError("Only functions declared by name can be generic", start, "Value-Function"), nil
+		}
+		return ok, err, function
+	default:
+		return false, NewParseError("Expected value", *p.peekToken(), "Value"), nil
+	}
+}
+
+func (p *Parser) PrimitiveLiteral() (bool, *ParseError, ast.Visitable) {
+	switch p.peekToken().TokType {
+	case lexer.LT_Number:
+		return p.NumberLiteral()
+	case lexer.LT_Boolean:
+		return p.BooleanLiteral()
+	case lexer.LT_String:
+		return p.StringLiteral()
+	default:
+		return false, NewParseError("Expected primitive literal", *p.peekToken(), "PrimitiveLiteral"), nil
+	}
+}
+
+func (p *Parser) NumberLiteral() (bool, *ParseError, *ast.NumberLiteral) {
+	if ok, err, token := p.expectToken(lexer.LT_Number, "NumberLiteral"); !ok {
+		return false, err, nil
+	} else {
+		return true, nil, ast.NewNumberLiteral(token)
+	}
+}
+
+func (p *Parser) BooleanLiteral() (bool, *ParseError, *ast.BooleanLiteral) {
+	if ok, err, token := p.expectToken(lexer.LT_Boolean, "BooleanLiteral"); !ok {
+		return false, err, nil
+	} else {
+		return true, nil, ast.NewBooleanLiteral(token)
+	}
+}
+
+func (p *Parser) StringLiteral() (bool, *ParseError, *ast.StringLiteral) {
+	if ok, err, token := p.expectToken(lexer.LT_String, "StringLiteral"); !ok {
+		return false, err, nil
+	} else {
+		return true, nil, ast.NewStringLiteral(token)
+	}
+}
+
+func (p *Parser) ListLiteral() (bool, *ParseError, *ast.ListLiteral) {
+	ok, err, containing := p.expectToken(lexer.KW_Containing, "ListLiteral-Containing")
+	if !ok {
+		return false, err, nil
+	}
+	element := p.peekToken()
+	if element.TokType == lexer.LT_Nothing {
+		p.getNextToken() // consume `nothing`
+		ok, err, done := p.expectToken(lexer.KW_Done, "ListLiteral-NothingDone")
+		if !ok {
+			return false, err, nil
+		}
+		return true, nil, ast.NewListLiteral(containing, nil, done)
+	}
+	ok, err, elements := p.ListElements()
+	if !ok {
+		return false, err.addRule("ListLiteral"), nil
+	}
+	ok, err, done := p.expectToken(lexer.KW_Done, "ListLiteral-SomethingDone")
+	if !ok {
+		return false, err, nil
+	}
+	return true, nil, ast.NewListLiteral(containing, elements, done)
+}
+
+func (p *Parser) ListElements() (bool, *ParseError, []ast.Visitable) {
+	var elements []ast.Visitable
+	ok, err := p.commaElements("ListElements", func() (bool, *ParseError) {
+		ok, err, element := p.ListValue()
+		elements = append(elements, element)
+		return ok, err
+	})
+	return ok, err, elements
+}
+
+// the elements of a literal, each followed by a comma, with `and` before the
+// last of two or more: `A,`, `A, and B,`, `A, B, and C,`
+func (p *Parser) commaElements(rule string, element func() (bool, *ParseError)) (bool, *ParseError) {
+	if ok, err := element(); !ok {
+		return false, err.addRule(rule + "-One")
+	}
+	if ok, err, _ := p.expectToken(lexer.OP_Comma, rule+"-OneComma"); !ok {
+		return false, err
+	}
+	if p.peekToken().TokType == lexer.KW_Done {
+		// single element, exit
+		return true, nil
+	}
+	for {
+		if p.peekToken().TokType == lexer.KW_And { // exit when see the last element
+			p.getNextToken() // consume `and``
+			break
+		}
+		if ok, err := element(); !ok {
+			return false, err.addRule(rule + "-MoreThan1")
+		}
+		if ok, err, _ := p.expectToken(lexer.OP_Comma, rule+"-TwoComma"); !ok {
+			return false, err
+		}
+	}
+	if ok, err := element(); !ok {
+		// last element
+		return false, err.addRule(rule + "-LastElement")
+	}
+	if ok, err, _ := p.expectToken(lexer.OP_Comma, rule+"-LastComma"); !ok {
+		return false, err
+	}
+	return true, nil
+}
+
+// whether the literal after `containing` is a map, which is when its first
+// element has an `as`
+func (p *Parser) isMapLiteral() bool {
+	depth := 0
+	for _, token := range p.Tokens[1:] {
+		switch token.TokType {
+		case lexer.OP_Lparen, lexer.KW_Containing:
+			depth++
+		case lexer.OP_Rparen, lexer.KW_Done:
+			if depth == 0 {
+				return false
+			}
+			depth--
+		case lexer.OP_Comma, lexer.ItemSemicolon:
+			if depth == 0 {
+				return false
+			}
+		case lexer.KW_As:
+			if depth == 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// `containing Key as Value, ..., and Key as Value, done`
+func (p *Parser) MapLiteral() (bool, *ParseError, *ast.MapLiteral) {
+	ok, err, containing := p.expectToken(lexer.KW_Containing, "MapLiteral-Containing")
+	if !ok {
+		return false, err, nil
+	}
+	var keys, values []ast.Visitable
+	ok, err = p.commaElements("MapElements", func() (bool, *ParseError) {
+		ok, err, key := p.Expression()
+		if !ok {
+			return false, err.addRule("MapPair-Key")
+		}
+		if ok, err, _ := p.expectToken(lexer.KW_As, "MapPair-As"); !ok {
+			return false, err
+		}
+		ok, err, val := p.Expression()
+		if !ok {
+			return false, err.addRule("MapPair-Value")
+		}
+		keys, values = append(keys, key), append(values, val)
+		return true, nil
+	})
+	if !ok {
+		return false, err.addRule("MapLiteral"), nil
+	}
+	ok, err, done := p.expectToken(lexer.KW_Done, "MapLiteral-Done")
+	if !ok {
+		return false, err, nil
+	}
+	return true, nil, ast.NewMapLiteral(containing, keys, values, done)
+}
+
+// an element stops at its comma, so even `A and B, and C` is unambiguous
+func (p *Parser) ListValue() (bool, *ParseError, ast.Visitable) {
+	switch p.peekToken().TokType {
+	case lexer.KW_From, lexer.KW_Every:
+		return p.RangeOrSlice()
+	default:
+		ok, err, element := p.Expression()
+		if !ok {
+			return false, err.addRule("ListValue"), nil
+		}
+		return true, nil, element
+	}
+}
+
+// a range, or a slice when the range is followed by `from Collection`.
+// `every N-th from Collection`, with no bounds, slices all of Collection.
+func (p *Parser) RangeOrSlice() (bool, *ParseError, ast.Visitable) {
+	if p.wholeSlice() {
+		first := *p.peekToken()
+		var step ast.Visitable
+		if ok, _ := p.maybeToken(lexer.KW_Every, "RangeOrSlice-Every"); ok {
+			p.getNextToken() // consume `every`
+			ok, err, nth := p.Nth()
+			if !ok {
+				return false, err.addRule("RangeOrSlice-EveryNth"), nil
+			}
+			step = nth
+		}
+		last := p.lastToken
+		ok, err, collection := p.Collection()
+		if !ok {
+			return false, err.addRule("RangeOrSlice-Whole"), nil
+		}
+		return true, nil, ast.NewSliceExpr(ast.NewRangeLiteral(&first, step, nil, nil, nil, nil, last), collection)
+	}
+	ok, err, rangeLiteral := p.RangeLiteral()
+	if !ok {
+		return false, err, nil
+	}
+	if p.peekToken().TokType != lexer.KW_From {
+		return true, nil, rangeLiteral
+	}
+	ok, err, collection := p.Collection()
+	if !ok {
+		return false, err.addRule("RangeOrSlice-Slice"), nil
+	}
+	return true, nil, ast.NewSliceExpr(rangeLiteral, collection)
+}
+
+// whether the tokens ahead are `[every N-th] from Collection` rather than a
+// range: what follows `from` is not a range start.
+func (p *Parser) wholeSlice() bool {
+	i := 0
+	for i < len(p.Tokens) && p.Tokens[i].TokType != lexer.KW_From {
+		i++
+	}
+	if i+1 >= len(p.Tokens) {
+		return false
+	}
+	switch p.Tokens[i+1].TokType {
+	case lexer.KW_Start, lexer.OP_Minus:
+		return false
+	case lexer.ItemIdent, lexer.LT_Number:
+		if i+2 >= len(p.Tokens) {
+			return true
+		}
+		next := p.Tokens[i+2].TokType
+		return next != lexer.KW_To && next != lexer.KW_Th
+	default:
+		return true
+	}
+}
+
+func (p *Parser) RangeLiteral() (bool, *ParseError, *ast.RangeLiteral) {
+	first := *p.peekToken()
+	var step ast.Visitable
+	if ok, _ := p.maybeToken(lexer.KW_Every, "RangeLiteral-Every"); ok {
+		// consume `every`
+		p.getNextToken()
+		ok, err, nth := p.Nth()
+		if !ok {
+			return false, err.addRule("RangeLiteral-EveryNth"), nil
+		}
+		step = nth
+	}
+	if ok, err, _ := p.expectToken(lexer.KW_From, "RangeLiteral-From"); !ok {
+		return false, err, nil
+	}
+	ok, err, start, startOf := p.RangeStart()
+	if !ok {
+		return false, err.addRule("RangeLiteral-Start"), nil
+	}
+	if ok, err, _ := p.expectToken(lexer.KW_To, "RangeLiteral-To"); !ok {
+		return false, err, nil
+	}
+	ok, err, end := p.RangeEnd()
+	if !ok {
+		return false, err.addRule("RangeLiteral-End"), nil
+	}
+	var of ast.Visitable
+	if ok, _ := p.maybeToken(lexer.KW_Of, "RangeLiteral-Of"); ok {
+		p.getNextToken() // consume `of`
+		ok, err, collection := p.FieldOrValue()
+		if !ok {
+			return false, err.addRule("RangeLiteral-Collection"), nil
+		}
+		of = collection
+	}
+	return true, nil, ast.NewRangeLiteral(&first, step, start, startOf, end, of, p.lastToken)
+}
+
+// nil for `start`, along with the collection in `start of Collection`
+func (p *Parser) RangeStart() (bool, *ParseError, ast.Visitable, ast.Visitable) {
+	if ok, _ := p.maybeToken(lexer.KW_Start, "RangeStart"); ok {
+		p.getNextToken() // consume start
+		if ok, _ := p.maybeToken(lexer.KW_Of, "RangeStart-Of"); !ok {
+			return true, nil, nil, nil
+		}
+		p.getNextToken() // consume `of`
+		ok, err, collection := p.FieldOrValue()
+		if !ok {
+			return false, err.addRule("RangeStart-Collection"), nil, nil
+		}
+		return true, nil, nil, collection
+	}
+	ok, err, start := p.Number()
+	if !ok {
+		return false, err.addRule("RangeStart-StartN"), nil, nil
+	}
+	p.maybeTh()
+	return true, nil, start, nil
+
+}
+
+// nil for `end`
+func (p *Parser) RangeEnd() (bool, *ParseError, ast.Visitable) {
+	if ok, _ := p.maybeToken(lexer.KW_End, "RangeEnd"); ok {
+		p.getNextToken() // consume end
+		return true, nil, nil
+	}
+	ok, err, end := p.Number()
+	if !ok {
+		return false, err.addRule("RangeEnd"), nil
+	}
+	p.maybeTh()
+	return true, nil, end
+}
+
+// range bounds may be written as indexes, like `from 1-th to 5-th`
+func (p *Parser) maybeTh() {
+	if ok, _ := p.maybeToken(lexer.KW_Th, "Th"); ok {
+		p.getNextToken()
+	}
+}
+
 func (p *Parser) Ident() (bool, *ParseError, *ast.Identifier) {
 	ident := p.getNextToken()
 	if ident.TokType != lexer.ItemIdent {
@@ -404,9 +1779,18 @@ func (p *Parser) Ident() (bool, *ParseError, *ast.Identifier) {
 	return true, nil, ast.NewIdentifier(&ident)
 }
 
-func (p *Parser) Number() (bool, *ParseError, ast.HasValue) {
+func (p *Parser) Number() (bool, *ParseError, ast.Visitable) {
+	if ok, _ := p.maybeToken(lexer.OP_Minus, "Number-Minus"); ok {
+		operator := p.getNextToken()
+		ok, err, operand := p.Number()
+		if !ok {
+			return false, err, nil
+		}
+		return true, nil, ast.NewUnaryExpr(&operator, operand)
+	}
 	if ok, _ := p.maybeToken(lexer.ItemIdent, "Number-Ident"); ok {
-		return p.Ident()
+		ok, err, ident := p.Ident()
+		return ok, err, ident
 	}
 	if ok, _ := p.maybeToken(lexer.LT_Number, "Number-NumberLiteral"); ok {
 		ok, err, val := p.NumberLiteral()
@@ -415,33 +1799,87 @@ func (p *Parser) Number() (bool, *ParseError, ast.HasValue) {
 	return false, NewParseError("Expected number", *p.lastToken, "Number"), nil
 }
 
-func (p *Parser) Nth() (bool, *ParseError) {
-	if ok, err, _ := p.Number(); !ok {
-		return false, err.addRule("Nth-Number")
+func (p *Parser) Nth() (bool, *ParseError, ast.Visitable) {
+	ok, err, n := p.Number()
+	if !ok {
+		return false, err.addRule("Nth-Number"), nil
 	}
-	ok, err, _ := p.expectToken(lexer.KW_Th, "Nth-Th")
-	return ok, err
+	if ok, err, _ := p.expectToken(lexer.KW_Th, "Nth-Th"); !ok {
+		return false, err, nil
+	}
+	return true, nil, n
 }
 
+// `do Name`, `do Name to nothing`, or `do Name to` its arguments. A method is
+// called on a struct with `do Name of Receiver`. Each argument is a whole
+// expression, so a call used inside a larger expression needs parentheses:
+// `(do F to 1) + 2`.
 func (p *Parser) FunctionCall() (bool, *ParseError, *ast.FunctionCall) {
-	call := ast.FunctionCall{}
-	if ok, err, _ := p.expectToken(lexer.KW_Do, "FunctionCall-Do"); !ok {
+	ok, err, do := p.expectToken(lexer.KW_Do, "FunctionCall-Do")
+	if !ok {
 		return false, err, nil
 	}
-	if ok, err, funcname := p.expectToken(lexer.ItemIdent, "FunctionCall-FuncName"); !ok {
-		return false, err, nil
-	} else {
-		call.FuncName = ast.NewIdentifier(funcname)
+	ok, err, name := p.Ident()
+	if !ok {
+		return false, err.addRule("FunctionCall-FuncName"), nil
 	}
-	if ok, err, _ := p.expectToken(lexer.KW_To, "FunctionCall-To"); !ok {
-		return false, err, nil
+	var receiver ast.Visitable
+	if p.peekToken().TokType == lexer.KW_Of {
+		p.getNextToken() // consume `of`
+		if ok, err, receiver = p.Postfix(); !ok {
+			return false, err.addRule("FunctionCall-Receiver"), nil
+		}
 	}
-	// TODO: Change to proper expr
-	if ok, err, primitive := p.PrimitiveLiteral(); !ok {
-		return false, err.addRule("FunctionCall-Parameter1"), nil
-	} else {
-		call.FuncParams = primitive
+	if p.peekToken().TokType != lexer.KW_To {
+		return true, nil, ast.NewFunctionCall(do, name, receiver, nil, p.lastToken)
+	}
+	p.getNextToken() // consume `to`
+	if p.peekToken().TokType == lexer.LT_Nothing {
+		nothing := p.getNextToken()
+		return true, nil, ast.NewFunctionCall(do, name, receiver, nil, &nothing)
+	}
+	ok, err, args := p.Arguments()
+	if !ok {
+		return false, err.addRule("FunctionCall"), nil
+	}
+	return true, nil, ast.NewFunctionCall(do, name, receiver, args, p.lastToken)
+}
+
+// one or more arguments, with a comma and `and` before the last of two or
+// more: `A`, `A, and B`, `A, B, and C`
+func (p *Parser) Arguments() (bool, *ParseError, []ast.Visitable) {
+	var args []ast.Visitable
+	for {
+		ok, err, arg := p.Expression()
+		if !ok {
+			return false, err.addRule("Arguments"), nil
+		}
+		args = append(args, arg)
+		switch {
+		case p.peekToken().TokType == lexer.OP_Comma && p.peekTokenAt(1).TokType == lexer.KW_And:
+			p.getNextToken() // consume `,`
+			p.getNextToken() // consume `and`
+			ok, err, arg := p.Expression()
+			if !ok {
+				return false, err.addRule("Arguments-Last"), nil
+			}
+			return true, nil, append(args, arg)
+		case p.peekToken().TokType == lexer.OP_Comma && isExpressionStart(p.peekTokenAt(1)):
+			p.getNextToken() // consume `,`
+		case len(args) > 1:
+			return false, NewParseError("Expected `, and` before the last argument", *p.peekToken(), "Arguments"), nil
+		default:
+			return true, nil, args
+		}
 	}
+}
 
-	return true, nil, &call
+func isExpressionStart(token *lexer.TokItem) bool {
+	switch token.TokType {
+	case lexer.ItemIdent, lexer.LT_Number, lexer.LT_Boolean, lexer.LT_String, lexer.LT_Nothing,
+		lexer.OP_Lparen, lexer.OP_Minus, lexer.KW_Not, lexer.KW_Containing, lexer.KW_From,
+		lexer.KW_Every, lexer.KW_Start, lexer.KW_End, lexer.KW_Do, lexer.KW_Function, lexer.KW_This:
+		return true
+	}
+	return false
 }
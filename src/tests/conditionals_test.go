@@ -0,0 +1,191 @@
+package tests
+
+import (
+	"nicer-syntax/ast"
+	"nicer-syntax/parser"
+	"testing"
+)
+
+func TestStringConditionals(t *testing.T) {
+	tests := []struct {
+		input  string
+		parsed string
+	}{
+		{"if X, then\n    Y is 1\ndone", `Statement(If(X, then Statement(VarAssignment(Y 1))))`},
+		{"if X then Y is 1 done", `Statement(If(X, then Statement(VarAssignment(Y 1))))`},
+		{"if X, then\n    nothing\ndone", `Statement(If(X, then nothing))`},
+		{
+			"if X > 1, then\n    Y is 1\nelse, then\n    Y is 2\ndone",
+			`Statement(If(BinaryExpr(X > 1), then Statement(VarAssignment(Y 1)), else Statement(VarAssignment(Y 2))))`,
+		},
+		{
+			"if X, then\n    Y is 1\nelse if Z, then\n    Y is 2\nelse if not Z, then\n    Y is 3\nelse, then\n    Y is 4\ndone",
+			`Statement(If(X, then Statement(VarAssignment(Y 1)), else Statement(If(Z, then Statement(VarAssignment(Y 2)), else Statement(If(UnaryExpr(not Z), then Statement(VarAssignment(Y 3)), else Statement(VarAssignment(Y 4))))))))`,
+		},
+		{
+			"if X, then\n    if Y, then\n        Z is 1\n    done\ndone",
+			`Statement(If(X, then Statement(If(Y, then Statement(VarAssignment(Z 1))))))`,
+		},
+	}
+	for _, test := range tests {
+		p := parser.NewParser(lexString("TestStringConditionals "+test.input, test.input))
+		ok, err, stmt := p.Stmt()
+		if !ok {
+			t.Errorf("failed `%v`, got %v", test.input, err)
+			continue
+		}
+		var stringVisitor ast.StringVisitor
+		stringVisitor.VisitStatement(&stringVisitor, stmt)
+		if parsed := stringVisitor.String(); parsed != test.parsed {
+			t.Errorf("`%v` parsed as %v, expected %v", test.input, parsed, test.parsed)
+		}
+	}
+}
+
+func TestParseConditionals(t *testing.T) {
+	tests := []struct {
+		input       string
+		indentation bool
+		succeed     bool
+	}{
+		{"if X, then\n    Y is 1\nelse, then\n    Y is 2\ndone", false, true},
+		{"if X, then\n    Y is 1\nelse if Z, then\n    Y is 2\ndone\nY is 3", false, true},
+		{"if X, then\n    Y is 1\nelse if Z, then\n    Y is 2\nelse, then\n    Y is 3\nY is 4", true, true},
+		{"if X, then\n    Y is 1\nY is 2", true, true},
+		{"if X, then\n    Y is 1", false, false},                         // never closed
+		{"if X\n    Y is 1\ndone", false, false},                         // no `then`
+		{"if, then\n    Y is 1\ndone", false, false},                     // no condition
+		{"if X, then\n    Y is 1\nelse\n    Y is 2\ndone", false, false}, // no `then` after `else`
+		{"if X, then\n    Y is 1\nelse, then\n    Y is 2\nelse, then\n    Y is 3\ndone", false, false},
+		{"if X, then\n    Y is 1\ndone\nelse, then\n    Y is 2\ndone", false, false},
+		{"if X, then\n    Y is 1\ndone", true, false}, // `done` in indentation mode
+	}
+	for _, test := range tests {
+		tokens := lexString("TestParseConditionals", test.input)
+		if test.indentation {
+			tokens = lexIndented("TestParseConditionals", test.input)
+		}
+		p := parser.NewParser(tokens)
+		p.Indentation = test.indentation
+		ok, err, _ := p.Program()
+		if !ok && test.succeed {
+			t.Errorf("failed `%q`, got %v", test.input, err)
+		} else if ok && !test.succeed {
+			t.Errorf("`%q` should not parse", test.input)
+		}
+	}
+}
+
+const conditionalDeclarations = `function Sign is function, taking number N, returning number, doing
+    if N < 0, then
+        return -1
+    else if N == 0, then
+        return 0
+    done
+    return 1
+done
+function Describe is function, taking number N, returning string, doing
+    variable Result is string "small"
+    if N > 100, then
+        Result is "huge"
+    else if N > 10, then
+        Result is "big"
+    else, then
+        if N < 0, then
+            Result is "negative"
+        done
+    done
+    return Result
+done
+`
+
+func TestEvalConditionals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`variable Result is number 1
+if Result > 0, then
+    Result is 2
+done`, "2"},
+		{`variable Result is number 1
+if Result < 0, then
+    Result is 2
+done`, "1"},
+		{`variable Result is number 1
+if false, then
+    Result is 2
+else, then
+    Result is 3
+done`, "3"},
+		{`variable Result is list of number containing (do Sign to -5), (do Sign to 0), and (do Sign to 7), done`, "[-1,0,1]"},
+		{`variable Result is list of string containing (do Describe to 500), (do Describe to 50), (do Describe to 5), and (do Describe to -5), done`, `["huge","big","small","negative"]`},
+		// each branch has a scope of its own, which may shadow an outer name
+		{`variable Result is number 1
+if true, then
+    variable Result is number 2
+    variable Inner is number 3
+done`, "1"},
+		{`variable Result is number 0
+variable Count is number 0
+if Count == 0 or Count / 0 > 1, then
+    Result is 1
+done`, "1"},
+		// a branch that is not taken is never run
+		{`variable Result is number 1
+if false, then
+    Result is 1 / 0
+done`, "1"},
+	}
+	for _, test := range tests {
+		program := conditionalDeclarations + test.input
+		if errs := typeCheck(t, "TestEvalConditionals "+test.input, program); len(errs) > 0 {
+			t.Errorf("`%v` failed to type check, got %v", test.input, errs)
+			continue
+		}
+		evaluatingVisitor := evalProgram(t, "TestEvalConditionals "+test.input, program)
+		if evaluatingVisitor == nil {
+			continue
+		}
+		if evaluatingVisitor.Err != nil {
+			t.Errorf("`%v` failed evaluating, got %v", test.input, evaluatingVisitor.Err)
+			continue
+		}
+		if result := evaluatingVisitor.Lookup("Result").String(); result != test.expected {
+			t.Errorf("`%v` is %v, expected %v", test.input, result, test.expected)
+		}
+	}
+}
+
+func TestConditionalErrors(t *testing.T) {
+	tests := []string{
+		`if 1, then
+    nothing
+done`,
+		`if "true", then
+    nothing
+done`,
+		`variable L is list of boolean
+if L, then
+    nothing
+done`,
+		`if false, then
+    nothing
+else if 0, then
+    nothing
+done`,
+		`if true, then
+    variable Inner is number 1
+done
+variable Result is number Inner`,
+	}
+	for _, input := range tests {
+		if errs := typeCheck(t, "TestConditionalErrors "+input, input); len(errs) == 0 {
+			t.Errorf("`%v` should not type check", input)
+		}
+		evaluatingVisitor := evalProgram(t, "TestConditionalErrors "+input, input)
+		if evaluatingVisitor != nil && evaluatingVisitor.Err == nil {
+			t.Errorf("`%v` should fail at runtime", input)
+		}
+	}
+}
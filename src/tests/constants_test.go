@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"nicer-syntax/evaluator"
+	"testing"
+)
+
+const constantDeclarations = `constant Four is number 4
+constant Values is list of number containing 1, 2, and 3, done
+constant Nested is list of list of number containing containing 1, done, and containing 2, done, done
+constant Names is map of string to number containing "bob" as 123, done
+variable Copy is list of number Values
+variable Mutable is list of number containing 1, and 2, done
+constant Frozen is list of number Mutable
+`
+
+func TestConstantsCannotChange(t *testing.T) {
+	tests := []struct {
+		input string
+		// whether the type checker can tell before the program runs
+		static bool
+	}{
+		{`Four is 5`, true},
+		{`0-th of Values is 5`, true},
+		{`end of Values is nothing`, true},
+		{`0-th of 1-th of Nested is 5`, true},
+		{`"pat"-th of Names is 345`, true},
+		{`0-th of Frozen is 5`, true},
+		{`0-th of Copy is 5`, false}, // shares the constant's list
+	}
+	for _, test := range tests {
+		errs := typeCheck(t, "TestConstantsCannotChange "+test.input, constantDeclarations+test.input)
+		if test.static && len(errs) == 0 {
+			t.Errorf("`%v` should not type check", test.input)
+		} else if !test.static && len(errs) > 0 {
+			t.Errorf("`%v` failed to type check, got %v", test.input, errs)
+		}
+		evaluatingVisitor := evalProgram(t, "TestConstantsCannotChange "+test.input, constantDeclarations+test.input)
+		if evaluatingVisitor != nil && evaluatingVisitor.Err == nil {
+			t.Errorf("`%v` should fail at runtime", test.input)
+		}
+	}
+}
+
+func TestConstantsAreCopies(t *testing.T) {
+	input := constantDeclarations + `0-th of Mutable is 5
+variable Inner is list of number 0-th of Nested
+`
+	if errs := typeCheck(t, "TestConstantsAreCopies", input); len(errs) > 0 {
+		t.Fatalf("failed to type check, got %v", errs)
+	}
+	evaluatingVisitor := evalProgram(t, "TestConstantsAreCopies", input)
+	if evaluatingVisitor == nil {
+		return
+	}
+	if evaluatingVisitor.Err != nil {
+		t.Fatalf("failed evaluating, got %v", evaluatingVisitor.Err)
+	}
+	if mutable := evaluatingVisitor.Lookup("Mutable").String(); mutable != "[5,2]" {
+		t.Errorf("Mutable is %v, expected [5,2]", mutable)
+	}
+	if frozen := evaluatingVisitor.Lookup("Frozen").String(); frozen != "[1,2]" {
+		t.Errorf("Frozen is %v, expected [1,2]", frozen)
+	}
+	// elements of a constant are constant all the way down
+	inner := evaluatingVisitor.Lookup("Inner")
+	if err := evaluator.SetIndex(inner, evaluator.NewNumber(0), evaluator.NewNumber(5)); err == nil {
+		t.Errorf("changed an element of a constant's element")
+	}
+	if err := evaluator.Append(inner, evaluator.NewNumber(5)); err == nil {
+		t.Errorf("appended to a constant's element")
+	}
+}
+
+func TestAppend(t *testing.T) {
+	list := evaluator.NewList("", nil)
+	if err := evaluator.Append(list, evaluator.NewNumber(1)); err != nil {
+		t.Fatalf("failed appending, got %v", err)
+	}
+	if err := evaluator.Append(list, evaluator.NewString("2")); err == nil {
+		t.Errorf("appended a string to %v", list.TypeName())
+	}
+	if err := evaluator.Append(list, evaluator.NewNumber(2)); err != nil {
+		t.Fatalf("failed appending, got %v", err)
+	}
+	if list.TypeName() != "list of number" || list.String() != "[1,2]" {
+		t.Errorf("list is %v %v", list.TypeName(), list)
+	}
+	if err := evaluator.Append(evaluator.Constant(list), evaluator.NewNumber(3)); err == nil {
+		t.Errorf("appended to a constant list")
+	}
+}
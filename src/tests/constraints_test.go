@@ -0,0 +1,211 @@
+package tests
+
+import (
+	"nicer-syntax/ast"
+	"nicer-syntax/parser"
+	"testing"
+)
+
+func TestStringConstraints(t *testing.T) {
+	tests := []struct {
+		input  string
+		parsed string
+	}{
+		{
+			"type Sorted is struct of E where E can do Compare is function, taking E, returning number containing nothing done",
+			`Statement(TypeDecl(Sorted struct of E where E can do Compare is function, taking E, returning number containing nothing done))`,
+		},
+		{
+			"type Table is struct of K, and V where K can do Hash is function, returning number, and V can do Show is function, returning string containing nothing done",
+			`Statement(TypeDecl(Table struct of K, and V where K can do Hash is function, returning number, and V can do Show is function, returning string containing nothing done))`,
+		},
+		{
+			"type P is struct of E where E can do Show is function, taking E, and E can do Reset is function containing nothing done",
+			`Statement(TypeDecl(P struct of E where E can do Show is function, taking E, and E can do Reset is function containing nothing done))`,
+		},
+		{
+			"function Least is function of T where T can do Compare is function, taking T, returning number, taking T A, and T B, returning T, doing\n    return A\ndone",
+			`Statement(FunctionDecl(Least FunctionLiteral(of T where T can do Compare is function, taking T, returning number, taking T A, and T B, returning T, doing Statement(Return(A)))))`,
+		},
+	}
+	for _, test := range tests {
+		p := parser.NewParser(lexString("TestStringConstraints "+test.input, test.input))
+		ok, err, stmt := p.Stmt()
+		if !ok {
+			t.Errorf("failed `%v`, got %v", test.input, err)
+			continue
+		}
+		var stringVisitor ast.StringVisitor
+		stringVisitor.VisitStatement(&stringVisitor, stmt)
+		if parsed := stringVisitor.String(); parsed != test.parsed {
+			t.Errorf("`%v` parsed as %v, expected %v", test.input, parsed, test.parsed)
+		}
+	}
+}
+
+func TestParseConstraints(t *testing.T) {
+	tests := []struct {
+		input       string
+		indentation bool
+		succeed     bool
+	}{
+		{"type P is struct of E where\n    E can do Show is function, returning string containing nothing done", false, true},
+		{"type P is struct of E where E can do A is function and E can do B is function containing nothing done", false, true},
+		{"type P is struct of E where F can do Show is function containing nothing done", false, false}, // not a type parameter
+		{"type P is struct of E where E can do Show containing nothing done", false, false},             // no type
+		{"type P is struct of E where E can Show is function containing nothing done", false, false},    // no `do`
+		{"type P is struct of E where E can do Show is number containing nothing done", false, false},   // not a function
+		{"type P is struct where E can do Show is function containing nothing done", false, false},      // no type parameters
+		{"function F is function of T where, doing\n    nothing\ndone", false, false},
+	}
+	for _, test := range tests {
+		tokens := lexString("TestParseConstraints", test.input)
+		if test.indentation {
+			tokens = lexIndented("TestParseConstraints", test.input)
+		}
+		p := parser.NewParser(tokens)
+		p.Indentation = test.indentation
+		ok, err, _ := p.Program()
+		if !ok && test.succeed {
+			t.Errorf("failed `%q`, got %v", test.input, err)
+		} else if ok && !test.succeed {
+			t.Errorf("`%q` should not parse", test.input)
+		}
+	}
+}
+
+const constraintDeclarations = `type Sorted is struct of E where E can do Compare is function, taking E, returning number containing
+    variable Items is list of E,
+and can do
+    function Add is function, taking E Item, returning Sorted of E, doing
+        variable Kept is list of E
+        variable Placed is boolean false
+        for E Existing from Items of this, loop
+            if not Placed and (do Compare of Item to Existing) < 0, then
+                do Append to Kept, and Item
+                Placed is true
+            done
+            do Append to Kept, and Existing
+        done
+        if not Placed, then
+            do Append to Kept, and Item
+        done
+        Items of this is Kept
+        return this
+    done
+done
+type Age is struct containing
+    constant Years is number,
+and can do
+    function Compare is function, taking Age Other, returning number, doing
+        return Years of this - Years of Other
+    done
+done
+type Name is struct containing
+    constant Text is string,
+and can do
+    function Compare is function, taking Name Other, returning number, doing
+        if Text of this < Text of Other, then
+            return -1
+        done
+        return 1
+    done
+done
+function Least is function of T where T can do Compare is function, taking T, returning number, taking list of T Items, returning T, doing
+    variable Found is T 0-th of Items
+    for T Item from Items, loop
+        if (do Compare of Item to Found) < 0, then
+            Found is Item
+        done
+    done
+    return Found
+done
+function AgeOf is function, taking number Years, returning Age, doing
+    variable Made is Age where Years is Years done
+    return Made
+done
+`
+
+func TestEvalConstraints(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`variable S is Sorted of Age where nothing done
+do Add of S to do AgeOf to 30
+do Add of S to do AgeOf to 10
+do Add of S to do AgeOf to 20
+variable Result is number Years of 0-th of Items of S`, "10"},
+		{`variable S is Sorted of Age where nothing done
+do Add of do Add of do Add of S to do AgeOf to 3 to do AgeOf to 1 to do AgeOf to 2
+variable Result is number Years of 2-th of Items of S`, "3"},
+		{`variable S is Sorted of Name where nothing done
+variable B is Name where Text is "b" done
+variable A is Name where Text is "a" done
+do Add of do Add of S to B to A
+variable Result is string Text of 0-th of Items of S`, "a"},
+		{`variable Ages is list of Age containing (do AgeOf to 5), (do AgeOf to 2), and (do AgeOf to 9), done
+variable Result is number Years of do Least to Ages`, "2"},
+	}
+	for _, test := range tests {
+		program := constraintDeclarations + test.input
+		if errs := typeCheck(t, "TestEvalConstraints "+test.input, program); len(errs) > 0 {
+			t.Errorf("`%v` failed to type check, got %v", test.input, errs)
+			continue
+		}
+		evaluatingVisitor := evalProgram(t, "TestEvalConstraints "+test.input, program)
+		if evaluatingVisitor == nil {
+			continue
+		}
+		if evaluatingVisitor.Err != nil {
+			t.Errorf("`%v` failed evaluating, got %v", test.input, evaluatingVisitor.Err)
+			continue
+		}
+		if result := evaluatingVisitor.Lookup("Result").String(); result != test.expected {
+			t.Errorf("`%v` is %v, expected %v", test.input, result, test.expected)
+		}
+	}
+}
+
+// constraints are checked where a generic struct or function is used, so
+// only the type checker catches these
+func TestConstraintErrors(t *testing.T) {
+	tests := []string{
+		`variable S is Sorted of number`,
+		`variable S is Sorted of list of Age`,
+		`type Plain is struct containing nothing done
+variable S is Sorted of Plain`,
+		`type Odd is struct containing nothing and can do
+    function Compare is function, taking number Other, returning number, doing
+        return 0
+    done
+done
+variable S is Sorted of Odd`,
+		`function F is function, taking Sorted of string S, doing
+    nothing
+done`,
+		`variable Result is number do Least to containing 1, and 2, done`,
+		`type Bad is struct of E containing
+    variable Items is list of E,
+and can do
+    function First is function, taking E Other, returning number, doing
+        return do Compare of Other to Other
+    done
+done`,
+		`type Wrapper is struct of E containing
+    variable Inner is Sorted of E,
+done`,
+		`type Wrapper is struct of E where E can do Compare is function, taking E, returning string containing
+    variable Inner is Sorted of E,
+done`,
+		`function Wrong is function of T where T can do Compare is function, taking T, returning number, taking T A, returning string, doing
+    return do Compare of A to A
+done`,
+	}
+	for _, input := range tests {
+		program := constraintDeclarations + input
+		if errs := typeCheck(t, "TestConstraintErrors "+input, program); len(errs) == 0 {
+			t.Errorf("`%v` should not type check", input)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package tests
+
+import (
+	"bytes"
+	"nicer-syntax/ast"
+	"nicer-syntax/evaluator"
+	"nicer-syntax/lexer"
+	"nicer-syntax/parser"
+	"testing"
+
+	"github.com/db47h/lex"
+)
+
+func lexString(name, input string) []lexer.TokItem {
+	byteReader := bytes.NewBuffer([]byte(input))
+	file := lex.NewFile(name, byteReader)
+	nicerLexer := lexer.NewLexer(file)
+	return nicerLexer.LexAll()
+}
+
+func TestParseExpressions(t *testing.T) {
+	tests := []TestCase{
+		{"1 + 2", true},
+		{"-1", true},
+		{"not true", true},
+		{"(1 + 2) * 3", true},
+		{"A + B * C > -D % E and not F - G / H ^ I + J == 0", true},
+		{"1 +", false},
+		{"(1 + 2", false},
+		{"* 2", false},
+	}
+	for _, expr := range tests {
+		p := parser.NewParser(lexString("TestParseExpressions "+expr.input, expr.input))
+		_, err, _ := p.Expression()
+		if err != nil && expr.shouldSucceed {
+			t.Errorf("failed `%v`, got %v", expr.input, err)
+		} else if err == nil && !expr.shouldSucceed {
+			t.Errorf("`%v` should not parse", expr.input)
+		}
+	}
+}
+
+func TestExpressionPrecedence(t *testing.T) {
+	tests := []struct {
+		input  string
+		parsed string
+	}{
+		{"1 - 2 - 3", "BinaryExpr(BinaryExpr(1 - 2) - 3)"},
+		{"1 + 2 * 3", "BinaryExpr(1 + BinaryExpr(2 * 3))"},
+		{"2 ^ 3 ^ 2", "BinaryExpr(BinaryExpr(2 ^ 3) ^ 2)"},
+		{"-2 ^ 2", "BinaryExpr(UnaryExpr(- 2) ^ 2)"},
+		{"not true and false", "BinaryExpr(UnaryExpr(not true) and false)"},
+		{"true or false and false", "BinaryExpr(BinaryExpr(true or false) and false)"},
+		// from docs/operators.md
+		{
+			"A + B * C > -D % E and not F - G / H ^ I + J == 0",
+			"BinaryExpr(BinaryExpr(BinaryExpr(A + BinaryExpr(B * C)) > BinaryExpr(UnaryExpr(- D) % E)) and " +
+				"UnaryExpr(not BinaryExpr(BinaryExpr(BinaryExpr(F - BinaryExpr(G / BinaryExpr(H ^ I))) + J) == 0)))",
+		},
+	}
+	for _, expr := range tests {
+		p := parser.NewParser(lexString("TestExpressionPrecedence "+expr.input, expr.input))
+		ok, err, node := p.Expression()
+		if !ok {
+			t.Errorf("failed `%v`, got %v", expr.input, err)
+			continue
+		}
+		var stringVisitor ast.StringVisitor
+		stringVisitor.Visit(node)
+		if stringVisitor.String() != expr.parsed {
+			t.Errorf("`%v` parsed as %v, expected %v", expr.input, stringVisitor, expr.parsed)
+		}
+	}
+}
+
+func TestEvalExpressions(t *testing.T) {
+	tests := []struct {
+		input  string
+		result *evaluator.NicerValue
+	}{
+		{"1 + 2 * 3", evaluator.NewNumber(7)},
+		{"(1 + 2) * 3", evaluator.NewNumber(9)},
+		{"10 - 4 - 3", evaluator.NewNumber(3)},
+		{"2 ^ 3", evaluator.NewNumber(8)},
+		{"-2 ^ 2", evaluator.NewNumber(4)},
+		{"7 % 4", evaluator.NewNumber(3)},
+		{"9 / 2", evaluator.NewNumber(4.5)},
+		{`"Hello, " + "World!"`, evaluator.NewString("Hello, World!")},
+		{"1 < 2", evaluator.NewBoolean(true)},
+		{"2 <= 1", evaluator.NewBoolean(false)},
+		{`"a" != "b"`, evaluator.NewBoolean(true)},
+		{"not 1 == 2", evaluator.NewBoolean(true)},
+		{"true and not false", evaluator.NewBoolean(true)},
+		{"false or false", evaluator.NewBoolean(false)},
+		// the right side is never evaluated
+		{"false and 1 / 0 == 1", evaluator.NewBoolean(false)},
+		{"true or 1 / 0 == 1", evaluator.NewBoolean(true)},
+	}
+	for _, expr := range tests {
+		p := parser.NewParser(lexString("TestEvalExpressions "+expr.input, expr.input))
+		ok, err, node := p.Expression()
+		if !ok {
+			t.Errorf("failed parsing `%v`, got %v", expr.input, err)
+			continue
+		}
+		evaluatingVisitor := ast.NewEvaluatingVisitor()
+		evaluatingVisitor.Visit(node)
+		if evaluatingVisitor.Err != nil {
+			t.Errorf("failed evaluating `%v`, got %v", expr.input, evaluatingVisitor.Err)
+			continue
+		}
+		if result := evaluatingVisitor.ValueStack.Pop(); !result.Equals(expr.result) {
+			t.Errorf("`%v` evaluated to %v, expected %v", expr.input, result.Value, expr.result.Value)
+		}
+	}
+}
+
+func TestEvalExpressionErrors(t *testing.T) {
+	tests := []string{
+		"1 / 0",
+		"1 % 0",
+		`1 + "one"`,
+		"-true",
+		"not 1",
+		"1 and true",
+		"false or 1",
+		`1 == "1"`,
+	}
+	for _, input := range tests {
+		p := parser.NewParser(lexString("TestEvalExpressionErrors "+input, input))
+		ok, err, node := p.Expression()
+		if !ok {
+			t.Errorf("failed parsing `%v`, got %v", input, err)
+			continue
+		}
+		evaluatingVisitor := ast.NewEvaluatingVisitor()
+		evaluatingVisitor.Visit(node)
+		if evaluatingVisitor.Err == nil {
+			t.Errorf("`%v` should fail at runtime", input)
+		}
+	}
+}
+
+func TestEvalAssignmentExpressions(t *testing.T) {
+	input := `variable Old is number 1
+variable Previous is number 2
+variable Current is number
+Current is Old + Previous`
+	p := parser.NewParser(lexString("TestEvalAssignmentExpressions", input))
+	ok, err, program := p.Program()
+	if !ok {
+		t.Fatalf("failed parsing, got %v", err)
+	}
+	evaluatingVisitor := ast.NewEvaluatingVisitor()
+	program.Accept(evaluatingVisitor)
+	if evaluatingVisitor.Err != nil {
+		t.Fatalf("failed evaluating, got %v", evaluatingVisitor.Err)
+	}
+	if current := evaluatingVisitor.Lookup("Current"); !current.Equals(evaluator.NewNumber(3)) {
+		t.Errorf("Current is %v, expected 3", current)
+	}
+}
+
+func TestParseComparisonChains(t *testing.T) {
+	tests := []TestCase{
+		{"0 < N < Q", true},
+		{"0 <= N < Q <= 100", true},
+		{"Q > N >= 0", true},
+		{"0 < N == M < Q", true},
+		{"A == B == C", true},
+		{"0 < N > Q", false},   // changes direction
+		{"0 <= N >= Q", false}, // changes direction
+		{"A != B != C", false}, // `!=` does not chain
+		{"0 < N != Q", false},
+	}
+	for _, chain := range tests {
+		p := parser.NewParser(lexString("TestParseComparisonChains "+chain.input, chain.input))
+		ok, err, node := p.Expression()
+		if err != nil && chain.shouldSucceed {
+			t.Errorf("failed `%v`, got %v", chain.input, err)
+		} else if err == nil && !chain.shouldSucceed {
+			t.Errorf("`%v` should not parse", chain.input)
+		} else if ok {
+			if _, isChain := node.(*ast.ComparisonChain); !isChain {
+				t.Errorf("`%v` did not parse as a comparison chain", chain.input)
+			}
+		}
+	}
+}
+
+func TestEvalComparisonChains(t *testing.T) {
+	tests := []struct {
+		input  string
+		result *evaluator.NicerValue
+	}{
+		{"0 < 1 < 2", evaluator.NewBoolean(true)},
+		{"0 < 2 < 1", evaluator.NewBoolean(false)},
+		{"0 <= 0 < 1 <= 1", evaluator.NewBoolean(true)},
+		{"3 > 2 >= 2 > 1", evaluator.NewBoolean(true)},
+		{"1 == 1 < 2", evaluator.NewBoolean(true)},
+		{`"a" < "b" < "c"`, evaluator.NewBoolean(true)},
+		{"0 < 2 - 1 < 2 and true", evaluator.NewBoolean(true)},
+		// stops at the first failing comparison
+		{"2 < 1 < 1 / 0", evaluator.NewBoolean(false)},
+	}
+	for _, expr := range tests {
+		p := parser.NewParser(lexString("TestEvalComparisonChains "+expr.input, expr.input))
+		ok, err, node := p.Expression()
+		if !ok {
+			t.Errorf("failed parsing `%v`, got %v", expr.input, err)
+			continue
+		}
+		evaluatingVisitor := ast.NewEvaluatingVisitor()
+		evaluatingVisitor.Visit(node)
+		if evaluatingVisitor.Err != nil {
+			t.Errorf("failed evaluating `%v`, got %v", expr.input, evaluatingVisitor.Err)
+			continue
+		}
+		if result := evaluatingVisitor.ValueStack.Pop(); !result.Equals(expr.result) {
+			t.Errorf("`%v` evaluated to %v, expected %v", expr.input, result.Value, expr.result.Value)
+		}
+	}
+}
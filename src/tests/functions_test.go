@@ -0,0 +1,466 @@
+package tests
+
+import (
+	"nicer-syntax/ast"
+	"nicer-syntax/parser"
+	"testing"
+)
+
+func TestStringFunctions(t *testing.T) {
+	tests := []struct {
+		input  string
+		parsed string
+	}{
+		{"function F is function doing\n    nothing\ndone", `FunctionDecl(F FunctionLiteral(doing nothing))`},
+		{
+			"function Double is function, taking number N, and returning number, doing\n    return N * 2\ndone",
+			`FunctionDecl(Double FunctionLiteral(taking number N, returning number, doing Statement(Return(BinaryExpr(N * 2)))))`,
+		},
+		{
+			"function F is function, taking constant number A, string B, and list of number C, does\n    return\ndone",
+			`FunctionDecl(F FunctionLiteral(taking constant number A, string B, and list of number C, doing Statement(Return())))`,
+		},
+		{
+			"function F is function, returning string, doing return \"hi\" done",
+			`FunctionDecl(F FunctionLiteral(returning string, doing Statement(Return("hi"))))`,
+		},
+		{
+			"function Keep is function, taking function, taking number, and returning boolean, Test, doing\n    nothing\ndone",
+			`FunctionDecl(Keep FunctionLiteral(taking function, taking number, returning boolean Test, doing nothing))`,
+		},
+	}
+	for _, test := range tests {
+		p := parser.NewParser(lexString("TestStringFunctions "+test.input, test.input))
+		ok, err, stmt := p.Stmt()
+		if !ok {
+			t.Errorf("failed `%v`, got %v", test.input, err)
+			continue
+		}
+		var stringVisitor ast.StringVisitor
+		stringVisitor.VisitDeclaration(&stringVisitor, stmt)
+		if parsed := stringVisitor.String(); parsed != test.parsed {
+			t.Errorf("`%v` parsed as %v, expected %v", test.input, parsed, test.parsed)
+		}
+	}
+}
+
+func TestParseFunctionErrors(t *testing.T) {
+	tests := []string{
+		"return 1",
+		"variable X is number 1\nreturn",
+		"function F is function, taking number, doing\ndone", // no parameter name
+		"function F is function doing\nX is 1",               // never closed
+		"function F is function, returning number\ndone",     // no `doing`
+		"function F is function, returning number doing return 1\ndone\nreturn 2",
+	}
+	for _, input := range tests {
+		p := parser.NewParser(lexString("TestParseFunctionErrors "+input, input))
+		if ok, _, _ := p.Program(); ok {
+			t.Errorf("`%v` should not parse", input)
+		}
+	}
+}
+
+// there are no conditionals yet, so recursion stops by short-circuiting `or`
+const functionDeclarations = `variable Calls is number 0
+function CountDown is function, taking number N, and returning boolean, doing
+    Calls is Calls + 1
+    return N <= 0 or do CountDown to (N - 1)
+done
+function Forever is function, taking number N, and returning number, doing
+    return do Forever to N
+done
+function Double is function, taking constant number N, returning number, doing
+    return N * 2
+done
+function First is function, taking list of number Values, returning number, doing
+    return start of Values
+    Calls is 1000
+done
+function Shadow is function, taking list of number Calls, returning number, doing
+    variable Inner is number 100
+    return Inner + end of Calls
+done
+function Append is function, taking number N, returning number, doing
+    return N
+done
+function Log is function, taking string Message, doing
+    Calls is Calls + 1
+done
+`
+
+func TestEvalFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		calls    string
+	}{
+		{`variable Result is number do Double to 21`, "42", "0"},
+		{`variable Result is number do Double to do Double to 2`, "8", "0"},
+		{`variable Result is number (do Double to 1) + do Double to 2`, "6", "0"},
+		{`variable Result is number do First to containing 5, and 6, done`, "5", "0"}, // stops at the first `return`
+		{`variable Result is number do First to from 3 to 1`, "3", "0"},
+		{`variable Result is number do Shadow to containing 1, and 2, done`, "102", "0"},
+		{`variable Result is boolean do CountDown to 5`, "true", "6"},
+		{`variable Result is number do Append to 1`, "1", "0"}, // hides the built-in
+		{`variable Result is string "a"
+Result is "b"`, "b", "0"},
+	}
+	for _, test := range tests {
+		program := functionDeclarations + test.input
+		if errs := typeCheck(t, "TestEvalFunctions "+test.input, program); len(errs) > 0 {
+			t.Errorf("`%v` failed to type check, got %v", test.input, errs)
+			continue
+		}
+		evaluatingVisitor := evalProgram(t, "TestEvalFunctions "+test.input, program)
+		if evaluatingVisitor == nil {
+			continue
+		}
+		if evaluatingVisitor.Err != nil {
+			t.Errorf("`%v` failed evaluating, got %v", test.input, evaluatingVisitor.Err)
+			continue
+		}
+		if result := evaluatingVisitor.Lookup("Result").String(); result != test.expected {
+			t.Errorf("`%v` is %v, expected %v", test.input, result, test.expected)
+		}
+		if calls := evaluatingVisitor.Lookup("Calls").String(); calls != test.calls {
+			t.Errorf("`%v` left Calls at %v, expected %v", test.input, calls, test.calls)
+		}
+	}
+}
+
+func TestFunctionErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		// whether the type checker can tell before the program runs
+		static bool
+	}{
+		{`variable Result is number do Double to "2"`, true},
+		{`variable Result is string do Double to 2`, true},
+		{`variable Result is number do Calls to 2`, true},
+		{`variable Result is number do Undeclared to 2`, true},
+		{`Double is Forever`, true},
+		{`variable Result is number do Forever to 1`, false},
+		{`function Add is function, taking number A, and number B, returning number, doing
+    return A + B
+done
+variable Result is number do Add to 1`, true},
+		{`function F is function, taking constant list of number L, returning number, doing
+    0-th of L is 1
+    return 0
+done
+variable Result is number do F to containing 1, done`, true},
+		{`function F is function, taking constant number N, returning number, doing
+    N is 1
+    return N
+done
+variable Result is number do F to 1`, true},
+		{`function F is function, returning number, doing
+    return "one"
+done
+variable Result is number do F to 1`, true},
+		{`function F is function, taking number N, doing
+    return N
+done
+variable Result is number do F to 1`, true},
+		{`function F is function, taking number N, returning number, doing
+    return
+done
+variable Result is number do F to 1`, true},
+		{`function F is function, taking number N, returning number, doing
+    variable Unused is number N
+done
+variable Result is number do F to 1`, false}, // never returns
+		{`function F is function, taking number N, returning number, doing
+    return Inner
+done
+variable Inner is number 1`, true}, // not declared yet where F is
+		{`function F is function, taking number N, returning number, doing
+    variable Inner is number N
+    return N
+done
+variable Result is number Inner`, true},
+	}
+	for _, test := range tests {
+		program := functionDeclarations + test.input
+		errs := typeCheck(t, "TestFunctionErrors "+test.input, program)
+		if test.static && len(errs) == 0 {
+			t.Errorf("`%v` should not type check", test.input)
+		} else if !test.static && len(errs) > 0 {
+			t.Errorf("`%v` failed to type check, got %v", test.input, errs)
+		}
+		evaluatingVisitor := evalProgram(t, "TestFunctionErrors "+test.input, program)
+		if evaluatingVisitor != nil && evaluatingVisitor.Err == nil {
+			t.Errorf("`%v` should fail at runtime", test.input)
+		}
+	}
+	// a call to a function that returns nothing has no value to use
+	if errs := typeCheck(t, "TestFunctionErrors Log", functionDeclarations+`variable Result is number do Log to "hi"`); len(errs) == 0 {
+		t.Errorf("used the value of a function that returns nothing")
+	}
+}
+
+func TestStringCalls(t *testing.T) {
+	tests := []struct {
+		input  string
+		parsed string
+	}{
+		{`do F`, `FunctionCall(F)`},
+		{`do F to nothing`, `FunctionCall(F)`},
+		{`do F to 1`, `FunctionCall(F 1)`},
+		{`do F to 1, and 2`, `FunctionCall(F 1, 2)`},
+		{`do F to 1 + 2, X, and "a"`, `FunctionCall(F BinaryExpr(1 + 2), X, "a")`},
+		{`do F to A and B, and C`, `FunctionCall(F BinaryExpr(A and B), C)`},
+		{`do F to do G to 1, and 2`, `FunctionCall(F FunctionCall(G 1, 2))`},
+		{`do F to do G to nothing, and do G + 1`, `FunctionCall(F FunctionCall(G), BinaryExpr(FunctionCall(G) + 1))`},
+		{`(do F to 1) + 2`, `BinaryExpr(GroupedExpr(FunctionCall(F 1)) + 2)`},
+		{`containing do F, and 2, done`, `ListLiteral(FunctionCall(F), 2)`},
+	}
+	for _, test := range tests {
+		p := parser.NewParser(lexString("TestStringCalls "+test.input, test.input))
+		ok, err, node := p.Expression()
+		if !ok {
+			t.Errorf("failed `%v`, got %v", test.input, err)
+			continue
+		}
+		var stringVisitor ast.StringVisitor
+		stringVisitor.Visit(node)
+		if parsed := stringVisitor.String(); parsed != test.parsed {
+			t.Errorf("`%v` parsed as %v, expected %v", test.input, parsed, test.parsed)
+		}
+	}
+}
+
+func TestParseCallErrors(t *testing.T) {
+	tests := []string{
+		`do F to 1, 2`, // no `and` before the last
+		`do F to`,
+		`do 1 to 2`,
+		`do F to 1, and`,
+	}
+	for _, input := range tests {
+		p := parser.NewParser(lexString("TestParseCallErrors "+input, input))
+		if ok, _, _ := p.Program(); ok {
+			t.Errorf("`%v` should not parse", input)
+		}
+	}
+}
+
+const callDeclarations = `variable Log is list of string
+function Add is function, taking number A, and number B, returning number, doing
+    return A + B
+done
+function Sum is function, taking number A, number B, and number C, returning number, doing
+    return A + B + C
+done
+function Answer is function, returning number, doing
+    return 42
+done
+function Record is function, taking string Message, doing
+    do Append to Log, and Message
+done
+`
+
+func TestEvalCalls(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`variable Result is number do Add to 1, and 2`, "3"},
+		{`variable Result is number do Sum to 1, 2, and 3`, "6"},
+		{`variable Result is number do Add to do Answer, and do Answer to nothing`, "84"},
+		{`variable Result is number do Add to 1, and do Add to 2, and 3`, "6"},
+		{`variable Result is number (do Add to 1, and 2) * (do Answer)`, "126"},
+		{`do Record to "a"
+do Record to "b"
+variable Result is string end of Log`, "b"},
+		{`variable Result is list of number containing 1, done
+do Append to Result, 2, and 3`, "[1,2,3]"},
+		{`variable Result is list of number
+do Append to Result, and do Answer`, "[42]"},
+	}
+	for _, test := range tests {
+		program := callDeclarations + test.input
+		if errs := typeCheck(t, "TestEvalCalls "+test.input, program); len(errs) > 0 {
+			t.Errorf("`%v` failed to type check, got %v", test.input, errs)
+			continue
+		}
+		evaluatingVisitor := evalProgram(t, "TestEvalCalls "+test.input, program)
+		if evaluatingVisitor == nil {
+			continue
+		}
+		if evaluatingVisitor.Err != nil {
+			t.Errorf("`%v` failed evaluating, got %v", test.input, evaluatingVisitor.Err)
+			continue
+		}
+		if result := evaluatingVisitor.Lookup("Result").String(); result != test.expected {
+			t.Errorf("`%v` is %v, expected %v", test.input, result, test.expected)
+		}
+	}
+}
+
+func TestCallErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		// whether the type checker can tell before the program runs
+		static bool
+	}{
+		{`variable Result is number do Add to 1`, true},
+		{`variable Result is number do Add to 1, 2, and 3`, true},
+		{`variable Result is number do Add to 1, and "2"`, true},
+		{`variable Result is number do Answer to 1`, true},
+		{`do Answer to nothing
+do Record`, true},
+		{`do Append to Log`, false},
+		{`do Append to Log, and 1`, false},
+		{`do Append to 1, and 1`, false},
+		{`constant Fixed is list of number containing 1, done
+do Append to Fixed, and 2`, false},
+	}
+	for _, test := range tests {
+		program := callDeclarations + test.input
+		errs := typeCheck(t, "TestCallErrors "+test.input, program)
+		if test.static && len(errs) == 0 {
+			t.Errorf("`%v` should not type check", test.input)
+		} else if !test.static && len(errs) > 0 {
+			t.Errorf("`%v` failed to type check, got %v", test.input, errs)
+		}
+		evaluatingVisitor := evalProgram(t, "TestCallErrors "+test.input, program)
+		if evaluatingVisitor != nil && evaluatingVisitor.Err == nil {
+			t.Errorf("`%v` should fail at runtime", test.input)
+		}
+	}
+	// built-in functions return nothing
+	if errs := typeCheck(t, "TestCallErrors Append", callDeclarations+`variable Result is number do Append to Log, and "a"`); len(errs) == 0 {
+		t.Errorf("used the value of a built-in function")
+	}
+}
+
+func TestStringFunctionValues(t *testing.T) {
+	tests := []struct {
+		input  string
+		parsed string
+	}{
+		{`function doing nothing done`, `FunctionLiteral(doing nothing)`},
+		{
+			`function, taking number N, returning number, doing return N + 1 done`,
+			`FunctionLiteral(taking number N, returning number, doing Statement(Return(BinaryExpr(N + 1))))`,
+		},
+		{
+			`do Twice to function, taking number N, returning number, doing return N done, and 1`,
+			`FunctionCall(Twice FunctionLiteral(taking number N, returning number, doing Statement(Return(N))), 1)`,
+		},
+	}
+	for _, test := range tests {
+		p := parser.NewParser(lexString("TestStringFunctionValues "+test.input, test.input))
+		ok, err, node := p.Expression()
+		if !ok {
+			t.Errorf("failed `%v`, got %v", test.input, err)
+			continue
+		}
+		var stringVisitor ast.StringVisitor
+		stringVisitor.Visit(node)
+		if parsed := stringVisitor.String(); parsed != test.parsed {
+			t.Errorf("`%v` parsed as %v, expected %v", test.input, parsed, test.parsed)
+		}
+	}
+}
+
+const closureDeclarations = `function Double is function, taking number N, returning number, doing
+    return N * 2
+done
+function Twice is function, taking function, taking number, and returning number, F, and number N, returning number, doing
+    return do F to do F to N
+done
+function MakeCounter is function, returning function, returning number, doing
+    variable Count is number 0
+    return function, returning number, doing
+        Count is Count + 1
+        return Count
+    done
+done
+function MakeAdder is function, taking number Amount, returning function, taking number, returning number, doing
+    return function, taking number N, returning number, doing return N + Amount done
+done
+`
+
+func TestEvalClosures(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`variable Result is number do Twice to Double, and 3`, "12"},
+		{`variable Result is number do Twice to function, taking number N, returning number, doing return N + 1 done, and 3`, "5"},
+		{`variable F is function, taking number, returning number Double
+variable Result is number do F to 4`, "8"},
+		{`variable AddTwo is function, taking number, returning number do MakeAdder to 2
+variable AddTen is function, taking number, returning number do MakeAdder to 10
+variable Result is number (do AddTwo to 1) * (do AddTen to 1)`, "33"},
+		{`variable Result is number do Twice to (do MakeAdder to 5), and 0`, "10"},
+		// each call to MakeCounter makes a new Count, which its function keeps
+		{`variable Counter is function, returning number do MakeCounter
+variable Other is function, returning number do MakeCounter
+do Counter
+do Counter
+do Other
+variable Result is number (do Counter) * 10 + (do Other)`, "32"},
+		// a function sees later changes to the names it captured
+		{`variable Base is number 1
+variable AddBase is function, taking number, returning number function, taking number N, returning number, doing return N + Base done
+Base is 100
+variable Result is number do AddBase to 1`, "101"},
+		{`variable F is function, taking number, returning number Double
+F is do MakeAdder to 1
+variable Result is number do F to 1`, "2"},
+		{`variable Result is boolean Double == Double`, "true"},
+	}
+	for _, test := range tests {
+		program := closureDeclarations + test.input
+		if errs := typeCheck(t, "TestEvalClosures "+test.input, program); len(errs) > 0 {
+			t.Errorf("`%v` failed to type check, got %v", test.input, errs)
+			continue
+		}
+		evaluatingVisitor := evalProgram(t, "TestEvalClosures "+test.input, program)
+		if evaluatingVisitor == nil {
+			continue
+		}
+		if evaluatingVisitor.Err != nil {
+			t.Errorf("`%v` failed evaluating, got %v", test.input, evaluatingVisitor.Err)
+			continue
+		}
+		if result := evaluatingVisitor.Lookup("Result").String(); result != test.expected {
+			t.Errorf("`%v` is %v, expected %v", test.input, result, test.expected)
+		}
+	}
+}
+
+func TestClosureErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		// whether the type checker can tell before the program runs
+		static bool
+	}{
+		{`variable Result is number do Twice to MakeCounter, and 1`, true},
+		{`variable Result is number do Twice to 1, and 1`, true},
+		{`variable F is function, returning number Double`, true},
+		{`variable N is number 1
+variable Result is number do N to 1`, true},
+		{`variable F is function, taking number, returning number
+variable Result is number do F to 1`, false}, // F holds nothing
+		{`variable F is function, taking number, returning number Double
+F is MakeCounter`, true},
+	}
+	for _, test := range tests {
+		program := closureDeclarations + test.input
+		errs := typeCheck(t, "TestClosureErrors "+test.input, program)
+		if test.static && len(errs) == 0 {
+			t.Errorf("`%v` should not type check", test.input)
+		} else if !test.static && len(errs) > 0 {
+			t.Errorf("`%v` failed to type check, got %v", test.input, errs)
+		}
+		evaluatingVisitor := evalProgram(t, "TestClosureErrors "+test.input, program)
+		if evaluatingVisitor != nil && evaluatingVisitor.Err == nil {
+			t.Errorf("`%v` should fail at runtime", test.input)
+		}
+	}
+}
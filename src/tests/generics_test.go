@@ -0,0 +1,225 @@
+package tests
+
+import (
+	"nicer-syntax/ast"
+	"nicer-syntax/parser"
+	"testing"
+)
+
+func TestStringGenerics(t *testing.T) {
+	tests := []struct {
+		input  string
+		parsed string
+	}{
+		{
+			"type Box is struct of T containing\n    variable Item is T,\ndone",
+			`Statement(TypeDecl(Box struct of T containing VarDecl(Item T nothing) done))`,
+		},
+		{
+			"type Tuple is struct of A, and B containing\n    variable First is A,\n    variable Second is B,\ndone",
+			`Statement(TypeDecl(Tuple struct of A, and B containing VarDecl(First A nothing), VarDecl(Second B nothing) done))`,
+		},
+		{"variable B is Box of number", `Statement(VarDecl(B Box of number nothing))`},
+		{"variable T is Tuple of number, and string", `Statement(VarDecl(T Tuple of number, and string nothing))`},
+		{"variable L is list of Box of string", `Statement(VarDecl(L list of Box of string nothing))`},
+		{
+			"function Identity is function of T, taking T X, returning T, doing\n    return X\ndone",
+			`Statement(FunctionDecl(Identity FunctionLiteral(of T, taking T X, returning T, doing Statement(Return(X)))))`,
+		},
+	}
+	for _, test := range tests {
+		p := parser.NewParser(lexString("TestStringGenerics "+test.input, test.input))
+		ok, err, stmt := p.Stmt()
+		if !ok {
+			t.Errorf("failed `%v`, got %v", test.input, err)
+			continue
+		}
+		var stringVisitor ast.StringVisitor
+		stringVisitor.VisitStatement(&stringVisitor, stmt)
+		if parsed := stringVisitor.String(); parsed != test.parsed {
+			t.Errorf("`%v` parsed as %v, expected %v", test.input, parsed, test.parsed)
+		}
+	}
+}
+
+func TestParseGenerics(t *testing.T) {
+	tests := []struct {
+		input       string
+		indentation bool
+		succeed     bool
+	}{
+		{"type P is struct of T containing\n    variable A is T,\ndone", false, true},
+		{"type P is struct of T, and U containing\n    variable A is T,\n    variable B is U,\ndone", true, true},
+		{"function F is function of T, taking T X, doing\n    nothing", true, true},
+		{"variable X is P of number where A is 1 done", false, true},
+		{"type P is struct of containing nothing done", false, false},           // no type parameters
+		{"type P is struct of list of T containing nothing done", false, false}, // not a name
+		{"type P is struct of T, and number containing nothing done", false, false},
+		{"function F is function of, doing\n    nothing\ndone", false, false},
+		{"variable F is function of T, taking T X, doing\n    nothing\ndone", false, false}, // anonymous
+	}
+	for _, test := range tests {
+		tokens := lexString("TestParseGenerics", test.input)
+		if test.indentation {
+			tokens = lexIndented("TestParseGenerics", test.input)
+		}
+		p := parser.NewParser(tokens)
+		p.Indentation = test.indentation
+		ok, err, _ := p.Program()
+		if !ok && test.succeed {
+			t.Errorf("failed `%q`, got %v", test.input, err)
+		} else if ok && !test.succeed {
+			t.Errorf("`%q` should not parse", test.input)
+		}
+	}
+}
+
+const genericDeclarations = `type Node is struct of E containing
+    variable Value is E,
+    variable Next is Node of E,
+done
+type Tuple is struct of A, and B containing
+    variable First is A,
+    variable Second is B,
+done
+type Stack is struct of E containing
+    variable Items is list of E,
+and can do
+    function Push is function, taking E Item, returning Stack of E, doing
+        do Append to Items of this, and Item
+        return this
+    done
+    function Top is function, returning E, doing
+        variable Found is E
+        for E Item from Items of this, loop
+            Found is Item
+        done
+        return Found
+    done
+done
+function Identity is function of T, taking T X, returning T, doing
+    return X
+done
+function Front is function of T, taking list of T Items, returning T, doing
+    return 0-th of Items
+done
+function Swap is function of A, and B, taking Tuple of A, and B Pair, returning Tuple of B, and A, doing
+    variable Swapped is Tuple of B, and A where First is Second of Pair, Second is First of Pair done
+    return Swapped
+done
+`
+
+func TestEvalGenerics(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`variable N is Node of number where nothing done
+Value of N is 1
+variable Result is number Value of N`, "1"},
+		{`variable N is Node of string where nothing done
+Next of N is Node of string where Value is "two" done
+variable Result is string Value of Next of N`, "two"},
+		{`variable N is Node of number where Value is 1 done
+variable Result is Node of number Next of N`, "nothing"},
+		{`variable T is Tuple of number, and string where First is 1, Second is "one" done
+variable Result is string Second of T`, "one"},
+		{`variable T is Tuple of number, and string where First is 1, Second is "one" done
+variable Result is Tuple of string, and number do Swap to T`, `{First:"one",Second:1}`},
+		{`variable S is Stack of string where nothing done
+do Push of S to "a"
+variable Result is string do Top of do Push of S to "b"`, "b"},
+		{`variable S is Stack of list of number where nothing done
+do Push of S to containing 1, and 2, done
+variable Result is number do Front to do Top of S`, "1"},
+		{`function Wrap is function of T, taking T X, returning Node of T, doing
+    return Node of T where Value is X done
+done
+variable Result is string Value of do Wrap to "w"`, "w"},
+		{`variable Result is number do Identity to 3`, "3"},
+		{`variable Result is string do Identity to "three"`, "three"},
+		{`variable Result is boolean do Front to containing true, and false, done`, "true"},
+		{`variable Nodes is list of Node of number
+variable N is Node of number where Value is 4 done
+do Append to Nodes, and N
+variable Result is number Value of 0-th of Nodes`, "4"},
+	}
+	for _, test := range tests {
+		program := genericDeclarations + test.input
+		if errs := typeCheck(t, "TestEvalGenerics "+test.input, program); len(errs) > 0 {
+			t.Errorf("`%v` failed to type check, got %v", test.input, errs)
+			continue
+		}
+		evaluatingVisitor := evalProgram(t, "TestEvalGenerics "+test.input, program)
+		if evaluatingVisitor == nil {
+			continue
+		}
+		if evaluatingVisitor.Err != nil {
+			t.Errorf("`%v` failed evaluating, got %v", test.input, evaluatingVisitor.Err)
+			continue
+		}
+		if result := evaluatingVisitor.Lookup("Result").String(); result != test.expected {
+			t.Errorf("`%v` is %v, expected %v", test.input, result, test.expected)
+		}
+	}
+}
+
+func TestGenericErrors(t *testing.T) {
+	tests := []string{
+		`variable N is Node of number where nothing done
+Value of N is "one"`,
+		`variable N is Node of number where Value is "one" done`,
+		`variable A is Node of number where nothing done
+variable B is Node of string
+Next of A is B`,
+		`variable N is Node of number
+variable Result is string Value of N`,
+		`variable S is Stack of number where nothing done
+do Push of S to "a"`,
+		`variable S is Stack of number where nothing done
+variable Result is string do Top of S`,
+		`variable Result is string do Identity to 3`,
+		`variable Result is number do Front to containing "a", done`,
+		`variable T is Tuple of number, and string where First is 1, Second is "one" done
+variable Result is Tuple of number, and string do Swap to T`,
+	}
+	for _, input := range tests {
+		program := genericDeclarations + input
+		if errs := typeCheck(t, "TestGenericErrors "+input, program); len(errs) == 0 {
+			t.Errorf("`%v` should not type check", input)
+		}
+		evaluatingVisitor := evalProgram(t, "TestGenericErrors "+input, program)
+		if evaluatingVisitor != nil && evaluatingVisitor.Err == nil {
+			t.Errorf("`%v` should fail at runtime", input)
+		}
+	}
+}
+
+// type arguments that do not match the declaration are only caught by the
+// type checker
+func TestGenericTypeErrors(t *testing.T) {
+	tests := []string{
+		`variable N is Node`,
+		`variable N is Node of number, and string`,
+		`variable T is Tuple of number`,
+		`type P is struct containing nothing done
+variable X is P of string`,
+		`function F is function, taking Node N, doing
+    nothing
+done`,
+		`type P is struct containing
+    variable Items is list of Stack,
+done`,
+		`function Make is function of T, returning T, doing
+    variable X is T
+    return X
+done
+variable Result is number do Make`,
+	}
+	for _, input := range tests {
+		program := genericDeclarations + input
+		if errs := typeCheck(t, "TestGenericTypeErrors "+input, program); len(errs) == 0 {
+			t.Errorf("`%v` should not type check", input)
+		}
+	}
+}
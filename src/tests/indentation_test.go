@@ -0,0 +1,171 @@
+package tests
+
+import (
+	"bytes"
+	"nicer-syntax/lexer"
+	"nicer-syntax/parser"
+	"testing"
+
+	"github.com/db47h/lex"
+)
+
+func lexIndented(name, input string) []lexer.TokItem {
+	byteReader := bytes.NewBuffer([]byte(input))
+	file := lex.NewFile(name, byteReader)
+	nicerLexer := lexer.NewLexer(file)
+	nicerLexer.Indentation = true
+	return nicerLexer.LexAll()
+}
+
+func tokenTypes(tokens []lexer.TokItem) []lex.Token {
+	types := make([]lex.Token, 0, len(tokens))
+	for _, token := range tokens {
+		types = append(types, token.TokType)
+	}
+	return types
+}
+
+func sameTokenTypes(a, b []lex.Token) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestLexIndentation(t *testing.T) {
+	const (
+		semi   = lexer.ItemSemicolon
+		indent = lexer.ItemIndent
+		dedent = lexer.ItemDedent
+		ident  = lexer.ItemIdent
+		is     = lexer.KW_Is
+		number = lexer.LT_Number
+	)
+	tests := []struct {
+		input  string
+		tokens []lex.Token
+	}{
+		{"A is 1\nB is 2", []lex.Token{ident, is, number, semi, ident, is, number, semi}},
+		{
+			"A is 1\n    B is 2\nC is 3",
+			[]lex.Token{ident, is, number, semi, indent, ident, is, number, semi, dedent, semi, ident, is, number, semi},
+		},
+		{ // blank lines, comment lines and trailing spaces do not count
+			"A is 1\n\n    # comment\n    B is 2   \n\n        \n    C is 3",
+			[]lex.Token{ident, is, number, semi, indent, ident, is, number, semi, ident, is, number, semi, dedent, semi},
+		},
+		{ // several levels closed at once, including at the end of the file
+			"A is 1\n\tB is 2\n\t\tC is 3\nD is 4\n\tE is 5",
+			[]lex.Token{ident, is, number, semi, indent, ident, is, number, semi, indent, ident, is, number, semi,
+				dedent, semi, dedent, semi, ident, is, number, semi, indent, ident, is, number, semi, dedent, semi},
+		},
+		{ // CRLF line endings
+			"A is 1\r\n  B is 2\r\n",
+			[]lex.Token{ident, is, number, semi, indent, ident, is, number, semi, dedent, semi},
+		},
+	}
+	for _, test := range tests {
+		tokens := tokenTypes(lexIndented("TestLexIndentation", test.input))
+		if !sameTokenTypes(tokens, test.tokens) {
+			t.Errorf("`%q` lexed as %v, expected %v", test.input, tokens, test.tokens)
+		}
+	}
+}
+
+func TestLexIndentationErrors(t *testing.T) {
+	tests := []string{
+		"A is 1\n    B is 2\n\tC is 3",       // spaces, then tabs
+		"A is 1\n \tB is 2",                  // both on one line
+		"A is 1\n        B is 2\n    C is 3", // dedents to a level never opened
+	}
+	for _, input := range tests {
+		tokens := lexIndented("TestLexIndentationErrors", input)
+		found := false
+		for _, token := range tokens {
+			found = found || token.TokType == lexer.ItemError
+		}
+		if !found {
+			t.Errorf("`%q` should not lex", input)
+		}
+	}
+}
+
+func TestIndentationPragma(t *testing.T) {
+	input := lexer.IndentationPragma + "\nA is 1\n    B is 2"
+	byteReader := bytes.NewBuffer([]byte(input))
+	nicerLexer := lexer.NewLexer(lex.NewFile("TestIndentationPragma", byteReader))
+	tokens := nicerLexer.LexAll()
+	if !nicerLexer.Indentation {
+		t.Fatalf("pragma did not turn on indentation mode")
+	}
+	found := false
+	for _, token := range tokens {
+		found = found || token.TokType == lexer.ItemIndent
+	}
+	if !found {
+		t.Errorf("no indent in %v", tokens)
+	}
+
+	// only on the first line
+	input = "A is 1\n" + lexer.IndentationPragma
+	byteReader = bytes.NewBuffer([]byte(input))
+	nicerLexer = lexer.NewLexer(lex.NewFile("TestIndentationPragma", byteReader))
+	nicerLexer.LexAll()
+	if nicerLexer.Indentation {
+		t.Errorf("pragma after the first line turned on indentation mode")
+	}
+}
+
+func TestParseBlocks(t *testing.T) {
+	tests := []struct {
+		input       string
+		indentation bool
+		statements  int
+		after       lex.Token // the token the block stops at
+		succeed     bool
+	}{
+		{"\n    A is 1\n    B is 2\ndone", false, 2, lexer.KW_Done, true},
+		{"\nA is 1\n\n\nB is 2\ndone", false, 2, lexer.KW_Done, true},
+		{" A is 1 done", false, 1, lexer.KW_Done, true},
+		{"\ndone", false, 0, lexer.KW_Done, true},
+		{"\nA is 1\nelse", false, 1, lexer.KW_Else, true},
+		{"\nA is 1\nB is 2", false, 0, 0, false}, // never closed
+		{"\n    A is 1\n    B is 2\nC is 3", true, 2, lexer.ItemSemicolon, true},
+		{"\n    A is 1\n\n    B is 2\n", true, 2, lexer.ItemSemicolon, true},
+		{"\n    A is 1\nelse", true, 1, lexer.KW_Else, true},
+		{"\n    A is 1\n        B is 2\n", true, 0, 0, false}, // indented without an opener
+		{"\nA is 1\n", true, 0, 0, false},                     // not indented
+	}
+	for _, test := range tests {
+		var tokens []lexer.TokItem
+		if test.indentation {
+			tokens = lexIndented("TestParseBlocks", test.input)
+		} else {
+			tokens = lexString("TestParseBlocks", test.input)
+		}
+		p := parser.NewParser(tokens)
+		p.Indentation = test.indentation
+		ok, err, stmts := p.Block("TestParseBlocks", lexer.KW_Else)
+		if !ok {
+			if test.succeed {
+				t.Errorf("failed `%q`, got %v", test.input, err)
+			}
+			continue
+		}
+		if !test.succeed {
+			t.Errorf("`%q` should not parse", test.input)
+			continue
+		}
+		if len(stmts) != test.statements {
+			t.Errorf("`%q` has %d statements, expected %d", test.input, len(stmts), test.statements)
+		}
+		if len(p.Tokens) == 0 || p.Tokens[0].TokType != test.after {
+			t.Errorf("`%q` stopped before %v, expected %v", test.input, p.Tokens, lexer.TokenString[test.after])
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package tests
+
+import (
+	"nicer-syntax/ast"
+	"nicer-syntax/evaluator"
+	"nicer-syntax/parser"
+	"strings"
+	"testing"
+)
+
+// from docs/types.md and sample/lists.nicer
+const indexingDeclarations = `constant Values is list of number containing 69, 420, and from 1 to 10, done
+constant Four is number 4
+constant Hello is string "Hello World!"
+constant Greek is string "αβγδ"
+constant Nested is list of list of number containing containing 1, and 2, done, and containing 3, done, done
+`
+
+// evaluate input on the line after indexingDeclarations
+func evalIndexing(t *testing.T, input string) (*evaluator.NicerValue, *evaluator.RuntimeError) {
+	tokens := lexString("TestIndexing "+input, indexingDeclarations+input)
+	line := strings.Count(indexingDeclarations, "\n") + 1
+	split := 0
+	for split < len(tokens) && tokens[split].TokSpan.Line < line {
+		split++
+	}
+	p := parser.NewParser(tokens[:split])
+	ok, err, program := p.Program()
+	if !ok {
+		t.Fatalf("failed parsing the declarations, got %v", err)
+	}
+	p = parser.NewParser(tokens[split:])
+	ok, err, expression := p.Expression()
+	if !ok {
+		t.Errorf("failed parsing `%v`, got %v", input, err)
+		return nil, nil
+	}
+	evaluatingVisitor := ast.NewEvaluatingVisitor()
+	program.Accept(evaluatingVisitor)
+	evaluatingVisitor.Visit(expression)
+	return evaluatingVisitor.ValueStack.Pop(), evaluatingVisitor.Err
+}
+
+func TestStringIndexing(t *testing.T) {
+	tests := []struct {
+		input  string
+		parsed string
+	}{
+		{"3-th from Values", "IndexExpr(3 from Values)"},
+		{"Key-th of Map", "IndexExpr(Key from Map)"},
+		{"start from Hello", "IndexExpr(start from Hello)"},
+		{"end of Hello", "IndexExpr(end from Hello)"},
+		{"1-th from 0-th from Nested", "IndexExpr(1 from IndexExpr(0 from Nested))"},
+		{"-1-th from Values", "IndexExpr(UnaryExpr(- 1) from Values)"},
+		{"-N-th from Values", "IndexExpr(UnaryExpr(- N) from Values)"},
+		{"- 1 + 1-th from Values", "BinaryExpr(UnaryExpr(- 1) + IndexExpr(1 from Values))"},
+		{"(N - 1)-th from Values + 1", "BinaryExpr(IndexExpr(GroupedExpr(BinaryExpr(N - 1)) from Values) + 1)"},
+		{"0-th to 5-th from Values", "SliceExpr(RangeLiteral(from 0 to 5) from Values)"},
+		{"6-th to end from Hello", "SliceExpr(RangeLiteral(from 6 to end) from Hello)"},
+		{"start to 2-th from Hello", "SliceExpr(RangeLiteral(from start to 2) from Hello)"},
+		{"every 2-th from 1-th to end from Values", "SliceExpr(RangeLiteral(every 2-th from 1 to end) from Values)"},
+		{"every 3-th from start to 6-th from Values", "SliceExpr(RangeLiteral(every 3-th from start to 6) from Values)"},
+		{"every Four-th from Values", "SliceExpr(RangeLiteral(every Four-th from start to end) from Values)"},
+		{"from start to end from Values", "SliceExpr(RangeLiteral(from start to end) from Values)"},
+	}
+	for _, test := range tests {
+		p := parser.NewParser(lexString("TestStringIndexing "+test.input, test.input))
+		ok, err, node := p.Expression()
+		if !ok {
+			t.Errorf("failed `%v`, got %v", test.input, err)
+			continue
+		}
+		var stringVisitor ast.StringVisitor
+		stringVisitor.Visit(node)
+		if stringVisitor.String() != test.parsed {
+			t.Errorf("`%v` parsed as %v, expected %v", test.input, stringVisitor, test.parsed)
+		}
+	}
+}
+
+func TestParseIndexingErrors(t *testing.T) {
+	tests := []string{
+		"3-th Values",            // no `from`
+		"3-th from",              // no collection
+		"end to 3-th from Hello", // slices go from a start
+		"0-th to 5 from Values",  // the end needs `-th` too
+		"start",
+	}
+	for _, input := range tests {
+		p := parser.NewParser(lexString("TestParseIndexingErrors "+input, input))
+		if ok, _, _ := p.Expression(); ok && len(p.Tokens) <= 1 {
+			t.Errorf("`%v` should not parse", input)
+		}
+	}
+}
+
+func TestEvalIndexing(t *testing.T) {
+	tests := []struct {
+		input  string
+		result string
+	}{
+		// sample/lists.nicer
+		{"Values", "[69,420,1,2,3,4,5,6,7,8,9,10]"},
+		{"3-th from Values", "2"},
+		{"0-th to 5-th from Values", "[69,420,1,2,3,4]"},
+		{"every 2-th from 1-th to end from Values", "[420,2,4,6,8,10]"},
+		{"every 3-th from start to 6-th from Values", "[69,2,5]"},
+		{"every Four-th from Values", "[69,3,7]"},
+		{"from start to end from Values", "[69,420,1,2,3,4,5,6,7,8,9,10]"},
+		// docs/types.md
+		{"start from Hello", "H"},
+		{"5-th from Hello", " "},
+		{"6-th to end from Hello", "World!"},
+		// by character, not byte
+		{"1-th from Greek", "β"},
+		{"end from Greek", "δ"},
+		{"1-th to end from Greek", "βγδ"},
+		{"3-th to 0-th from Greek", "δγβα"},
+		{"end from Values", "10"},
+		{"1-th from 0-th from Nested", "2"},
+		{"0-th from Nested", "[1,2]"},
+		{"2-th from (from 10 to 20)", "12"},
+		{"1-th to 2-th from (from 10 to 20)", "[11,12]"},
+		{"3-th to 1-th from Values", "[2,1,420]"},
+	}
+	for _, test := range tests {
+		result, err := evalIndexing(t, test.input)
+		if err != nil {
+			t.Errorf("failed evaluating `%v`, got %v", test.input, err)
+			continue
+		}
+		if result.String() != test.result {
+			t.Errorf("`%v` evaluated to %v, expected %v", test.input, result, test.result)
+		}
+	}
+}
+
+func TestEvalIndexingErrors(t *testing.T) {
+	tests := []string{
+		"12-th from Values",
+		"(-1)-th from Values",
+		"-1-th from Values",
+		"-Four-th from Values",
+		"1.5-th from Values",
+		"4-th from Greek",
+		"0-th to 12-th from Values",
+		"every 2-th from 10-th to 20-th from Hello",
+		// checked before anything is allocated for the slice
+		"0-th to 9007199254740990-th from Values",
+		"900000000000-th to 0-th from Hello",
+		`"a"-th from Values`,
+		"0-th from Four",
+		"start from (containing nothing done)",
+		"end from (containing nothing done)",
+		"0-th from Undeclared",
+	}
+	for _, input := range tests {
+		_, err := evalIndexing(t, input)
+		if err == nil {
+			t.Errorf("`%v` should fail at runtime", input)
+			continue
+		}
+		// positioned on the last line, where the expression is
+		if !strings.HasPrefix(err.Error(), "TestIndexing "+input+":6:") {
+			t.Errorf("`%v` failed without a position on line 6: %v", input, err)
+		}
+	}
+}
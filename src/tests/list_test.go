@@ -3,6 +3,8 @@ package tests
 import (
 	"bytes"
 	"fmt"
+	"nicer-syntax/ast"
+	"nicer-syntax/evaluator"
 	"nicer-syntax/lexer"
 	"nicer-syntax/parser"
 	"testing"
@@ -43,7 +45,7 @@ func TestParseListLiteral(t *testing.T) {
 		p := parser.NewParser(tokens)
 		var err *parser.ParseError
 		output := captureOutput(func() {
-			_, err = p.ListLiteral()
+			_, err, _ = p.ListLiteral()
 		})
 		if err != nil && list.shouldSucceed {
 			fmt.Println(output)
@@ -51,3 +53,111 @@ func TestParseListLiteral(t *testing.T) {
 		}
 	}
 }
+
+func TestStringListLiteral(t *testing.T) {
+	tests := []struct {
+		input  string
+		parsed string
+	}{
+		{"containing nothing done", "ListLiteral()"},
+		{"containing 1 + 2, done", "ListLiteral(BinaryExpr(1 + 2))"},
+		{"containing 69, 420, and from 1 to 10, done", "ListLiteral(69, 420, RangeLiteral(from 1 to 10))"},
+		{"containing every 2-th from start to end, done", "ListLiteral(RangeLiteral(every 2-th from start to end))"},
+		{"containing containing A, done, and containing nothing done, done", "ListLiteral(ListLiteral(A), ListLiteral())"},
+	}
+	for _, list := range tests {
+		p := parser.NewParser(lexString("TestStringListLiteral "+list.input, list.input))
+		ok, err, node := p.Expression()
+		if !ok {
+			t.Errorf("failed `%v`, got %v", list.input, err)
+			continue
+		}
+		var stringVisitor ast.StringVisitor
+		stringVisitor.Visit(node)
+		if stringVisitor.String() != list.parsed {
+			t.Errorf("`%v` parsed as %v, expected %v", list.input, stringVisitor, list.parsed)
+		}
+	}
+}
+
+func TestEvalListLiteral(t *testing.T) {
+	numbers := func(ns ...float64) *evaluator.NicerValue {
+		elements := make([]*evaluator.NicerValue, 0, len(ns))
+		for _, n := range ns {
+			elements = append(elements, evaluator.NewNumber(n))
+		}
+		return evaluator.NewList(evaluator.NT_number, elements)
+	}
+	tests := []struct {
+		input  string
+		result *evaluator.NicerValue
+	}{
+		{"containing 69, 420, and from 1 to 10, done", numbers(69, 420, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10)},
+		{"containing every 3-th from 0 to 10, done", numbers(0, 3, 6, 9)},
+		{"containing 1 + 1, and 2 * 2, done", numbers(2, 4)},
+		{"containing nothing done", evaluator.NewList("", nil)},
+		{`containing "a", done`, evaluator.NewList(evaluator.NT_string, []*evaluator.NicerValue{evaluator.NewString("a")})},
+		{
+			"containing containing 1, done, and containing 2, done, done",
+			evaluator.NewList(evaluator.ListOf(evaluator.NT_number), []*evaluator.NicerValue{numbers(1), numbers(2)}),
+		},
+	}
+	for _, list := range tests {
+		p := parser.NewParser(lexString("TestEvalListLiteral "+list.input, list.input))
+		ok, err, node := p.Expression()
+		if !ok {
+			t.Errorf("failed parsing `%v`, got %v", list.input, err)
+			continue
+		}
+		evaluatingVisitor := ast.NewEvaluatingVisitor()
+		evaluatingVisitor.Visit(node)
+		if evaluatingVisitor.Err != nil {
+			t.Errorf("failed evaluating `%v`, got %v", list.input, evaluatingVisitor.Err)
+			continue
+		}
+		if result := evaluatingVisitor.ValueStack.Pop(); !result.Equals(list.result) {
+			t.Errorf("`%v` evaluated to %v %v, expected %v %v", list.input, result.TypeName(), result, list.result.TypeName(), list.result)
+		}
+	}
+}
+
+func TestEvalListLiteralErrors(t *testing.T) {
+	tests := []string{
+		`containing 1, and "one", done`,
+		"containing 1, and true, done",
+		"containing from 1 to end, done", // nothing to end at
+		"containing every 0-th from 1 to 10, done",
+		"containing Undeclared, done",
+	}
+	for _, input := range tests {
+		p := parser.NewParser(lexString("TestEvalListLiteralErrors "+input, input))
+		ok, err, node := p.Expression()
+		if !ok {
+			t.Errorf("failed parsing `%v`, got %v", input, err)
+			continue
+		}
+		evaluatingVisitor := ast.NewEvaluatingVisitor()
+		evaluatingVisitor.Visit(node)
+		if evaluatingVisitor.Err == nil {
+			t.Errorf("`%v` should fail at runtime", input)
+		}
+	}
+}
+
+func TestEvalListDeclaration(t *testing.T) {
+	input := "constant Values is list of number containing 69, 420, and from 1 to 10, done"
+	p := parser.NewParser(lexString("TestEvalListDeclaration", input))
+	ok, err, program := p.Program()
+	if !ok {
+		t.Fatalf("failed parsing, got %v", err)
+	}
+	evaluatingVisitor := ast.NewEvaluatingVisitor()
+	program.Accept(evaluatingVisitor)
+	if evaluatingVisitor.Err != nil {
+		t.Fatalf("failed evaluating, got %v", evaluatingVisitor.Err)
+	}
+	values := evaluatingVisitor.Lookup("Values")
+	if values.TypeName() != "list of number" || values.String() != "[69,420,1,2,3,4,5,6,7,8,9,10]" {
+		t.Errorf("Values is %v %v", values.TypeName(), values)
+	}
+}
@@ -0,0 +1,398 @@
+package tests
+
+import (
+	"nicer-syntax/ast"
+	"nicer-syntax/parser"
+	"testing"
+)
+
+func TestStringForLoops(t *testing.T) {
+	tests := []struct {
+		input  string
+		parsed string
+	}{
+		{"for number I from 0 to 5, loop\n    X is I\ndone", `Statement(For(number I from RangeLiteral(from 0 to 5), loop Statement(VarAssignment(X I))))`},
+		{"for number I from 0 to 5 loop X is I done", `Statement(For(number I from RangeLiteral(from 0 to 5), loop Statement(VarAssignment(X I))))`},
+		{"for string S from Names, loop\n    nothing\ndone", `Statement(For(string S from Names, loop nothing))`},
+		{"for string S from start to end from Names, loop\n    nothing\ndone", `Statement(For(string S from Names, loop nothing))`},
+		{"for string S from 1-th to end from Names, loop\n    nothing\ndone", `Statement(For(string S from SliceExpr(RangeLiteral(from 1 to end) from Names), loop nothing))`},
+		{"for number I every 2-th from start to end of Names, loop\n    nothing\ndone", `Statement(For(number I from RangeLiteral(every 2-th from start to end of Names), loop nothing))`},
+		{"for constant number I from 1 to 2, loop\n    nothing\ndone", `Statement(For(constant number I from RangeLiteral(from 1 to 2), loop nothing))`},
+		{
+			"for function, returning number, F from Functions, loop\n    nothing\ndone",
+			`Statement(For(function, returning number F from Functions, loop nothing))`,
+		},
+	}
+	for _, test := range tests {
+		p := parser.NewParser(lexString("TestStringForLoops "+test.input, test.input))
+		ok, err, stmt := p.Stmt()
+		if !ok {
+			t.Errorf("failed `%v`, got %v", test.input, err)
+			continue
+		}
+		var stringVisitor ast.StringVisitor
+		stringVisitor.VisitStatement(&stringVisitor, stmt)
+		if parsed := stringVisitor.String(); parsed != test.parsed {
+			t.Errorf("`%v` parsed as %v, expected %v", test.input, parsed, test.parsed)
+		}
+	}
+}
+
+func TestParseForLoops(t *testing.T) {
+	tests := []struct {
+		input       string
+		indentation bool
+		succeed     bool
+	}{
+		{"for number I from 0 to 5, loop\n    X is I\ndone\nX is 1", false, true},
+		{"for number I from 0 to 5, loop\n    X is I\nX is 1", true, true},
+		{"for number I from 0 to 5, loop\n    X is I", false, false}, // never closed
+		{"for number I from 0 to 5\n    X is I\ndone", false, false}, // no `loop`
+		{"for I from 0 to 5, loop\n    X is I\ndone", false, false},  // no type
+		{"for number from 0 to 5, loop\n    X is I\ndone", false, false},
+		{"for number I Values, loop\n    X is I\ndone", false, false}, // no `from`
+		{"for number I from, loop\n    X is I\ndone", false, false},
+	}
+	for _, test := range tests {
+		tokens := lexString("TestParseForLoops", test.input)
+		if test.indentation {
+			tokens = lexIndented("TestParseForLoops", test.input)
+		}
+		p := parser.NewParser(tokens)
+		p.Indentation = test.indentation
+		ok, err, _ := p.Program()
+		if !ok && test.succeed {
+			t.Errorf("failed `%q`, got %v", test.input, err)
+		} else if ok && !test.succeed {
+			t.Errorf("`%q` should not parse", test.input)
+		}
+	}
+}
+
+const loopDeclarations = `variable Numbers is list of number containing 3, 1, and 4, done
+variable Ages is map of string to number containing "bob" as 30, and "pat" as 40, done
+function Sum is function, taking list of number Values, returning number, doing
+    variable Total is number 0
+    for number V from Values, loop
+        Total is Total + V
+    done
+    return Total
+done
+function IndexOf is function, taking list of number Values, and number Wanted, returning number, doing
+    for number I from start to end of Values, loop
+        if I-th of Values == Wanted, then
+            return I
+        done
+    done
+    return -1
+done
+function FirstOver is function, taking number Limit, returning number, doing
+    for number I from 0 to 1000000000000, loop
+        if I > Limit, then
+            return I
+        done
+    done
+    return -1
+done
+`
+
+func TestEvalForLoops(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`variable Result is number 0
+for number I from 1 to 4, loop
+    Result is Result * 10 + I
+done`, "1234"},
+		{`variable Result is number 0
+for number I from 4 to 1, loop
+    Result is Result * 10 + I
+done`, "4321"},
+		{`variable Result is number 0
+for number I every 3-th from 0 to 10, loop
+    Result is Result + I
+done`, "18"},
+		{`variable Result is number do Sum to Numbers`, "8"},
+		{`variable Result is number do Sum to from 1 to 100`, "5050"},
+		{`variable Result is number do IndexOf to Numbers, and 4`, "2"},
+		{`variable Result is number do IndexOf to Numbers, and 5`, "-1"},
+		{`variable Result is list of number
+for number N from 1-th to end from Numbers, loop
+    do Append to Result, and N * 2
+done`, "[2,8]"},
+		{`variable Result is list of string
+for string C from "héllo", loop
+    do Append to Result, and C
+done`, `["h","é","l","l","o"]`},
+		{`variable Result is list of string
+for string Name from Ages, loop
+    do Append to Result, and Name
+done`, `["bob","pat"]`},
+		{`variable Result is number 0
+for string Name from Ages, loop
+    Result is Result + Name-th of Ages
+done`, "70"},
+		{`variable Result is number 0
+variable Empty is list of number
+for number N from Empty, loop
+    Result is 1
+done`, "0"},
+		// a huge range is never expanded, and `return` leaves the loop
+		{`variable Result is number do FirstOver to 3`, "4"},
+		// the collection is looped over as it was when the loop started
+		{`variable Result is list of number containing 1, and 2, done
+for number N from Result, loop
+    do Append to Result, and N + 10
+done`, "[1,2,11,12]"},
+		{`variable Result is list of number containing 1, and 2, done
+variable Seen is list of number
+for number N from Result, loop
+    1-th of Result is 5
+    do Append to Seen, and N
+done
+Result is Seen`, "[1,2]"},
+		{`variable Result is map of string to number containing "a" as 1, done
+for string Key from Result, loop
+    variable NewKey is string Key + "!"
+    NewKey-th of Result is 2
+done`, `{"a":1,"a!":2}`},
+		// the variable is new every time round, and can be changed
+		{`variable Result is number 0
+for number I from 1 to 3, loop
+    I is I * 100
+    Result is Result + I
+done`, "600"},
+		{`variable Functions is list of function, returning number
+for number I from 1 to 3, loop
+    do Append to Functions, and function, returning number, doing return I done
+done
+variable Result is number 0
+for function, returning number, F from Functions, loop
+    Result is Result * 10 + do F
+done`, "123"},
+		{`variable Result is number 5
+for number Result from 1 to 2, loop
+    nothing
+done`, "5"},
+	}
+	for _, test := range tests {
+		program := loopDeclarations + test.input
+		if errs := typeCheck(t, "TestEvalForLoops "+test.input, program); len(errs) > 0 {
+			t.Errorf("`%v` failed to type check, got %v", test.input, errs)
+			continue
+		}
+		evaluatingVisitor := evalProgram(t, "TestEvalForLoops "+test.input, program)
+		if evaluatingVisitor == nil {
+			continue
+		}
+		if evaluatingVisitor.Err != nil {
+			t.Errorf("`%v` failed evaluating, got %v", test.input, evaluatingVisitor.Err)
+			continue
+		}
+		if result := evaluatingVisitor.Lookup("Result").String(); result != test.expected {
+			t.Errorf("`%v` is %v, expected %v", test.input, result, test.expected)
+		}
+	}
+}
+
+func TestForLoopErrors(t *testing.T) {
+	tests := []string{
+		`for number I from 5, loop
+    nothing
+done`,
+		`for string S from Numbers, loop
+    nothing
+done`,
+		`for number N from Ages, loop
+    nothing
+done`,
+		`for number N from "abc", loop
+    nothing
+done`,
+		`for constant number I from 1 to 2, loop
+    I is 3
+done`,
+		`for number I from 1 to 2, loop
+    nothing
+done
+variable Result is number I`,
+		`for number I from 1 to 2, loop
+    variable I is number 3
+done`,
+	}
+	for _, input := range tests {
+		program := loopDeclarations + input
+		if errs := typeCheck(t, "TestForLoopErrors "+input, program); len(errs) == 0 {
+			t.Errorf("`%v` should not type check", input)
+		}
+		evaluatingVisitor := evalProgram(t, "TestForLoopErrors "+input, program)
+		if evaluatingVisitor != nil && evaluatingVisitor.Err == nil {
+			t.Errorf("`%v` should fail at runtime", input)
+		}
+	}
+}
+
+func TestStringWhileLoops(t *testing.T) {
+	tests := []struct {
+		input  string
+		parsed string
+	}{
+		{"while X < 5, loop\n    X is X + 1\ndone", `Statement(While(BinaryExpr(X < 5), loop Statement(VarAssignment(X BinaryExpr(X + 1)))))`},
+		{"while true loop stop done", `Statement(While(true, loop Statement(Stop)))`},
+		{
+			"while true, loop\n    if X, then\n        skip\n    else, then\n        stop\n    done\ndone",
+			`Statement(While(true, loop Statement(If(X, then Statement(Skip), else Statement(Stop)))))`,
+		},
+		{"for number I from 1 to 2, loop\n    skip\ndone", `Statement(For(number I from RangeLiteral(from 1 to 2), loop Statement(Skip)))`},
+	}
+	for _, test := range tests {
+		p := parser.NewParser(lexString("TestStringWhileLoops "+test.input, test.input))
+		ok, err, stmt := p.Stmt()
+		if !ok {
+			t.Errorf("failed `%v`, got %v", test.input, err)
+			continue
+		}
+		var stringVisitor ast.StringVisitor
+		stringVisitor.VisitStatement(&stringVisitor, stmt)
+		if parsed := stringVisitor.String(); parsed != test.parsed {
+			t.Errorf("`%v` parsed as %v, expected %v", test.input, parsed, test.parsed)
+		}
+	}
+}
+
+func TestParseLoopControl(t *testing.T) {
+	tests := []TestCase{
+		{"while true, loop\n    stop\ndone", true},
+		{"while true, loop\n    if X, then\n        skip\n    done\ndone", true},
+		{"for number I from 1 to 2, loop\n    while true, loop\n        stop\n    done\n    skip\ndone", true},
+		{"while true, loop\n    function F is function doing\n        while true, loop\n            stop\n        done\n    done\ndone", true},
+		{"stop", false},
+		{"skip", false},
+		{"if true, then\n    stop\ndone", false},
+		{"while true, loop\n    nothing\ndone\nskip", false},
+		// a function cannot stop the loop it is written in
+		{"while true, loop\n    function F is function doing\n        stop\n    done\ndone", false},
+		{"while true\n    stop\ndone", false}, // no `loop`
+		{"while, loop\n    stop\ndone", false},
+	}
+	for _, test := range tests {
+		p := parser.NewParser(lexString("TestParseLoopControl "+test.input, test.input))
+		ok, err, _ := p.Program()
+		if !ok && test.shouldSucceed {
+			t.Errorf("failed `%q`, got %v", test.input, err)
+		} else if ok && !test.shouldSucceed {
+			t.Errorf("`%q` should not parse", test.input)
+		}
+	}
+}
+
+func TestEvalWhileLoops(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`variable Result is number 1
+while Result < 100, loop
+    Result is Result * 2
+done`, "128"},
+		{`variable Result is number 1
+while false, loop
+    Result is 2
+done`, "1"},
+		{`variable Result is number 0
+while true, loop
+    Result is Result + 1
+    if Result == 5, then
+        stop
+    done
+done`, "5"},
+		{`variable Result is list of number
+variable I is number 0
+while I < 6, loop
+    I is I + 1
+    if I % 2 == 0, then
+        skip
+    done
+    do Append to Result, and I
+done`, "[1,3,5]"},
+		{`variable Result is list of number
+for number I from 1 to 10, loop
+    if I % 3 == 0, then
+        skip
+    else if I > 7, then
+        stop
+    done
+    do Append to Result, and I
+done`, "[1,2,4,5,7]"},
+		// `stop` leaves only the innermost loop
+		{`variable Result is number 0
+for number I from 1 to 3, loop
+    while true, loop
+        Result is Result + 1
+        stop
+    done
+    Result is Result + 10
+done`, "33"},
+		{`function FirstDoubling is function, taking number N, returning number, doing
+    variable Power is number 1
+    while true, loop
+        if Power > N, then
+            return Power
+        done
+        Power is Power * 2
+    done
+done
+variable Result is number do FirstDoubling to 20`, "32"},
+		// names declared in the body are new every time round
+		{`variable Result is number 0
+while Result < 3, loop
+    variable Step is number 1
+    Result is Result + Step
+    Step is 100
+done`, "3"},
+	}
+	for _, test := range tests {
+		if errs := typeCheck(t, "TestEvalWhileLoops "+test.input, test.input); len(errs) > 0 {
+			t.Errorf("`%v` failed to type check, got %v", test.input, errs)
+			continue
+		}
+		evaluatingVisitor := evalProgram(t, "TestEvalWhileLoops "+test.input, test.input)
+		if evaluatingVisitor == nil {
+			continue
+		}
+		if evaluatingVisitor.Err != nil {
+			t.Errorf("`%v` failed evaluating, got %v", test.input, evaluatingVisitor.Err)
+			continue
+		}
+		if result := evaluatingVisitor.Lookup("Result").String(); result != test.expected {
+			t.Errorf("`%v` is %v, expected %v", test.input, result, test.expected)
+		}
+	}
+}
+
+func TestWhileLoopErrors(t *testing.T) {
+	tests := []string{
+		`while 1, loop
+    stop
+done`,
+		`variable X is string
+while X, loop
+    stop
+done`,
+		`while true, loop
+    variable Inner is number 1
+    stop
+done
+variable Result is number Inner`,
+	}
+	for _, input := range tests {
+		if errs := typeCheck(t, "TestWhileLoopErrors "+input, input); len(errs) == 0 {
+			t.Errorf("`%v` should not type check", input)
+		}
+		evaluatingVisitor := evalProgram(t, "TestWhileLoopErrors "+input, input)
+		if evaluatingVisitor != nil && evaluatingVisitor.Err == nil {
+			t.Errorf("`%v` should fail at runtime", input)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package tests
+
+import (
+	"nicer-syntax/ast"
+	"nicer-syntax/evaluator"
+	"nicer-syntax/parser"
+	"testing"
+)
+
+func evalProgram(t *testing.T, name, input string) *ast.EvaluatingVisitor {
+	p := parser.NewParser(lexString(name, input))
+	ok, err, program := p.Program()
+	if !ok {
+		t.Errorf("failed parsing `%v`, got %v", input, err)
+		return nil
+	}
+	evaluatingVisitor := ast.NewEvaluatingVisitor()
+	program.Accept(evaluatingVisitor)
+	return evaluatingVisitor
+}
+
+func TestStringMapLiteral(t *testing.T) {
+	tests := []struct {
+		input  string
+		parsed string
+	}{
+		{`containing 123 as "bob", done`, `MapLiteral(123 as "bob")`},
+		{`containing 123 as "bob", 345 as "pat", and 420 as "dog", done`, `MapLiteral(123 as "bob", 345 as "pat", 420 as "dog")`},
+		{`containing "a" + "b" as 1 + 2, and (X) as Y, done`, `MapLiteral(BinaryExpr("a" + "b") as BinaryExpr(1 + 2), GroupedExpr(X) as Y)`},
+		{`containing containing 1, done as "one", done`, `MapLiteral(ListLiteral(1) as "one")`},
+		{`containing (containing 1 as 2, done), done`, `ListLiteral(GroupedExpr(MapLiteral(1 as 2)))`},
+	}
+	for _, test := range tests {
+		p := parser.NewParser(lexString("TestStringMapLiteral "+test.input, test.input))
+		ok, err, node := p.Expression()
+		if !ok {
+			t.Errorf("failed `%v`, got %v", test.input, err)
+			continue
+		}
+		var stringVisitor ast.StringVisitor
+		stringVisitor.Visit(node)
+		if stringVisitor.String() != test.parsed {
+			t.Errorf("`%v` parsed as %v, expected %v", test.input, stringVisitor, test.parsed)
+		}
+	}
+}
+
+func TestParseMapLiteral(t *testing.T) {
+	tests := []TestCase{
+		{`containing 1 as "a", done`, true},
+		{`containing 1 as "a", and 2 as "b", done`, true},
+		{`containing 1 as "a" done`, false},            // no comma after the last pair
+		{`containing 1 as "a", 2 as "b", done`, false}, // no `and` before the last pair
+		{`containing 1 as "a", and 2, done`, false},    // not a pair
+		{`containing 1 as, done`, false},
+	}
+	for _, test := range tests {
+		p := parser.NewParser(lexString("TestParseMapLiteral "+test.input, test.input))
+		ok, err, _ := p.Expression()
+		if !ok && test.shouldSucceed {
+			t.Errorf("failed `%v`, got %v", test.input, err)
+		} else if ok && !test.shouldSucceed {
+			t.Errorf("`%v` should not parse", test.input)
+		}
+	}
+}
+
+func TestEvalMaps(t *testing.T) {
+	// from docs/types.md
+	input := `variable HouseNumbers is map of number to string containing 123 as "bob", 345 as "pat", and 420 as "dog", done
+999-th of HouseNumbers is "rich"
+23-th of HouseNumbers is nothing
+123-th of HouseNumbers is "bobby"
+variable Bob is string 123-th from HouseNumbers
+variable Missing is string 1-th from HouseNumbers
+variable Names is map of string to number containing "bob" as 123, "pat" as 345, and "dog" as 420, done
+variable Abc is number "abc"-th from Names
+variable Lists is map of string to list of number containing "a" as containing 1, done, done
+0-th of "a"-th of Lists is 2
+variable Numbers is list of number containing 1, and 2, done
+end of Numbers is 3
+start of Numbers is nothing
+`
+	evaluatingVisitor := evalProgram(t, "TestEvalMaps", input)
+	if evaluatingVisitor == nil {
+		return
+	}
+	if evaluatingVisitor.Err != nil {
+		t.Fatalf("failed evaluating, got %v", evaluatingVisitor.Err)
+	}
+	tests := []struct {
+		name   string
+		result string
+	}{
+		// in the order the keys were added, whatever their values
+		{"HouseNumbers", `{123:"bobby",345:"pat",420:"dog",999:"rich",23:""}`},
+		{"Bob", "bobby"},
+		{"Missing", ""},
+		{"Abc", "0"},
+		{"Lists", `{"a":[2]}`},
+		{"Numbers", "[0,3]"},
+	}
+	for _, test := range tests {
+		if result := evaluatingVisitor.Lookup(test.name).String(); result != test.result {
+			t.Errorf("%v is %v, expected %v", test.name, result, test.result)
+		}
+	}
+	houseNumbers := evaluatingVisitor.Lookup("HouseNumbers")
+	if houseNumbers.TypeName() != "map of number to string" {
+		t.Errorf("HouseNumbers has type %v", houseNumbers.TypeName())
+	}
+	// reading a missing key does not add it
+	if length, _ := houseNumbers.Len(); length != 5 {
+		t.Errorf("HouseNumbers has %d keys, expected 5", length)
+	}
+}
+
+func TestEvalMapEquality(t *testing.T) {
+	a := evaluator.NewMap(evaluator.NT_string, evaluator.NT_number)
+	b := evaluator.NewMap(evaluator.NT_string, evaluator.NT_number)
+	evaluator.SetIndex(a, evaluator.NewString("x"), evaluator.NewNumber(1))
+	evaluator.SetIndex(a, evaluator.NewString("y"), evaluator.NewNumber(2))
+	evaluator.SetIndex(b, evaluator.NewString("y"), evaluator.NewNumber(2))
+	if a.Equals(b) {
+		t.Errorf("%v equals %v", a, b)
+	}
+	evaluator.SetIndex(b, evaluator.NewString("x"), evaluator.NewNumber(1))
+	if !a.Equals(b) {
+		t.Errorf("%v does not equal %v", a, b)
+	}
+}
+
+func TestEvalMapErrors(t *testing.T) {
+	declarations := `variable Names is map of string to number containing "bob" as 123, done
+variable Hello is string "hello"
+`
+	tests := []string{
+		`constant X is number containing "a" as 1, and "a" as 2, done`, // duplicate key
+		`constant X is number containing "a" as 1, and 2 as 2, done`,
+		`constant X is number containing "a" as 1, and "b" as "2", done`,
+		`constant X is number 1-th from Names`,
+		`1-th of Names is 1`,
+		`"pat"-th of Names is "345"`,
+		`0-th of Hello is "j"`,
+		`constant X is number from start to end from Names`,
+		`1-th of Undeclared is 1`,
+	}
+	for _, input := range tests {
+		evaluatingVisitor := evalProgram(t, "TestEvalMapErrors", declarations+input)
+		if evaluatingVisitor != nil && evaluatingVisitor.Err == nil {
+			t.Errorf("`%v` should fail at runtime", input)
+		}
+	}
+}
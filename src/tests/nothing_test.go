@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"nicer-syntax/ast"
+	"nicer-syntax/parser"
+	"testing"
+)
+
+func TestStringNothing(t *testing.T) {
+	tests := []struct {
+		input  string
+		parsed string
+	}{
+		{`nothing`, `nothing`},
+		{`X is nothing`, `NothingCheck(X is nothing)`},
+		{`X is not nothing`, `NothingCheck(X is not nothing)`},
+		{`0-th of X is nothing and Y`, `BinaryExpr(NothingCheck(IndexExpr(0 from X) is nothing) and Y)`},
+		{`not X is nothing`, `UnaryExpr(not NothingCheck(X is nothing))`},
+		{`X == nothing`, `BinaryExpr(X == nothing)`},
+	}
+	for _, test := range tests {
+		p := parser.NewParser(lexString("TestStringNothing "+test.input, test.input))
+		ok, err, node := p.Expression()
+		if !ok {
+			t.Errorf("failed `%v`, got %v", test.input, err)
+			continue
+		}
+		var stringVisitor ast.StringVisitor
+		stringVisitor.Visit(node)
+		if parsed := stringVisitor.String(); parsed != test.parsed {
+			t.Errorf("`%v` parsed as %v, expected %v", test.input, parsed, test.parsed)
+		}
+	}
+}
+
+func TestEvalDefaultValues(t *testing.T) {
+	tests := []struct {
+		input    string
+		value    string
+		typeName string
+	}{
+		{`variable X is number`, "0", "number"},
+		{`variable X is string`, "", "string"},
+		{`variable X is boolean`, "false", "boolean"},
+		{`variable X is list of number`, "[]", "list of number"},
+		{`variable X is map of string to number`, "{}", "map of string to number"},
+		{`type Node is struct containing nothing done
+variable X is Node`, "nothing", "Node"},
+		{`variable X is number nothing`, "0", "number"},
+		{`variable X is list of string nothing`, "[]", "list of string"},
+		{`variable X is number 5
+X is nothing`, "0", "number"},
+		{`variable X is map of number to string containing 1 as "a", done
+1-th of X is nothing`, `{1:""}`, "map of number to string"},
+	}
+	for _, test := range tests {
+		evaluatingVisitor := evalProgram(t, "TestEvalDefaultValues "+test.input, test.input)
+		if evaluatingVisitor == nil {
+			continue
+		}
+		if evaluatingVisitor.Err != nil {
+			t.Errorf("`%v` failed evaluating, got %v", test.input, evaluatingVisitor.Err)
+			continue
+		}
+		val := evaluatingVisitor.Lookup("X")
+		if val.String() != test.value || val.TypeName() != test.typeName {
+			t.Errorf("`%v` gave %v %v, expected %v %v", test.input, val.TypeName(), val, test.typeName, test.value)
+		}
+	}
+}
+
+func TestEvalNothingChecks(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`N is nothing`, true},
+		{`N is not nothing`, false},
+		{`N == nothing`, true},
+		{`N != nothing`, false},
+		{`Zero is nothing`, false}, // a default value is not nothing
+		{`Empty is not nothing`, true},
+		{`Zero == nothing`, false},
+		{`not N is nothing or Zero is nothing`, false},
+	}
+	declarations := `type Node is struct containing nothing done
+variable N is Node
+variable Zero is number
+variable Empty is list of number
+`
+	for _, test := range tests {
+		input := declarations + "variable Result is boolean " + test.input
+		if errs := typeCheck(t, "TestEvalNothingChecks "+test.input, input); len(errs) > 0 {
+			t.Errorf("`%v` failed to type check, got %v", test.input, errs)
+			continue
+		}
+		evaluatingVisitor := evalProgram(t, "TestEvalNothingChecks "+test.input, input)
+		if evaluatingVisitor == nil {
+			continue
+		}
+		if evaluatingVisitor.Err != nil {
+			t.Errorf("`%v` failed evaluating, got %v", test.input, evaluatingVisitor.Err)
+			continue
+		}
+		if result, _ := evaluatingVisitor.Lookup("Result").AsBoolean(); result != test.expected {
+			t.Errorf("`%v` is %v, expected %v", test.input, result, test.expected)
+		}
+	}
+}
+
+func TestNothingErrors(t *testing.T) {
+	tests := []string{
+		`variable X is list of number containing 1, and nothing, done`,
+		`variable X is map of number to string containing 1 as nothing, done`,
+		`variable X is number nothing + 1`,
+	}
+	for _, input := range tests {
+		if errs := typeCheck(t, "TestNothingErrors "+input, input); len(errs) == 0 {
+			t.Errorf("`%v` should not type check", input)
+		}
+		evaluatingVisitor := evalProgram(t, "TestNothingErrors "+input, input)
+		if evaluatingVisitor != nil && evaluatingVisitor.Err == nil {
+			t.Errorf("`%v` should fail at runtime", input)
+		}
+	}
+}
@@ -3,6 +3,8 @@ package tests
 import (
 	"bytes"
 	"fmt"
+	"nicer-syntax/ast"
+	"nicer-syntax/evaluator"
 	"nicer-syntax/lexer"
 	"nicer-syntax/parser"
 	"testing"
@@ -19,6 +21,11 @@ func TestParseRanges(t *testing.T) {
 		{"from Id1 to Id2", true},
 		{"every 2-th from start to end", true},
 		{"every Skip-th from start to end", true},
+		{"every 2-th from start to end of List", true},
+		{"from start of List to end of List", true},
+		{"from 1 to 5 of (List)", true},
+		{"from start of to end", false},
+		{"every 2 from 1 to 5", false},
 	}
 	for _, rangelit := range tests {
 		text := []byte(rangelit.input)
@@ -31,9 +38,160 @@ func TestParseRanges(t *testing.T) {
 
 		p := parser.NewParser(tokens)
 		var err *parser.ParseError
-		_, err = p.RangeLiteral()
+		_, err, _ = p.RangeLiteral()
 		if err != nil && rangelit.shouldSucceed {
 			t.Errorf("failed `%v`, got %v", rangelit.input, err)
 		}
 	}
 }
+
+func TestStringRanges(t *testing.T) {
+	tests := []struct {
+		input  string
+		parsed string
+	}{
+		{"from 1 to 10", "RangeLiteral(from 1 to 10)"},
+		{"every 2-th from start to end of List", "RangeLiteral(every 2-th from start to end of List)"},
+		{"from start of List to end of List", "RangeLiteral(from start of List to end of List)"},
+		{"from 5 to 1 of List", "RangeLiteral(from 5 to 1 of List)"},
+	}
+	for _, rangelit := range tests {
+		p := parser.NewParser(lexString("TestStringRanges "+rangelit.input, rangelit.input))
+		ok, err, node := p.Expression()
+		if !ok {
+			t.Errorf("failed `%v`, got %v", rangelit.input, err)
+			continue
+		}
+		var stringVisitor ast.StringVisitor
+		stringVisitor.Visit(node)
+		if stringVisitor.String() != rangelit.parsed {
+			t.Errorf("`%v` parsed as %v, expected %v", rangelit.input, stringVisitor, rangelit.parsed)
+		}
+	}
+}
+
+func TestEvalRanges(t *testing.T) {
+	declarations := `constant List is list of number containing 0, 1, 1, 2, 3, 5, 8, and 13, done
+constant Empty is list of number containing nothing done
+constant Greeting is string "héllo"
+`
+	tests := []struct {
+		input   string
+		numbers []float64
+	}{
+		{"from 1 to 5", []float64{1, 2, 3, 4, 5}},
+		{"from 5 to 5", []float64{5}},
+		{"from 5 to 1", []float64{5, 4, 3, 2, 1}},
+		{"every 2-th from 0 to 9", []float64{0, 2, 4, 6, 8}},
+		{"every 3-th from 10 to 1", []float64{10, 7, 4, 1}},
+		{"from 1.7 to 4.2", []float64{1, 2, 3, 4}}, // floored
+		{"from -1.5 to 1", []float64{-2, -1, 0, 1}},
+		{"every 2.9-th from 0 to 4", []float64{0, 2, 4}},
+		{"from start to 3", []float64{0, 1, 2, 3}},
+		{"from start to end of List", []float64{0, 1, 2, 3, 4, 5, 6, 7}},
+		{"every 3-th from start to end of List", []float64{0, 3, 6}},
+		{"from start of List to end of List", []float64{0, 1, 2, 3, 4, 5, 6, 7}},
+		{"from 6 to end of List", []float64{6, 7}},
+		{"from end of List to 5", nil},                              // `end` is only a range end
+		{"from start to end of Greeting", []float64{0, 1, 2, 3, 4}}, // by character
+		{"from start to end of Empty", []float64{}},
+		{"from start to end of (from 3 to 5)", []float64{0, 1, 2}},
+	}
+	for _, rangelit := range tests {
+		input := declarations + "constant Range is list of number " + rangelit.input
+		p := parser.NewParser(lexString("TestEvalRanges "+rangelit.input, input))
+		ok, err, program := p.Program()
+		if !ok {
+			if rangelit.numbers != nil {
+				t.Errorf("failed parsing `%v`, got %v", rangelit.input, err)
+			}
+			continue
+		}
+		if rangelit.numbers == nil {
+			t.Errorf("`%v` should not parse", rangelit.input)
+			continue
+		}
+		evaluatingVisitor := ast.NewEvaluatingVisitor()
+		program.Accept(evaluatingVisitor)
+		if evaluatingVisitor.Err != nil {
+			t.Errorf("failed evaluating `%v`, got %v", rangelit.input, evaluatingVisitor.Err)
+			continue
+		}
+		result, isRange := evaluatingVisitor.Lookup("Range").AsRange()
+		if !isRange {
+			t.Errorf("`%v` did not evaluate to a range", rangelit.input)
+			continue
+		}
+		numbers := make([]float64, 0, result.Len())
+		for i := 0; i < result.Len(); i++ {
+			numbers = append(numbers, result.At(i))
+		}
+		if fmt.Sprint(numbers) != fmt.Sprint(rangelit.numbers) {
+			t.Errorf("`%v` evaluated to %v, expected %v", rangelit.input, numbers, rangelit.numbers)
+		}
+	}
+}
+
+func TestEvalRangesAreLazy(t *testing.T) {
+	val, err := evaluator.NewRange(1, 1000000000, 1)
+	if err != nil {
+		t.Fatalf("failed making the range, got %v", err)
+	}
+	r, _ := val.AsRange()
+	if r.Len() != 1000000000 || r.At(999999999) != 1000000000 {
+		t.Errorf("range has %d numbers ending in %v", r.Len(), r.At(r.Len()-1))
+	}
+}
+
+// a bound held in a variable can be bigger than any range, which must fail
+// where the range is written rather than crash the interpreter
+// ranges too long to count, or to put into a list one number at a time
+func TestEvalRangeTooLong(t *testing.T) {
+	tests := []struct {
+		input        string
+		line, column int
+	}{
+		{`constant Big is number 10^300
+constant L is list of number containing from 0 to Big, done`, 2, 41},
+		{`constant L is list of number containing from 1 to 100000000000, done`, 1, 41},
+		{`constant L is list of number containing 1, and every 2-th from 1 to 100000000, done`, 1, 48},
+	}
+	for _, test := range tests {
+		evaluatingVisitor := evalProgram(t, "TestEvalRangeTooLong", test.input)
+		if evaluatingVisitor == nil {
+			continue
+		}
+		if evaluatingVisitor.Err == nil {
+			t.Errorf("`%v` should fail at runtime", test.input)
+			continue
+		}
+		if span := evaluatingVisitor.Err.Span; span.Line != test.line || span.Column != test.column {
+			t.Errorf("`%v` reported at %v, expected line %d, column %d", test.input, span, test.line, test.column)
+		}
+	}
+}
+
+func TestEvalRangeErrors(t *testing.T) {
+	tests := []string{
+		"from 1 to end",          // nothing to end at
+		"from start to end of 5", // numbers have no end
+		"every 0-th from 1 to 10",
+		"from start of true to 10",
+		"every -2-th from 1 to 10",
+		"from 1 to 5 of 5",
+		"from 1 to Undeclared",
+	}
+	for _, input := range tests {
+		p := parser.NewParser(lexString("TestEvalRangeErrors "+input, input))
+		ok, err, node := p.Expression()
+		if !ok {
+			t.Errorf("failed parsing `%v`, got %v", input, err)
+			continue
+		}
+		evaluatingVisitor := ast.NewEvaluatingVisitor()
+		evaluatingVisitor.Visit(node)
+		if evaluatingVisitor.Err == nil {
+			t.Errorf("`%v` should fail at runtime", input)
+		}
+	}
+}
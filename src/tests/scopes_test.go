@@ -0,0 +1,120 @@
+package tests
+
+import (
+	"nicer-syntax/ast"
+	"nicer-syntax/evaluator"
+	"nicer-syntax/parser"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	input := `variable A is number 1
+constant B is number A + 1
+A is B
+`
+	p := parser.NewParser(lexString("TestResolve", input))
+	ok, err, program := p.Program()
+	if !ok {
+		t.Fatalf("failed parsing, got %v", err)
+	}
+	if errs := ast.Resolve(program); len(errs) > 0 {
+		t.Fatalf("failed resolving, got %v", errs)
+	}
+	if program.Slots != 2 {
+		t.Errorf("program has %d slots, expected 2", program.Slots)
+	}
+	a := program.Statements[0].(*ast.VarDecl).VarName
+	b := program.Statements[1].(*ast.ConstDecl).ConstName
+	used := program.Statements[1].(*ast.ConstDecl).Value.(*ast.BinaryExpr).Left.(*ast.Identifier)
+	assignment := program.Statements[2].(*ast.VarAssignment)
+	tests := []struct {
+		id      *ast.Identifier
+		binding *ast.Binding
+		slot    int
+	}{
+		{a, a.Binding, 0},
+		{b, b.Binding, 1},
+		{used, a.Binding, 0},
+		{assignment.Name, a.Binding, 0},
+		{assignment.Value.(*ast.Identifier), b.Binding, 1},
+	}
+	for _, test := range tests {
+		if test.id.Binding == nil || test.id.Binding != test.binding || test.id.Binding.Slot != test.slot || test.id.Depth != 0 {
+			t.Errorf("%v resolved to %#v at depth %d, expected slot %d", test.id.Name, test.id.Binding, test.id.Depth, test.slot)
+		}
+	}
+	if !b.Binding.Constant || a.Binding.Constant {
+		t.Errorf("only B should be constant")
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	tests := []string{
+		"variable A is number 1\nvariable A is number 2",
+		"variable A is number 1\nconstant A is string \"a\"",
+		"variable A is number A",
+		"A is 1\nvariable A is number",
+		"variable A is list of number containing B, done",
+	}
+	for _, input := range tests {
+		p := parser.NewParser(lexString("TestResolveErrors "+input, input))
+		ok, err, program := p.Program()
+		if !ok {
+			t.Errorf("failed parsing `%v`, got %v", input, err)
+			continue
+		}
+		if errs := ast.Resolve(program); len(errs) == 0 {
+			t.Errorf("`%v` should not resolve", input)
+		}
+		evaluatingVisitor := ast.NewEvaluatingVisitor()
+		program.Accept(evaluatingVisitor)
+		if evaluatingVisitor.Err == nil {
+			t.Errorf("`%v` should fail at runtime", input)
+		}
+	}
+}
+
+func TestScopes(t *testing.T) {
+	global := evaluator.NewScope(nil, 2)
+	global.Declare(0, "A", evaluator.NewNumber(1), false)
+	global.Declare(1, "B", evaluator.NewNumber(2), true)
+	inner := evaluator.NewScope(global, 1)
+	inner.Declare(0, "A", evaluator.NewString("shadow"), false)
+
+	tests := []struct {
+		scope               *evaluator.Scope
+		name                string
+		depth, slot         int
+		value               string
+		constant, undefined bool
+	}{
+		{global, "A", 0, 0, "1", false, false},
+		{global, "B", 0, 1, "2", true, false},
+		{inner, "A", 0, 0, "shadow", false, false},
+		{inner, "B", 1, 1, "2", true, false},
+		{inner, "C", 0, 0, "", false, true},
+	}
+	for _, test := range tests {
+		depth, slot, ok := test.scope.Lookup(test.name)
+		if ok == test.undefined {
+			t.Errorf("%v found %v", test.name, ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if depth != test.depth || slot != test.slot {
+			t.Errorf("%v is at %d:%d, expected %d:%d", test.name, depth, slot, test.depth, test.slot)
+		}
+		if val := test.scope.Get(depth, slot).String(); val != test.value {
+			t.Errorf("%v is %v, expected %v", test.name, val, test.value)
+		}
+		if test.scope.IsConstant(depth, slot) != test.constant {
+			t.Errorf("%v should be constant: %v", test.name, test.constant)
+		}
+	}
+	inner.Set(1, 0, evaluator.NewNumber(3))
+	if a := global.Get(0, 0).String(); a != "3" {
+		t.Errorf("setting the outer A from the inner scope left it %v", a)
+	}
+}
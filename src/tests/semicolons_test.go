@@ -0,0 +1,140 @@
+package tests
+
+import (
+	"nicer-syntax/lexer"
+	"nicer-syntax/parser"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/db47h/lex"
+)
+
+func TestLexLineContinuations(t *testing.T) {
+	const (
+		semi   = lexer.ItemSemicolon
+		ident  = lexer.ItemIdent
+		is     = lexer.KW_Is
+		number = lexer.LT_Number
+		comma  = lexer.OP_Comma
+		and    = lexer.KW_And
+	)
+	tests := []struct {
+		input  string
+		tokens []lex.Token
+	}{
+		{"A is 1 +\n    2", []lex.Token{ident, is, number, lexer.OP_Plus, number, semi}},
+		{"A is 1\n+ 2", []lex.Token{ident, is, number, semi, lexer.OP_Plus, number, semi}}, // too late
+		{"A is 1 and # comment\n    B", []lex.Token{ident, is, number, and, ident, semi}},
+		{"A is not\nB", []lex.Token{ident, is, lexer.KW_Not, ident, semi}},
+		{"A is 1 <=\n\n2", []lex.Token{ident, is, number, lexer.OP_LtEq, number, semi}},
+		{"A is (\n1\n+\n2\n)\nB is 3", []lex.Token{ident, is, lexer.OP_Lparen, number, lexer.OP_Plus, number,
+			lexer.OP_Rparen, semi, ident, is, number, semi}},
+		{"containing\n1,\n2,\nand 3,\ndone", []lex.Token{lexer.KW_Containing, number, comma, number, comma,
+			and, number, comma, lexer.KW_Done, semi}},
+		// blank lines are not empty statements
+		{"\n\nA is 1\n\n\nB is 2\n\n", []lex.Token{ident, is, number, semi, ident, is, number, semi}},
+	}
+	for _, test := range tests {
+		tokens := tokenTypes(lexString("TestLexLineContinuations", test.input))
+		if !sameTokenTypes(tokens, test.tokens) {
+			t.Errorf("`%q` lexed as %v, expected %v", test.input, tokens, test.tokens)
+		}
+	}
+}
+
+func TestLexLineContinuationsIndented(t *testing.T) {
+	// continued lines do not open blocks
+	input := "A is 1 +\n        2\nB is (\n    3)\n    C is 4"
+	expected := []lex.Token{
+		lexer.ItemIdent, lexer.KW_Is, lexer.LT_Number, lexer.OP_Plus, lexer.LT_Number, lexer.ItemSemicolon,
+		lexer.ItemIdent, lexer.KW_Is, lexer.OP_Lparen, lexer.LT_Number, lexer.OP_Rparen, lexer.ItemSemicolon,
+		lexer.ItemIndent, lexer.ItemIdent, lexer.KW_Is, lexer.LT_Number, lexer.ItemSemicolon,
+		lexer.ItemDedent, lexer.ItemSemicolon,
+	}
+	tokens := tokenTypes(lexIndented("TestLexLineContinuationsIndented", input))
+	if !sameTokenTypes(tokens, expected) {
+		t.Errorf("`%q` lexed as %v, expected %v", input, tokens, expected)
+	}
+}
+
+func TestParseMultiLineStatements(t *testing.T) {
+	tests := []TestCase{
+		{"variable X is number 1 +\n    2 *\n    3", true},
+		{"variable X is number (1\n    + 2)", true},
+		{"variable X is boolean true and\n    false or\n    true", true},
+		{"variable X is number 1\n    + 2", false},
+	}
+	for _, test := range tests {
+		p := parser.NewParser(lexString("TestParseMultiLineStatements", test.input))
+		_, err, _ := p.Program()
+		if err != nil && test.shouldSucceed {
+			t.Errorf("failed `%q`, got %v", test.input, err)
+		} else if err == nil && !test.shouldSucceed {
+			t.Errorf("`%q` should not parse", test.input)
+		}
+	}
+}
+
+// every sample file: no statement ends on a line that continues, and no
+// statement is empty. The programs parse and type check, and each line of
+// the literal samples is a literal.
+func TestSampleStatements(t *testing.T) {
+	programs, _ := filepath.Glob("../../sample/*.nicer")
+	literals, _ := filepath.Glob("../../sample/literals/*.nicer")
+	if len(programs) == 0 || len(literals) == 0 {
+		t.Fatalf("no sample files found")
+	}
+	for n, name := range append(programs, literals...) {
+		input, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatalf("cannot read %v: %v", name, err)
+		}
+		tokens := lexString(name, string(input))
+		parens := 0
+		for i, token := range tokens {
+			switch token.TokType {
+			case lexer.OP_Lparen:
+				parens++
+			case lexer.OP_Rparen:
+				parens--
+			case lexer.ItemSemicolon:
+				if parens > 0 {
+					t.Errorf("%v: statement ends inside parentheses", token.TokSpan)
+				}
+				if i == 0 {
+					t.Errorf("%v: file starts with an empty statement", token.TokSpan)
+					continue
+				}
+				switch previous := tokens[i-1]; previous.TokType {
+				case lexer.ItemSemicolon:
+					t.Errorf("%v: empty statement", token.TokSpan)
+				case lexer.OP_Comma, lexer.OP_Lparen, lexer.KW_Containing, lexer.KW_And, lexer.KW_Or,
+					lexer.OP_Plus, lexer.OP_Minus, lexer.OP_Star, lexer.OP_Slash, lexer.OP_Caret:
+					t.Errorf("%v: statement ends after %v", token.TokSpan, previous)
+				}
+			}
+		}
+		if last := tokens[len(tokens)-1]; last.TokType != lexer.ItemSemicolon {
+			t.Errorf("%v: last statement is not ended, got %v", name, last)
+		}
+		if n < len(programs) {
+			if errs := typeCheck(t, name, string(input)); len(errs) > 0 {
+				t.Errorf("%v does not type check, got %v", name, errs)
+			}
+			continue
+		}
+		for len(tokens) > 0 {
+			p := parser.NewParser(tokens)
+			if ok, err, _ := p.PrimitiveLiteral(); !ok {
+				t.Errorf("%v: expected a literal, got %v", name, err)
+				break
+			}
+			if len(p.Tokens) == 0 || p.Tokens[0].TokType != lexer.ItemSemicolon {
+				t.Errorf("%v: expected one literal per line", name)
+				break
+			}
+			tokens = p.Tokens[1:]
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"nicer-syntax/ast"
+	"nicer-syntax/lexer"
+	"nicer-syntax/parser"
+	"strings"
+	"testing"
+)
+
+func TestTokenSpans(t *testing.T) {
+	tokens := lexString("spans.nicer", "variable Héllo is number 12.5\n  Héllo is \"héllo\" + -true")
+	expected := []lexer.Span{
+		{File: "spans.nicer", Line: 1, Column: 1, EndLine: 1, EndColumn: 9},   // variable
+		{File: "spans.nicer", Line: 1, Column: 10, EndLine: 1, EndColumn: 16}, // Héllo
+		{File: "spans.nicer", Line: 1, Column: 17, EndLine: 1, EndColumn: 19}, // is
+		{File: "spans.nicer", Line: 1, Column: 20, EndLine: 1, EndColumn: 26}, // number
+		{File: "spans.nicer", Line: 1, Column: 27, EndLine: 1, EndColumn: 31}, // 12.5
+		{File: "spans.nicer", Line: 1, Column: 31, EndLine: 2, EndColumn: 1},  // newline
+		{File: "spans.nicer", Line: 2, Column: 3, EndLine: 2, EndColumn: 9},   // Héllo
+		{File: "spans.nicer", Line: 2, Column: 10, EndLine: 2, EndColumn: 12}, // is
+		{File: "spans.nicer", Line: 2, Column: 13, EndLine: 2, EndColumn: 21}, // "héllo"
+		{File: "spans.nicer", Line: 2, Column: 22, EndLine: 2, EndColumn: 23}, // +
+		{File: "spans.nicer", Line: 2, Column: 24, EndLine: 2, EndColumn: 25}, // -
+		{File: "spans.nicer", Line: 2, Column: 25, EndLine: 2, EndColumn: 29}, // true
+	}
+	for i, span := range expected {
+		if i >= len(tokens) {
+			t.Fatalf("expected %d tokens, got %d", len(expected), len(tokens))
+		}
+		if tokens[i].TokSpan != span {
+			t.Errorf("token %v has span %#v, expected %#v", tokens[i], tokens[i].TokSpan, span)
+		}
+	}
+}
+
+func TestNodeSpans(t *testing.T) {
+	input := "variable X is number 1\nX is (X + 2) * 3"
+	p := parser.NewParser(lexString("nodes.nicer", input))
+	ok, err, program := p.Program()
+	if !ok {
+		t.Fatalf("failed parsing, got %v", err)
+	}
+	assignment := program.Statements[1].(*ast.VarAssignment)
+	product := assignment.Value.(*ast.BinaryExpr)
+	grouped := product.Left.(*ast.GroupedExpr)
+	tests := []struct {
+		node                             ast.Spanned
+		line, column, endLine, endColumn int
+	}{
+		{program, 1, 1, 2, 17},
+		{program.Statements[0].(*ast.VarDecl), 1, 1, 1, 23},
+		{assignment, 2, 1, 2, 17},
+		{product, 2, 6, 2, 17},
+		{grouped, 2, 6, 2, 13},
+		{grouped.Inner.(*ast.BinaryExpr), 2, 7, 2, 12},
+		{product.Right.(*ast.NumberLiteral), 2, 16, 2, 17},
+	}
+	for _, test := range tests {
+		span := test.node.Location()
+		if span.Line != test.line || span.Column != test.column || span.EndLine != test.endLine || span.EndColumn != test.endColumn {
+			t.Errorf("%T has span %d:%d-%d:%d, expected %d:%d-%d:%d", test.node,
+				span.Line, span.Column, span.EndLine, span.EndColumn,
+				test.line, test.column, test.endLine, test.endColumn)
+		}
+	}
+}
+
+func TestErrorLocations(t *testing.T) {
+	p := parser.NewParser(lexString("fib.nicer", "variable X is number 1\nX is (X + ) * 3"))
+	ok, err, _ := p.Program()
+	if ok {
+		t.Fatalf("should not parse")
+	}
+	if !strings.HasPrefix(err.Error(), "fib.nicer:2:11: ") {
+		t.Errorf("parse error is not located at fib.nicer:2:11: %v", err)
+	}
+
+	p = parser.NewParser(lexString("fib.nicer", "variable X is number 1\nX is X + 2 * (X - 1) / 0"))
+	ok, err, program := p.Program()
+	if !ok {
+		t.Fatalf("failed parsing, got %v", err)
+	}
+	evaluatingVisitor := ast.NewEvaluatingVisitor()
+	program.Accept(evaluatingVisitor)
+	if evaluatingVisitor.Err == nil {
+		t.Fatalf("should fail at runtime")
+	}
+	if !strings.HasPrefix(evaluatingVisitor.Err.Error(), "fib.nicer:2:10: ") {
+		t.Errorf("runtime error is not located at fib.nicer:2:10: %v", evaluatingVisitor.Err)
+	}
+}
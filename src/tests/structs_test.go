@@ -0,0 +1,455 @@
+package tests
+
+import (
+	"nicer-syntax/ast"
+	"nicer-syntax/parser"
+	"testing"
+)
+
+func TestStringStructs(t *testing.T) {
+	tests := []struct {
+		input  string
+		parsed string
+	}{
+		{
+			"type Person is struct containing\n    variable Name is string,\n    constant Age is number,\ndone",
+			`Statement(TypeDecl(Person struct containing VarDecl(Name string nothing), ConstDecl(Age number nothing) done))`,
+		},
+		{"type Empty is struct containing nothing done", `Statement(TypeDecl(Empty struct containing nothing done))`},
+		{
+			"type Counter is struct containing\n    variable Count is number,\nand can do\n    function Bump is function, doing\n        Count of this is Count of this + 1\n    done\ndone",
+			`Statement(TypeDecl(Counter struct containing VarDecl(Count number nothing) and can do FunctionDecl(Bump FunctionLiteral(doing Statement(FieldAssignment(FieldExpr(Count of this) BinaryExpr(FieldExpr(Count of this) + 1))))) done))`,
+		},
+		{"Value of Head is 1", `Statement(FieldAssignment(FieldExpr(Value of Head) 1))`},
+		{"Next of Current is Next of Next of Current", `Statement(FieldAssignment(FieldExpr(Next of Current) FieldExpr(Next of FieldExpr(Next of Current))))`},
+		{"0-th of Items of Box is 1", `Statement(IndexAssignment(IndexExpr(0 from FieldExpr(Items of Box)) 1))`},
+		{"do Bump of C", `Statement(FunctionCall(Bump of C))`},
+		{"do Add of C to 1, and 2", `Statement(FunctionCall(Add of C 1, 2))`},
+	}
+	for _, test := range tests {
+		p := parser.NewParser(lexString("TestStringStructs "+test.input, test.input))
+		ok, err, stmt := p.Stmt()
+		if !ok {
+			t.Errorf("failed `%v`, got %v", test.input, err)
+			continue
+		}
+		var stringVisitor ast.StringVisitor
+		stringVisitor.VisitStatement(&stringVisitor, stmt)
+		if parsed := stringVisitor.String(); parsed != test.parsed {
+			t.Errorf("`%v` parsed as %v, expected %v", test.input, parsed, test.parsed)
+		}
+	}
+}
+
+func TestParseStructs(t *testing.T) {
+	tests := []struct {
+		input       string
+		indentation bool
+		succeed     bool
+	}{
+		{"type P is struct containing\n    variable A is number,\ndone", false, true},
+		{"type P is struct containing\n    variable A is number\ndone", false, true},
+		{"type P is struct containing\n    variable A is number,\n    variable B is number,\ndone\nA of X is 1", true, true},
+		{"type P is struct containing\n    variable A is number,\nand can do\n    function F is function, doing\n        nothing\ndone", true, true},
+		{"type P is struct containing\n    variable A is number,", false, false},            // never closed
+		{"type P is struct containing\n    variable A is number 1,\ndone", false, false},    // fields have no value
+		{"type P is struct containing\n    A is number,\ndone", false, false},               // no `variable`
+		{"type P is struct containing\n    variable A,\ndone", false, false},                // no type
+		{"if true, then\n    type P is struct containing nothing done\ndone", false, false}, // only at the top level
+		{"type P is struct containing nothing and can do\n    variable X is number\ndone", false, false},
+		{"variable X is number this", false, false},
+		{"function F is function, doing\n    do PrintLine to this\ndone", false, false},
+		{"A of is 1", false, false},
+	}
+	for _, test := range tests {
+		tokens := lexString("TestParseStructs", test.input)
+		if test.indentation {
+			tokens = lexIndented("TestParseStructs", test.input)
+		}
+		p := parser.NewParser(tokens)
+		p.Indentation = test.indentation
+		ok, err, _ := p.Program()
+		if !ok && test.succeed {
+			t.Errorf("failed `%q`, got %v", test.input, err)
+		} else if ok && !test.succeed {
+			t.Errorf("`%q` should not parse", test.input)
+		}
+	}
+}
+
+const structDeclarations = `type Node is struct containing
+    variable Value is number,
+    variable Next is Node,
+done
+type Counter is struct containing
+    variable Count is number,
+    constant Step is number,
+    variable Seen is list of number,
+and can do
+    function Bump is function, doing
+        Count of this is Count of this + 1
+        do Append to Seen of this, and Count of this
+    done
+    function Get is function, returning number, doing
+        return Count of this
+    done
+    function Add is function, taking number N, returning Counter, doing
+        Count of this is Count of this + N
+        return this
+    done
+done
+`
+
+func TestEvalStructs(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`variable C is Counter where nothing done
+Count of C is 2
+variable Result is number Count of C`, "2"},
+		{`variable Result is Counter where nothing done
+Count of Result is 2`, `{Count:2,Step:0,Seen:[]}`},
+		{`variable Result is boolean
+variable N is Node
+Result is N is nothing`, "true"},
+		{`variable Head is Node where Value is 1 done
+Next of Head is Node where Value is 2 done
+Next of Next of Head is Node where Value is 3 done
+variable Result is number 0
+variable Current is Node Head
+while Current is not nothing, loop
+    Result is Result * 10 + Value of Current
+    Current is Next of Current
+done`, "123"},
+		// structs are references, so changes are seen through every name
+		{`variable A is Counter where nothing done
+Count of A is 1
+variable B is Counter A
+Count of B is 5
+variable Result is number Count of A`, "5"},
+		{`variable A is Counter where nothing done
+Count of A is 1
+function Change is function, taking Counter C, doing
+    Count of C is 9
+done
+do Change to A
+variable Result is number Count of A`, "9"},
+		{`variable C is Counter where nothing done
+Count of C is 0
+do Bump of C
+do Bump of C
+variable Result is number do Get of C`, "2"},
+		{`variable C is Counter where nothing done
+Count of C is 0
+do Bump of C
+do Bump of C
+variable Result is list of number Seen of C`, "[1,2]"},
+		{`variable C is Counter where nothing done
+Count of C is 1
+variable Result is number Count of do Add of C to 10`, "11"},
+		{`variable C is Counter where nothing done
+Count of C is 1
+variable Result is number do Get of do Add of do Add of C to 1 to 2`, "4"},
+		{`variable Counters is list of Counter
+variable C is Counter where nothing done
+Count of C is 7
+do Append to Counters, and C
+variable Result is number Count of 0-th of Counters`, "7"},
+		// the value a field held is not changed by assigning the field again
+		{`variable N is Node where nothing done
+Next of N is Node where Value is 1 done
+variable Second is Node Next of N
+Next of N is nothing
+variable Result is number Value of Second`, "1"},
+		// a constant struct variable keeps its struct, but not its fields
+		{`variable C is Counter where nothing done
+Count of C is 1
+constant K is Counter C
+Count of K is 3
+variable Result is number Count of C`, "3"},
+	}
+	for _, test := range tests {
+		program := structDeclarations + test.input
+		if errs := typeCheck(t, "TestEvalStructs "+test.input, program); len(errs) > 0 {
+			t.Errorf("`%v` failed to type check, got %v", test.input, errs)
+			continue
+		}
+		evaluatingVisitor := evalProgram(t, "TestEvalStructs "+test.input, program)
+		if evaluatingVisitor == nil {
+			continue
+		}
+		if evaluatingVisitor.Err != nil {
+			t.Errorf("`%v` failed evaluating, got %v", test.input, evaluatingVisitor.Err)
+			continue
+		}
+		if result := evaluatingVisitor.Lookup("Result").String(); result != test.expected {
+			t.Errorf("`%v` is %v, expected %v", test.input, result, test.expected)
+		}
+	}
+}
+
+func TestStructErrors(t *testing.T) {
+	tests := []string{
+		`variable C is Counter where nothing done
+Step of C is 2`,
+		`variable C is Counter where nothing done
+Count of C is "two"`,
+		`variable C is Counter where nothing done
+Missing of C is 2`,
+		`variable C is Counter
+variable Result is number Missing of C`,
+		`variable C is Counter
+do Missing of C`,
+		`variable N is number 1
+variable Result is number Count of N`,
+		`variable N is number 1
+do Bump of N`,
+		`variable C is Counter
+variable Result is string Count of C`,
+		`variable D is Counter where nothing done
+Count of D is 1
+constant C is Counter D
+C is D`,
+		`type Node is struct containing nothing done`,
+		`type P is struct containing
+    variable A is number,
+    variable A is string,
+done`,
+		`type P is struct containing
+    variable A is number,
+and can do
+    function F is function, doing
+        nothing
+    done
+    function F is function, doing
+        nothing
+    done
+done`,
+		`type P is struct containing
+    variable A is number,
+and can do
+    function F is function, doing
+        A of this is "a"
+    done
+done
+variable X is P
+do F of X`,
+	}
+	for _, input := range tests {
+		program := structDeclarations + input
+		if errs := typeCheck(t, "TestStructErrors "+input, program); len(errs) == 0 {
+			t.Errorf("`%v` should not type check", input)
+		}
+		evaluatingVisitor := evalProgram(t, "TestStructErrors "+input, program)
+		if evaluatingVisitor != nil && evaluatingVisitor.Err == nil {
+			t.Errorf("`%v` should fail at runtime", input)
+		}
+	}
+}
+
+// mistakes with references to nothing, which only show once the program runs
+func TestStructRuntimeErrors(t *testing.T) {
+	tests := []string{
+		`variable C is Counter
+variable Result is number do Get of C`,
+		`variable N is Node
+variable Result is number Value of N`,
+		`variable N is Node where Value is 1 done
+variable Result is number Value of Next of N`,
+		// a reference to nothing is never given a struct by assigning its fields
+		`variable N is Node
+Value of N is 1`,
+		`variable N is Node where nothing done
+Value of Next of N is 2`,
+		`function Make is function, returning Node, doing
+    variable N is Node
+    return N
+done
+Value of do Make is 1`,
+	}
+	for _, input := range tests {
+		program := structDeclarations + input
+		if errs := typeCheck(t, "TestStructRuntimeErrors "+input, program); len(errs) > 0 {
+			t.Errorf("`%v` failed to type check, got %v", input, errs)
+			continue
+		}
+		evaluatingVisitor := evalProgram(t, "TestStructRuntimeErrors "+input, program)
+		if evaluatingVisitor != nil && evaluatingVisitor.Err == nil {
+			t.Errorf("`%v` should fail at runtime", input)
+		}
+	}
+}
+
+func TestStringStructLiterals(t *testing.T) {
+	tests := []struct {
+		input  string
+		parsed string
+	}{
+		{"variable N is Node where Value is 1, Next is nothing done", `Statement(VarDecl(N Node StructLiteral(Node, Value is 1, Next is nothing)))`},
+		{"variable N is Node where\n    Value is 1,\n    Next is nothing,\ndone", `Statement(VarDecl(N Node StructLiteral(Node, Value is 1, Next is nothing)))`},
+		{"constant P is Pair containing First is 1 + 2 done", `Statement(ConstDecl(P Pair StructLiteral(Pair, First is BinaryExpr(1 + 2))))`},
+		{"variable N is Node where nothing done", `Statement(VarDecl(N Node StructLiteral(Node)))`},
+		// anywhere a value can go, after the type it makes a new struct of
+		{"N is Node where Value is 1 done", `Statement(VarAssignment(N StructLiteral(Node, Value is 1)))`},
+		{"do F to Pair containing First is 1 done", `Statement(FunctionCall(F StructLiteral(Pair, First is 1)))`},
+		{"Next of N is Node of number where nothing done", `Statement(FieldAssignment(FieldExpr(Next of N) StructLiteral(Node of number)))`},
+		{"variable X is number First of (Pair where First is 1 done)", `Statement(VarDecl(X number FieldExpr(First of GroupedExpr(StructLiteral(Pair, First is 1)))))`},
+		// a list's elements are never fields being given values
+		{"variable L is list of boolean containing X is nothing, done", `Statement(VarDecl(L list of boolean ListLiteral(NothingCheck(X is nothing))))`},
+	}
+	for _, test := range tests {
+		p := parser.NewParser(lexString("TestStringStructLiterals "+test.input, test.input))
+		ok, err, stmt := p.Stmt()
+		if !ok {
+			t.Errorf("failed `%v`, got %v", test.input, err)
+			continue
+		}
+		var stringVisitor ast.StringVisitor
+		stringVisitor.VisitStatement(&stringVisitor, stmt)
+		if parsed := stringVisitor.String(); parsed != test.parsed {
+			t.Errorf("`%v` parsed as %v, expected %v", test.input, parsed, test.parsed)
+		}
+	}
+}
+
+func TestParseStructLiterals(t *testing.T) {
+	tests := []struct {
+		input       string
+		indentation bool
+		succeed     bool
+	}{
+		{"variable N is Node where\n    Value is 1,\n    Next is nothing\ndone\nX is 1", false, true},
+		{"variable N is Node where\n    Value is 1,\n    Next is nothing\ndone\nX is 1", true, true},
+		{"if true, then\n    variable N is Node where\n        Value is 1,\n    done\n    X is 1", true, true},
+		{"variable N is Node where Value is 1, Value is 2 done", false, false}, // the same field twice
+		{"variable N is Node where Value is 1", false, false},                  // never closed
+		{"variable N is Node where Value 1 done", false, false},
+		{"variable N is Node where 1 done", false, false},
+		{"variable N is number where Value is 1 done", false, false},
+		{"N is where Value is 1 done", false, false}, // only a declaration says which struct
+		{"N is Node where Value is 1 done", false, true},
+		{"N is Node of T where Value is 1 done", false, true},
+		{"N is Node Value is 1 done", false, false},
+	}
+	for _, test := range tests {
+		tokens := lexString("TestParseStructLiterals", test.input)
+		if test.indentation {
+			tokens = lexIndented("TestParseStructLiterals", test.input)
+		}
+		p := parser.NewParser(tokens)
+		p.Indentation = test.indentation
+		ok, err, _ := p.Program()
+		if !ok && test.succeed {
+			t.Errorf("failed `%q`, got %v", test.input, err)
+		} else if ok && !test.succeed {
+			t.Errorf("`%q` should not parse", test.input)
+		}
+	}
+}
+
+const structLiteralDeclarations = `type Pair is struct containing
+    constant First is number,
+    constant Second is string,
+    variable Tags is list of string,
+done
+`
+
+func TestEvalStructLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`variable Result is Pair where First is 1, Second is "a" done`, `{First:1,Second:"a",Tags:[]}`},
+		{`variable Result is Pair containing Second is "a" done`, `{First:0,Second:"a",Tags:[]}`},
+		{`variable Result is Pair where nothing done`, `{First:0,Second:"",Tags:[]}`},
+		{`variable Result is Pair where Second is "b", First is 2 done`, `{First:2,Second:"b",Tags:[]}`},
+		{`variable Result is Pair where Tags is containing "x", done done`, `{First:0,Second:"",Tags:["x"]}`},
+		{`variable Result is Pair where First is 1, Tags is nothing, done`, `{First:1,Second:"",Tags:[]}`},
+		{`variable Tail is Node where Value is 2 done
+variable Head is Node where
+    Value is 1,
+    Next is Tail,
+done
+variable Result is number Value of Next of Head`, "2"},
+		{`variable Result is Counter where Count is 4 done
+do Bump of Result`, `{Count:5,Step:0,Seen:[5]}`},
+		{`constant Result is Counter where Step is 2 done`, `{Count:0,Step:2,Seen:[]}`},
+		{`variable Result is number do Get of Counter where Count is 3 done`, "3"},
+		{`function Make is function, taking number N, returning Pair, doing
+    return Pair where First is N done
+done
+variable Result is number First of do Make to 5`, "5"},
+		{`variable Result is list of Pair containing Pair where First is 1 done, and Pair containing Second is "b" done, done`, `[{First:1,Second:"",Tags:[]},{First:0,Second:"b",Tags:[]}]`},
+		{`variable Result is Node where Value is 1 done
+Next of Result is Node where Value is 2 done
+variable Two is number Value of Next of Result`, `{Value:1,Next:{Value:2,Next:nothing}}`},
+		// each literal makes a new struct
+		{`variable Result is list of number
+for number I from 1 to 2, loop
+    variable N is Node where Value is I done
+    Value of N is Value of N * 10
+    do Append to Result, and Value of N
+done`, "[10,20]"},
+	}
+	for _, test := range tests {
+		program := structDeclarations + structLiteralDeclarations + test.input
+		if errs := typeCheck(t, "TestEvalStructLiterals "+test.input, program); len(errs) > 0 {
+			t.Errorf("`%v` failed to type check, got %v", test.input, errs)
+			continue
+		}
+		evaluatingVisitor := evalProgram(t, "TestEvalStructLiterals "+test.input, program)
+		if evaluatingVisitor == nil {
+			continue
+		}
+		if evaluatingVisitor.Err != nil {
+			t.Errorf("`%v` failed evaluating, got %v", test.input, evaluatingVisitor.Err)
+			continue
+		}
+		if result := evaluatingVisitor.Lookup("Result").String(); result != test.expected {
+			t.Errorf("`%v` is %v, expected %v", test.input, result, test.expected)
+		}
+	}
+}
+
+func TestStructLiteralErrors(t *testing.T) {
+	tests := []string{
+		`variable P is Pair where Third is 1 done`,
+		`variable P is Pair where First is "one" done`,
+		`variable P is Pair where Tags is containing 1, done done`,
+		`variable P is Pair where First is 1 done
+First of P is 2`,
+		`variable X is Missing where nothing done`,
+		`variable X is number First of (Pair where First is "one" done)`,
+	}
+	for _, input := range tests {
+		program := structDeclarations + structLiteralDeclarations + input
+		if errs := typeCheck(t, "TestStructLiteralErrors "+input, program); len(errs) == 0 {
+			t.Errorf("`%v` should not type check", input)
+		}
+		evaluatingVisitor := evalProgram(t, "TestStructLiteralErrors "+input, program)
+		if evaluatingVisitor != nil && evaluatingVisitor.Err == nil {
+			t.Errorf("`%v` should fail at runtime", input)
+		}
+	}
+}
+
+// mistakes in a literal are reported at the field they are about
+func TestStructLiteralErrorPositions(t *testing.T) {
+	input := structLiteralDeclarations + "variable P is Pair where First is 1, Third is 2 done"
+	errs := typeCheck(t, "TestStructLiteralErrorPositions", input)
+	if len(errs) != 1 {
+		t.Fatalf("expected one type error, got %v", errs)
+	}
+	if span := errs[0].Span; span.Line != 6 || span.Column != 38 {
+		t.Errorf("unknown field reported at %v, expected line 6, column 38", span)
+	}
+	p := parser.NewParser(lexString("TestStructLiteralErrorPositions", "variable P is Pair where First is 1, First is 2 done"))
+	ok, err, _ := p.Program()
+	if ok {
+		t.Fatalf("a field given twice should not parse")
+	}
+	if span := err.Token.TokSpan; span.Line != 1 || span.Column != 38 {
+		t.Errorf("repeated field reported at %v, expected line 1, column 38", span)
+	}
+}
@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"nicer-syntax/parser"
+	"nicer-syntax/typecheck"
+	"strings"
+	"testing"
+)
+
+func typeCheck(t *testing.T, name, input string) []*typecheck.TypeError {
+	p := parser.NewParser(lexString(name, input))
+	ok, err, program := p.Program()
+	if !ok {
+		t.Errorf("failed parsing `%v`, got %v", input, err)
+		return nil
+	}
+	return typecheck.Check(program)
+}
+
+const typeCheckDeclarations = `variable Values is list of number containing 69, 420, and from 1 to 10, done
+variable Names is map of string to number containing "bob" as 123, done
+variable Hello is string "hello"
+variable Flag is boolean
+`
+
+func TestTypeCheck(t *testing.T) {
+	tests := []TestCase{
+		{`variable X is number 1 + 2 * 3`, true},
+		{`variable X is number "hello"`, false},
+		{`variable X is string "a" + "b"`, true},
+		{`variable X is string "a" + 1`, false},
+		{`variable X is boolean 1 < 2 <= 3`, true},
+		{`variable X is boolean 1 < "2"`, false},
+		{`variable X is boolean 1 == 1 and not Flag`, true},
+		{`variable X is boolean 1 and Flag`, false},
+		{`variable X is number -Hello`, false},
+		{`variable X is boolean not 1`, false},
+		{`variable X is boolean Values == Values`, true},
+		{`variable X is boolean Values == Hello`, false},
+		{`variable X is list of number containing nothing done`, true},
+		{`variable X is map of number to string containing nothing done`, true},
+		{`variable X is list of number from 1 to 10`, true},
+		{`variable X is list of string from 1 to 10`, false},
+		{`variable X is list of number containing 1, and "2", done`, false},
+		{`variable X is map of string to number containing "a" as 1, and 2 as 2, done`, false},
+		{`variable X is number 0-th from Values`, true},
+		{`variable X is number "a"-th from Values`, false},
+		{`variable X is number "bob"-th from Names`, true},
+		{`variable X is string "bob"-th from Names`, false},
+		{`variable X is number 0-th from Names`, false},
+		{`variable X is string start of Hello`, true},
+		{`variable X is string 1-th to end from Hello`, true},
+		{`variable X is list of number every 2-th from Values`, true},
+		{`variable X is number 0-th from Flag`, false},
+		{`variable X is list of number from 1 to end of Flag`, false},
+		{`variable X is list of number from Hello to 3`, false},
+		{`Hello is "world"`, true},
+		{`Hello is 1`, false},
+		{`Undeclared is 1`, false},
+		{`variable X is number Undeclared`, false},
+		{`0-th of Values is 1`, true},
+		{`0-th of Values is "1"`, false},
+		{`"pat"-th of Names is 345`, true},
+		{`"pat"-th of Names is nothing`, true},
+		{`"pat"-th of Names is "345"`, false},
+		{`0-th of Hello is "j"`, false},
+	}
+	for _, test := range tests {
+		errs := typeCheck(t, "TestTypeCheck "+test.input, typeCheckDeclarations+test.input)
+		if len(errs) > 0 && test.shouldSucceed {
+			t.Errorf("`%v` failed to type check, got %v", test.input, errs)
+		} else if len(errs) == 0 && !test.shouldSucceed {
+			t.Errorf("`%v` should not type check", test.input)
+		}
+	}
+}
+
+func TestTypeCheckReportsEverything(t *testing.T) {
+	input := `variable X is number "hello"
+variable Y is string 1 + 2
+X is "again"
+variable Z is boolean (-"a") + 1
+`
+	errs := typeCheck(t, "everything.nicer", input)
+	// `-` is reported, but not the `+` it is an operand of
+	expected := []string{"everything.nicer:1:22:", "everything.nicer:2:22:", "everything.nicer:3:6:", "everything.nicer:4:23:", "everything.nicer:4:24:"}
+	if len(errs) != len(expected) {
+		t.Fatalf("expected %d errors, got %v", len(expected), errs)
+	}
+	for i, err := range errs {
+		if !strings.HasPrefix(err.Error(), expected[i]) {
+			t.Errorf("error %d is not located at %v: %v", i, expected[i], err)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package tests
+
+import (
+	"nicer-syntax/ast"
+	"nicer-syntax/parser"
+	"testing"
+)
+
+func TestStringTypes(t *testing.T) {
+	tests := []struct {
+		input  string
+		parsed string
+	}{
+		{"number", "number"},
+		{"list of number", "list of number"},
+		{"list of list of string", "list of list of string"},
+		{"map of string to list of number", "map of string to list of number"},
+		{"map of map of number to boolean to string", "map of map of number to boolean to string"},
+		{"Student", "Student"},
+		{"list of Student", "list of Student"},
+		{"Node of E", "Node of E"},
+		{"Tuple of A and B", "Tuple of A, and B"},
+		{"Tuple of number, and list of string", "Tuple of number, and list of string"},
+		{"Triple of number, string, and boolean", "Triple of number, string, and boolean"},
+		{"function", "function"},
+		{"function, returning string", "function, returning string"},
+		{"function, taking E, and returning boolean", "function, taking E, returning boolean"},
+		{"function, taking number, and string", "function, taking number, and string"},
+		{"function, taking list of E, function, taking E, returning boolean, returning list of E",
+			"function, taking list of E, and function, taking E, returning boolean, returning list of E"},
+	}
+	for _, test := range tests {
+		p := parser.NewParser(lexString("TestStringTypes "+test.input, test.input))
+		ok, err, typeName := p.TypeName()
+		if !ok {
+			t.Errorf("failed `%v`, got %v", test.input, err)
+			continue
+		}
+		if len(p.Tokens) > 1 {
+			t.Errorf("`%v` left %v unparsed", test.input, p.Tokens)
+		}
+		var stringVisitor ast.StringVisitor
+		stringVisitor.Visit(typeName)
+		if stringVisitor.String() != test.parsed {
+			t.Errorf("`%v` parsed as %v, expected %v", test.input, stringVisitor, test.parsed)
+		}
+		if string(typeName.NicerType()) != test.parsed {
+			t.Errorf("`%v` has type %v, expected %v", test.input, typeName.NicerType(), test.parsed)
+		}
+		// and back again
+		p = parser.NewParser(lexString("TestStringTypes "+test.parsed, test.parsed))
+		if ok, err, again := p.TypeName(); !ok || again.NicerType() != typeName.NicerType() {
+			t.Errorf("`%v` does not parse back to the same type, got %v", test.parsed, err)
+		}
+	}
+}
+
+func TestParseTypes(t *testing.T) {
+	tests := []TestCase{
+		{"variable X is list of number", true},
+		{"variable X is map of string to number", true},
+		{"variable X is Node of E", true},
+		{"variable X is function, taking number, returning number", true},
+		{"variable X is list of", false},
+		{"variable X is map of string", false},
+		{"variable X is map of string to", false},
+		{"variable X is Node of", false},
+		{"variable X is function, returning", false},
+		{"variable X is 12", false},
+	}
+	for _, test := range tests {
+		p := parser.NewParser(lexString("TestParseTypes "+test.input, test.input))
+		ok, err, _ := p.VarDecl()
+		if !ok && test.shouldSucceed {
+			t.Errorf("failed `%v`, got %v", test.input, err)
+		} else if ok && !test.shouldSucceed {
+			t.Errorf("`%v` should not parse", test.input)
+		}
+	}
+}
+
+func TestEvalDeclaredTypes(t *testing.T) {
+	input := `variable Names is list of string containing nothing done
+variable Ages is map of string to number containing nothing done
+constant Evens is list of number every 2-th from 0 to 10
+variable Number is number 1
+`
+	evaluatingVisitor := evalProgram(t, "TestEvalDeclaredTypes", input)
+	if evaluatingVisitor == nil {
+		return
+	}
+	if evaluatingVisitor.Err != nil {
+		t.Fatalf("failed evaluating, got %v", evaluatingVisitor.Err)
+	}
+	// empty literals take the declared type
+	tests := []struct {
+		name     string
+		typeName string
+	}{
+		{"Names", "list of string"},
+		{"Ages", "map of string to number"},
+		{"Evens", "range"},
+		{"Number", "number"},
+	}
+	for _, test := range tests {
+		if typeName := evaluatingVisitor.Lookup(test.name).TypeName(); typeName != test.typeName {
+			t.Errorf("%v has type %v, expected %v", test.name, typeName, test.typeName)
+		}
+	}
+}
+
+func TestEvalDeclaredTypeErrors(t *testing.T) {
+	tests := []string{
+		`variable X is number "hello"`,
+		`constant X is string 1`,
+		`constant X is list of string containing 1, done`,
+		`constant X is list of string from 1 to 3`,
+		`constant X is map of string to string containing 1 as "a", done`,
+		`constant X is list of list of number containing 1, done`,
+		`constant X is Student 1`,
+	}
+	for _, input := range tests {
+		evaluatingVisitor := evalProgram(t, "TestEvalDeclaredTypeErrors", input)
+		if evaluatingVisitor != nil && evaluatingVisitor.Err == nil {
+			t.Errorf("`%v` should fail at runtime", input)
+		}
+	}
+}
+
+func TestStringTypeDecls(t *testing.T) {
+	tests := []struct {
+		input  string
+		parsed string
+	}{
+		{"type NumberList is list of number", `Statement(TypeDecl(NumberList list of number))`},
+		{"type AddressBook is map of string to string", `Statement(TypeDecl(AddressBook map of string to string))`},
+		{"type Classroom is list of Student", `Statement(TypeDecl(Classroom list of Student))`},
+		{"type Pairs is list of Tuple of number, and string", `Statement(TypeDecl(Pairs list of Tuple of number, and string))`},
+		{"type Test is function, taking number, returning boolean", `Statement(TypeDecl(Test function, taking number, returning boolean))`},
+	}
+	for _, test := range tests {
+		p := parser.NewParser(lexString("TestStringTypeDecls "+test.input, test.input))
+		ok, err, stmt := p.Stmt()
+		if !ok {
+			t.Errorf("failed `%v`, got %v", test.input, err)
+			continue
+		}
+		var stringVisitor ast.StringVisitor
+		stringVisitor.VisitStatement(&stringVisitor, stmt)
+		if parsed := stringVisitor.String(); parsed != test.parsed {
+			t.Errorf("`%v` parsed as %v, expected %v", test.input, parsed, test.parsed)
+		}
+	}
+}
+
+// types are declared after they are first used, to show that they can be
+const typeDeclarations = `type Classroom is list of Student
+type Student is struct containing
+    variable Name is string,
+    variable Classes is Names,
+done
+type Names is list of string
+type Person is Student
+type AddressBook is map of string to string
+type Test is function, taking number, returning boolean
+type Tree is struct containing
+    variable Children is Forest,
+done
+type Forest is list of Tree
+`
+
+func TestEvalTypeDecls(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`variable N is Names containing "a", and "b", done
+variable Result is string 1-th of N`, "b"},
+		{`variable N is Names containing "a", done
+variable Result is list of string N`, `["a"]`},
+		{`variable Result is Names`, "[]"},
+		{`variable S is Student where Name is "Ann", Classes is containing "maths", done done
+variable C is Classroom containing S, done
+variable Result is string 0-th of Classes of 0-th of C`, "maths"},
+		{`variable P is Person where Name is "Bo" done
+variable S is Student P
+variable Result is string Name of S`, "Bo"},
+		{`variable B is AddressBook containing "bob" as "1 Main St", done
+variable Result is string "bob"-th from B`, "1 Main St"},
+		{`function Positive is function, taking number N, returning boolean, doing
+    return N > 0
+done
+variable P is Test Positive
+variable Result is boolean do P to 3`, "true"},
+		{`variable T is Tree where nothing done
+do Append to Children of T, and T
+variable Result is number 0`, "0"},
+	}
+	for _, test := range tests {
+		program := typeDeclarations + test.input
+		if errs := typeCheck(t, "TestEvalTypeDecls "+test.input, program); len(errs) > 0 {
+			t.Errorf("`%v` failed to type check, got %v", test.input, errs)
+			continue
+		}
+		evaluatingVisitor := evalProgram(t, "TestEvalTypeDecls "+test.input, program)
+		if evaluatingVisitor == nil {
+			continue
+		}
+		if evaluatingVisitor.Err != nil {
+			t.Errorf("`%v` failed evaluating, got %v", test.input, evaluatingVisitor.Err)
+			continue
+		}
+		if result := evaluatingVisitor.Lookup("Result").String(); result != test.expected {
+			t.Errorf("`%v` is %v, expected %v", test.input, result, test.expected)
+		}
+	}
+}
+
+func TestTypeDeclErrors(t *testing.T) {
+	tests := []string{
+		`variable X is Missing`,
+		`variable X is list of Missing`,
+		`variable X is Missing of number`,
+		`function F is function, taking Missing M, doing
+    nothing
+done`,
+		`function F is function, returning Missing, doing
+    nothing
+done`,
+		`type Bad is struct containing
+    variable Items is list of Missing,
+done`,
+		`variable X is Missing where nothing done`,
+		`type Names is list of number`,
+		`type Loop is list of Loop`,
+		`type A is list of B
+type B is map of string to A`,
+		`type C is C`,
+		`variable N is Names containing 1, done`,
+		`variable N is list of number
+variable M is Names N`,
+	}
+	for _, input := range tests {
+		program := typeDeclarations + input
+		if errs := typeCheck(t, "TestTypeDeclErrors "+input, program); len(errs) == 0 {
+			t.Errorf("`%v` should not type check", input)
+		}
+		evaluatingVisitor := evalProgram(t, "TestTypeDeclErrors "+input, program)
+		if evaluatingVisitor != nil && evaluatingVisitor.Err == nil {
+			t.Errorf("`%v` should fail at runtime", input)
+		}
+	}
+}
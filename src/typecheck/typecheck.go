@@ -0,0 +1,841 @@
+// Package typecheck finds type errors in a parsed program before it runs.
+package typecheck
+
+import (
+	"fmt"
+	"nicer-syntax/ast"
+	"nicer-syntax/evaluator"
+	"nicer-syntax/lexer"
+	"sort"
+)
+
+// the type of an expression that cannot be known, or that is already wrong.
+// Nothing is reported about unknown types, so one mistake is reported once.
+const unknown evaluator.NicerType = ""
+
+type TypeError struct {
+	Reason string
+	Span   lexer.Span // where the mistake is in the source
+}
+
+// for interface error.Error()
+func (te *TypeError) Error() string {
+	return fmt.Sprintf("%v: %v %v", te.Span, evaluator.COLOR_ERROR("TYPE ERROR:"), evaluator.COLOR_KEYWORD(te.Reason))
+}
+
+// Checker is an ast.Visitor that checks declarations, assignments, operators
+// and calls against the types they need. Each expression it visits pushes its
+// type onto types.
+type Checker struct {
+	ast.DefaultVisitor
+	types typeStack
+	// the functions whose bodies are being checked, innermost last
+	functions []*ast.FunctionLiteral
+	// the types the program declares
+	declared ast.TypeTable
+	// the constraints on the type parameters of the code being checked
+	constraints []*ast.Constraint
+	// every mistake found
+	Errors []*TypeError
+}
+
+func NewChecker() *Checker {
+	return new(Checker)
+}
+
+// Check returns every type error in program, including names used where they
+// are not declared, in source order, or nothing if it is well-typed.
+// Identifiers are resolved first, as names get their types from the
+// declarations they resolve to.
+func Check(program *ast.Program) []*TypeError {
+	checker := NewChecker()
+	for _, err := range ast.Resolve(program) {
+		checker.report(err.Node, "%s: %s", err.Reason, err.Name)
+	}
+	checker.VisitProgram(checker, program)
+	sort.SliceStable(checker.Errors, func(i, j int) bool {
+		a, b := checker.Errors[i].Span, checker.Errors[j].Span
+		return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+	})
+	return checker.Errors
+}
+
+func (c *Checker) report(node interface{}, format string, args ...interface{}) {
+	c.Errors = append(c.Errors, &TypeError{Reason: fmt.Sprintf(format, args...), Span: ast.SpanOf(node)})
+}
+
+// the type of an expression
+func (c *Checker) typeOf(node ast.Visitable) evaluator.NicerType {
+	c.Visit(node)
+	return c.types.Pop()
+}
+
+// whether a value of type actual can be stored where expected is declared.
+// Empty literals and `nothing` take on any list or map type, `nothing` any
+// other type too, and ranges stand in for lists of numbers.
+func assignable(expected, actual evaluator.NicerType) bool {
+	if expected == unknown || actual == unknown || actual == evaluator.NT_nothing || expected == actual {
+		return true
+	}
+	switch actual {
+	case evaluator.NT_list:
+		_, isList := expected.ElementType()
+		_, _, isMap := expected.KeyValueTypes()
+		return isList || isMap
+	case evaluator.NT_range:
+		return expected == evaluator.ListOf(evaluator.NT_number)
+	}
+	return false
+}
+
+func (c *Checker) Visit(vis ast.Visitable) {
+	switch vis := vis.(type) {
+	case *ast.NumberLiteral:
+		c.VisitNumberLiteral(c, vis)
+	case *ast.BooleanLiteral:
+		c.VisitBooleanLiteral(c, vis)
+	case *ast.StringLiteral:
+		c.VisitStringLiteral(c, vis)
+	case *ast.NothingLiteral:
+		c.VisitNothingLiteral(c, vis)
+	case *ast.Identifier:
+		c.VisitIdentifier(c, vis)
+	case *ast.FunctionCall:
+		c.VisitFunctionCall(c, vis)
+	case *ast.FunctionLiteral:
+		c.VisitFunctionLiteral(c, vis)
+	case *ast.BinaryExpr:
+		c.VisitBinaryExpr(c, vis)
+	case *ast.UnaryExpr:
+		c.VisitUnaryExpr(c, vis)
+	case *ast.ComparisonChain:
+		c.VisitComparisonChain(c, vis)
+	case *ast.NothingCheck:
+		c.VisitNothingCheck(c, vis)
+	case *ast.GroupedExpr:
+		c.VisitGroupedExpr(c, vis)
+	case *ast.ListLiteral:
+		c.VisitListLiteral(c, vis)
+	case *ast.MapLiteral:
+		c.VisitMapLiteral(c, vis)
+	case *ast.StructLiteral:
+		c.VisitStructLiteral(c, vis)
+	case *ast.RangeLiteral:
+		c.VisitRangeLiteral(c, vis)
+	case *ast.IndexExpr:
+		c.VisitIndexExpr(c, vis)
+	case *ast.SliceExpr:
+		c.VisitSliceExpr(c, vis)
+	case *ast.FieldExpr:
+		c.VisitFieldExpr(c, vis)
+	default:
+		c.types.Push(unknown)
+	}
+}
+
+func (c *Checker) VisitNumberLiteral(_ ast.Visitor, nl *ast.NumberLiteral) {
+	c.types.Push(evaluator.NT_number)
+}
+func (c *Checker) VisitBooleanLiteral(_ ast.Visitor, bl *ast.BooleanLiteral) {
+	c.types.Push(evaluator.NT_boolean)
+}
+func (c *Checker) VisitStringLiteral(_ ast.Visitor, sl *ast.StringLiteral) {
+	c.types.Push(evaluator.NT_string)
+}
+func (c *Checker) VisitNothingLiteral(_ ast.Visitor, nl *ast.NothingLiteral) {
+	c.types.Push(evaluator.NT_nothing)
+}
+
+func (c *Checker) VisitIdentifier(_ ast.Visitor, id *ast.Identifier) {
+	if id.Binding == nil { // already reported by the resolver
+		c.types.Push(unknown)
+		return
+	}
+	c.types.Push(id.Binding.Type.NicerType())
+}
+
+// a call used as a value must be to a function that returns one
+func (c *Checker) VisitFunctionCall(_ ast.Visitor, fc *ast.FunctionCall) {
+	returns, hasValue := c.call(fc)
+	if !hasValue {
+		c.report(fc, "%s returns nothing, so it has no value", fc.FuncName.Name)
+	}
+	c.types.Push(returns)
+}
+
+// the type fc returns, and whether it returns anything. Each argument must fit
+// its parameter. A generic function's type parameters are worked out from its
+// arguments, and a method's from the struct it is called on. Built-in
+// functions take anything and return nothing, and names that are not
+// declared are already reported by the resolver.
+func (c *Checker) call(fc *ast.FunctionCall) (evaluator.NicerType, bool) {
+	argTypes := make([]evaluator.NicerType, 0, len(fc.Args))
+	for _, arg := range fc.Args {
+		argTypes = append(argTypes, c.typeOf(arg))
+	}
+	name := fc.FuncName
+	var function *ast.FunctionType
+	bindings := make(map[string]evaluator.NicerType)
+	if fc.Receiver != nil {
+		receiver := c.typeOf(fc.Receiver)
+		if receiver == unknown {
+			return unknown, true
+		}
+		var method *ast.FunctionDecl
+		if st, these, ok := c.declared.Struct(receiver); ok {
+			method, bindings = st.Method(name.Name), these
+		}
+		if method != nil {
+			function = method.Function.Type
+		} else if constraint := c.constraint(receiver, name.Name); constraint != nil {
+			function = constraint.Type
+		} else {
+			c.report(name, "%s has no method %s", receiver, name.Name)
+			return unknown, true
+		}
+	} else if name.Binding == nil {
+		_, builtin := evaluator.BuiltInFunctions[name.Name]
+		return unknown, !builtin
+	} else if t, ok := ast.Underlying(name.Binding.Type).(*ast.FunctionType); ok {
+		function = t
+	} else {
+		c.report(name, "Cannot call %s, which is %s", name.Name, name.Binding.Type.NicerType())
+		return unknown, true
+	}
+	if len(fc.Args) != len(function.Params) {
+		c.report(fc, "%s takes %d arguments, got %d", name.Name, len(function.Params), len(fc.Args))
+		return c.returns(function, nil)
+	}
+	for i, param := range function.Params {
+		ast.Bind(param, argTypes[i], function.TypeParams, bindings)
+	}
+	for _, param := range function.TypeParams {
+		if _, bound := bindings[param.Name]; !bound {
+			c.report(fc, "Cannot tell what %s is when calling %s", param.Name, name.Name)
+			return c.returns(function, nil)
+		}
+	}
+	for _, constraint := range function.Constraints {
+		c.satisfy(fc, name.Name, constraint, bindings)
+	}
+	for i, param := range function.Params {
+		if t := ast.Substitute(param, bindings); !assignable(t, argTypes[i]) {
+			c.report(fc.Args[i], "Cannot pass %s to %s as %s", argTypes[i], name.Name, t)
+		}
+	}
+	return c.returns(function, bindings)
+}
+
+// the type a call to function returns, and whether it returns anything. It
+// is unknown when what its type parameters stand for is, which bindings then
+// leaves out.
+func (c *Checker) returns(function *ast.FunctionType, bindings map[string]evaluator.NicerType) (evaluator.NicerType, bool) {
+	if function.Returns == nil {
+		return unknown, false
+	}
+	if bindings == nil && len(function.TypeParams) > 0 {
+		return unknown, true
+	}
+	return ast.Substitute(function.Returns, bindings), true
+}
+
+// the body is checked along with the function, though it runs later. Inside
+// it, type parameters are types of their own, which only match themselves.
+func (c *Checker) VisitFunctionLiteral(_ ast.Visitor, fl *ast.FunctionLiteral) {
+	outer := c.constraints
+	c.constraints = append(outer[:len(outer):len(outer)], fl.Constraints...)
+	c.checkConstraints(fl.Constraints)
+	for _, param := range fl.Params {
+		c.checkType(param.TypeName)
+	}
+	if fl.Returns != nil {
+		c.checkType(fl.Returns)
+	}
+	c.functions = append(c.functions, fl)
+	for _, stmt := range fl.Body {
+		c.VisitStatement(c, stmt)
+	}
+	c.functions = c.functions[:len(c.functions)-1]
+	c.constraints = outer
+	c.types.Push(fl.Type.NicerType())
+}
+
+func (c *Checker) VisitUnaryExpr(_ ast.Visitor, ue *ast.UnaryExpr) {
+	operand := c.typeOf(ue.Operand)
+	want := evaluator.NT_number
+	if ue.Operator == "not" {
+		want = evaluator.NT_boolean
+	}
+	if operand != unknown && operand != want {
+		c.report(ue, "Cannot apply `%s` to %s", ue.Operator, operand)
+	}
+	c.types.Push(want)
+}
+
+func (c *Checker) VisitBinaryExpr(_ ast.Visitor, be *ast.BinaryExpr) {
+	left := c.typeOf(be.Left)
+	right := c.typeOf(be.Right)
+	c.types.Push(c.binary(be, be.Operator, left, right))
+}
+
+// the type of `left operator right`, following evaluator.ApplyBinary
+func (c *Checker) binary(node interface{}, operator string, left, right evaluator.NicerType) evaluator.NicerType {
+	var result evaluator.NicerType
+	var operands []evaluator.NicerType // the types the operator works on
+	switch operator {
+	case "and", "or":
+		result, operands = evaluator.NT_boolean, []evaluator.NicerType{evaluator.NT_boolean}
+	case "==", "!=":
+		// anything can be compared with nothing
+		if left != unknown && right != unknown && left != right && left != evaluator.NT_nothing && right != evaluator.NT_nothing {
+			c.report(node, "Cannot apply `%s` to %s and %s", operator, left, right)
+		}
+		return evaluator.NT_boolean
+	case ">", ">=", "<", "<=":
+		result, operands = evaluator.NT_boolean, []evaluator.NicerType{evaluator.NT_number, evaluator.NT_string}
+	case "+":
+		operands = []evaluator.NicerType{evaluator.NT_number, evaluator.NT_string}
+	default:
+		result, operands = evaluator.NT_number, []evaluator.NicerType{evaluator.NT_number}
+	}
+	if left == unknown || right == unknown {
+		return result
+	}
+	for _, operand := range operands {
+		if left == operand && right == operand {
+			if result == unknown {
+				return operand
+			}
+			return result
+		}
+	}
+	c.report(node, "Cannot apply `%s` to %s and %s", operator, left, right)
+	return result
+}
+
+func (c *Checker) VisitComparisonChain(_ ast.Visitor, cc *ast.ComparisonChain) {
+	left := c.typeOf(cc.Operands[0])
+	for i, operator := range cc.Operators {
+		right := c.typeOf(cc.Operands[i+1])
+		c.binary(cc, operator, left, right)
+		left = right
+	}
+	c.types.Push(evaluator.NT_boolean)
+}
+
+func (c *Checker) VisitNothingCheck(_ ast.Visitor, nc *ast.NothingCheck) {
+	c.typeOf(nc.Operand)
+	c.types.Push(evaluator.NT_boolean)
+}
+
+func (c *Checker) VisitGroupedExpr(_ ast.Visitor, ge *ast.GroupedExpr) {
+	c.Visit(ge.Inner)
+}
+
+// ranges add numbers to a list, and an empty list has no element type
+func (c *Checker) VisitListLiteral(_ ast.Visitor, ll *ast.ListLiteral) {
+	elementType := unknown
+	for _, element := range ll.Elements {
+		t := c.typeOf(element)
+		if t == evaluator.NT_range {
+			t = evaluator.NT_number
+		} else if t == evaluator.NT_nothing {
+			c.report(element, "Lists cannot contain nothing")
+			continue
+		}
+		if elementType == unknown {
+			elementType = t
+		} else if t != unknown && t != elementType {
+			c.report(element, "List elements must all have the same type, expected %s but got %s", elementType, t)
+		}
+	}
+	if len(ll.Elements) == 0 {
+		c.types.Push(evaluator.NT_list)
+	} else if elementType == unknown {
+		c.types.Push(unknown)
+	} else {
+		c.types.Push(evaluator.ListOf(elementType))
+	}
+}
+
+func (c *Checker) VisitMapLiteral(_ ast.Visitor, ml *ast.MapLiteral) {
+	keyType, valueType := unknown, unknown
+	for i := range ml.Keys {
+		key := c.typeOf(ml.Keys[i])
+		value := c.typeOf(ml.Values[i])
+		if key == evaluator.NT_nothing || value == evaluator.NT_nothing {
+			c.report(ml.Keys[i], "Maps cannot contain nothing")
+			continue
+		}
+		if keyType == unknown {
+			keyType = key
+		} else if !assignable(keyType, key) {
+			c.report(ml.Keys[i], "Keys of %s must be %s, got %s", evaluator.MapOf(keyType, valueType), keyType, key)
+		}
+		if valueType == unknown {
+			valueType = value
+		} else if !assignable(valueType, value) {
+			c.report(ml.Values[i], "Values of %s must be %s, got %s", evaluator.MapOf(keyType, valueType), valueType, value)
+		}
+	}
+	if keyType == unknown || valueType == unknown {
+		c.types.Push(unknown)
+		return
+	}
+	c.types.Push(evaluator.MapOf(keyType, valueType))
+}
+
+// every field given must be one of the struct's, with a value that fits it
+func (c *Checker) VisitStructLiteral(_ ast.Visitor, sl *ast.StructLiteral) {
+	t := sl.Type.NicerType()
+	st, bindings, isStruct := c.declared.Struct(t)
+	if !isStruct {
+		c.report(sl, "%s is not a struct", t)
+	}
+	for i, name := range sl.Fields {
+		val := c.typeOf(sl.Values[i])
+		if !isStruct {
+			continue
+		}
+		typeName, _, ok := st.Field(name.Name)
+		if !ok {
+			c.report(name, "%s has no field %s", t, name.Name)
+		} else if want := ast.Substitute(typeName, bindings); !assignable(want, val) {
+			c.report(sl.Values[i], "Cannot assign %s to %s, which is %s", val, name.Name, want)
+		}
+	}
+	c.types.Push(t)
+}
+
+func (c *Checker) VisitRangeLiteral(_ ast.Visitor, rl *ast.RangeLiteral) {
+	c.checkRange(rl)
+	c.types.Push(evaluator.NT_range)
+}
+
+func (c *Checker) checkRange(rl *ast.RangeLiteral) {
+	for _, bound := range []struct {
+		node ast.Visitable
+		what string
+	}{{rl.Step, "step"}, {rl.Start, "start"}, {rl.End, "end"}} {
+		if bound.node == nil {
+			continue
+		}
+		if t := c.typeOf(bound.node); t != unknown && t != evaluator.NT_number {
+			c.report(bound.node, "The %s must be a number, got %s", bound.what, t)
+		}
+	}
+	for _, of := range []ast.Visitable{rl.StartOf, rl.Of} {
+		if of == nil {
+			continue
+		}
+		if t := c.typeOf(of); t != unknown && !isCollection(t) {
+			c.report(of, "Cannot range over %s", t)
+		}
+	}
+}
+
+func isCollection(t evaluator.NicerType) bool {
+	_, isList := t.ElementType()
+	_, _, isMap := t.KeyValueTypes()
+	return isList || isMap || t == evaluator.NT_list || t == evaluator.NT_range || t == evaluator.NT_string
+}
+
+// the element type of a collection, and the type its indexes must be
+func elementOf(t evaluator.NicerType) (element, index evaluator.NicerType, ok bool) {
+	if element, ok := t.ElementType(); ok {
+		return element, evaluator.NT_number, true
+	}
+	if key, value, ok := t.KeyValueTypes(); ok {
+		return value, key, true
+	}
+	switch t {
+	case evaluator.NT_string:
+		return evaluator.NT_string, evaluator.NT_number, true
+	case evaluator.NT_range:
+		return evaluator.NT_number, evaluator.NT_number, true
+	case evaluator.NT_list:
+		return unknown, evaluator.NT_number, true
+	}
+	return unknown, unknown, false
+}
+
+func (c *Checker) VisitIndexExpr(_ ast.Visitor, ie *ast.IndexExpr) {
+	element, _ := c.indexType(ie)
+	c.types.Push(element)
+}
+
+// the type of the element ie names, and of the collection it is in
+func (c *Checker) indexType(ie *ast.IndexExpr) (evaluator.NicerType, evaluator.NicerType) {
+	index := evaluator.NT_number // `start` and `end`
+	if ie.Index != nil {
+		index = c.typeOf(ie.Index)
+	}
+	collection := c.typeOf(ie.Collection)
+	if collection == unknown {
+		return unknown, unknown
+	}
+	element, want, ok := elementOf(collection)
+	if !ok {
+		c.report(ie, "Cannot index %s", collection)
+		return unknown, collection
+	}
+	if _, _, isMap := collection.KeyValueTypes(); isMap && ie.Index == nil {
+		c.report(ie, "Cannot index %s from its start or end", collection)
+	} else if index != unknown && index != want {
+		if want == evaluator.NT_number {
+			c.report(ie.Index, "Indexes of %s must be numbers, got %s", collection, index)
+		} else {
+			c.report(ie.Index, "Keys of %s must be %s, got %s", collection, want, index)
+		}
+	}
+	return element, collection
+}
+
+// slicing a string gives a string, anything else a list
+func (c *Checker) VisitSliceExpr(_ ast.Visitor, se *ast.SliceExpr) {
+	c.checkRange(se.Range)
+	collection := c.typeOf(se.Collection)
+	element, _, ok := elementOf(collection)
+	_, _, isMap := collection.KeyValueTypes()
+	switch {
+	case collection == unknown:
+		c.types.Push(unknown)
+	case !ok || isMap:
+		c.report(se, "Cannot slice %s", collection)
+		c.types.Push(unknown)
+	case collection == evaluator.NT_string:
+		c.types.Push(evaluator.NT_string)
+	case element == unknown:
+		c.types.Push(evaluator.NT_list)
+	default:
+		c.types.Push(evaluator.ListOf(element))
+	}
+}
+
+// the type of the field fe names, which its object's struct must have
+func (c *Checker) VisitFieldExpr(_ ast.Visitor, fe *ast.FieldExpr) {
+	t, _ := c.fieldType(fe)
+	c.types.Push(t)
+}
+
+// the type of the field fe names, and whether it is constant
+func (c *Checker) fieldType(fe *ast.FieldExpr) (evaluator.NicerType, bool) {
+	object := c.typeOf(fe.Object)
+	if object == unknown {
+		return unknown, false
+	}
+	st, bindings, ok := c.declared.Struct(object)
+	if !ok {
+		c.report(fe, "Cannot get field %s of %s", fe.Field.Name, object)
+		return unknown, false
+	}
+	typeName, constant, ok := st.Field(fe.Field.Name)
+	if !ok {
+		c.report(fe.Field, "%s has no field %s", object, fe.Field.Name)
+		return unknown, false
+	}
+	return ast.Substitute(typeName, bindings), constant
+}
+
+// a generic struct must be given as many type arguments as it has type
+// parameters wherever its name is used as a type, and other types none
+func (c *Checker) checkType(t ast.TypeExpr) {
+	switch t := t.(type) {
+	case *ast.ListType:
+		c.checkType(t.Element)
+	case *ast.MapType:
+		c.checkType(t.Key)
+		c.checkType(t.Value)
+	case *ast.FunctionType:
+		for _, param := range t.Params {
+			c.checkType(param)
+		}
+		if t.Returns != nil {
+			c.checkType(t.Returns)
+		}
+	case *ast.NamedType:
+		if params := c.typeParams(t.Name.Name); len(params) > 0 {
+			c.report(t, "%s needs %d type arguments, like `%s of %s`", t.Name.Name, len(params), t.Name.Name, params[0].Name)
+		}
+	case *ast.GenericType:
+		for _, arg := range t.Args {
+			c.checkType(arg)
+		}
+		decl, ok := c.declared[t.Name.Name]
+		if !ok {
+			return
+		}
+		if params := c.typeParams(t.Name.Name); len(params) != len(t.Args) {
+			c.report(t, "%s takes %d type arguments, got %d", decl.Name.Name, len(params), len(t.Args))
+		} else if st, bindings, ok := c.declared.Struct(t.NicerType()); ok {
+			for _, constraint := range st.Constraints {
+				c.satisfy(t, decl.Name.Name, constraint, bindings)
+			}
+		}
+	}
+}
+
+// the type bindings gives a constraint's parameter must have the method it
+// names, of the type it names once bindings are substituted. generic is the
+// struct or function that node uses, which the constraint belongs to.
+func (c *Checker) satisfy(node ast.Spanned, generic string, constraint *ast.Constraint, bindings map[string]evaluator.NicerType) {
+	t := bindings[constraint.Param.Name]
+	want := ast.Substitute(constraint.Type, bindings)
+	var has evaluator.NicerType
+	if st, these, ok := c.declared.Struct(t); ok {
+		if method := st.Method(constraint.Method.Name); method != nil {
+			has = ast.Substitute(method.Function.Type, these)
+		}
+	} else if own := c.constraint(t, constraint.Method.Name); own != nil {
+		has = own.Type.NicerType()
+	}
+	if t != unknown && has != want {
+		c.report(node, "Cannot use %s as %s of %s, since it cannot do %s as %s", t, constraint.Param.Name, generic, constraint.Method.Name, want)
+	}
+}
+
+// the constraint that lets a value of type t, a type parameter of the code
+// being checked, do method, if there is one
+func (c *Checker) constraint(t evaluator.NicerType, method string) *ast.Constraint {
+	for i := len(c.constraints) - 1; i >= 0; i-- {
+		if constraint := c.constraints[i]; constraint.Param.Name == string(t) && constraint.Method.Name == method {
+			return constraint
+		}
+	}
+	return nil
+}
+
+func (c *Checker) checkConstraints(constraints []*ast.Constraint) {
+	for _, constraint := range constraints {
+		c.checkType(constraint.Type)
+	}
+}
+
+// the type parameters of the type declared as name
+func (c *Checker) typeParams(name string) []*ast.Identifier {
+	if decl, ok := c.declared[name]; ok {
+		if st, ok := decl.Type.(*ast.StructType); ok {
+			return st.TypeParams
+		}
+	}
+	return nil
+}
+
+func (c *Checker) VisitProgram(_ ast.Visitor, p *ast.Program) {
+	c.declared = p.Types
+	for _, stmt := range p.Statements {
+		c.VisitStatement(c, stmt)
+	}
+}
+
+func (c *Checker) VisitStatement(_ ast.Visitor, s ast.Statement) {
+	switch s := s.(type) {
+	case *ast.VarAssignment:
+		c.VisitVarAssignment(c, s)
+	case *ast.IndexAssignment:
+		c.VisitIndexAssignment(c, s)
+	case *ast.FieldAssignment:
+		c.VisitFieldAssignment(c, s)
+	case *ast.FunctionCall:
+		c.call(s)
+	case *ast.ReturnStmt:
+		c.VisitReturnStmt(c, s)
+	case *ast.IfStmt:
+		c.VisitIfStmt(c, s)
+	case *ast.ForLoop:
+		c.VisitForLoop(c, s)
+	case *ast.WhileLoop:
+		c.VisitWhileLoop(c, s)
+	case ast.Declaration:
+		c.VisitDeclaration(c, s)
+	}
+}
+
+func (c *Checker) VisitDeclaration(_ ast.Visitor, d ast.Declaration) {
+	switch d := d.(type) {
+	case *ast.VarDecl:
+		c.VisitVarDecl(c, d)
+	case *ast.ConstDecl:
+		c.VisitConstDecl(c, d)
+	case *ast.FunctionDecl:
+		c.VisitFunctionDecl(c, d)
+	case *ast.TypeDecl:
+		c.VisitTypeDecl(c, d)
+	}
+}
+
+func (c *Checker) VisitFunctionDecl(_ ast.Visitor, fd *ast.FunctionDecl) {
+	c.typeOf(fd.Function)
+}
+
+// a struct's fields and methods are checked like any other declarations, as
+// is the type that any other type is declared as
+func (c *Checker) VisitTypeDecl(_ ast.Visitor, td *ast.TypeDecl) {
+	st, ok := td.Type.(*ast.StructType)
+	if !ok {
+		c.checkType(td.Type)
+		return
+	}
+	c.constraints = st.Constraints
+	c.checkConstraints(st.Constraints)
+	for _, d := range st.Fields {
+		switch d := d.(type) {
+		case *ast.VarDecl:
+			c.checkType(d.TypeName)
+		case *ast.ConstDecl:
+			c.checkType(d.TypeName)
+		}
+	}
+	for _, method := range st.Methods {
+		c.VisitFunctionDecl(c, method)
+	}
+	c.constraints = nil
+}
+
+// a function returns a value of its return type, or no value if it has none
+func (c *Checker) VisitReturnStmt(_ ast.Visitor, rs *ast.ReturnStmt) {
+	if len(c.functions) == 0 { // the parser allows no such program
+		return
+	}
+	returns := c.functions[len(c.functions)-1].Returns
+	switch {
+	case returns == nil && rs.Value != nil:
+		c.typeOf(rs.Value)
+		c.report(rs.Value, "Cannot return a value from a function that returns nothing")
+	case returns != nil && rs.Value == nil:
+		c.report(rs, "`return` needs a value of type %s", returns.NicerType())
+	case returns != nil:
+		if t := c.typeOf(rs.Value); !assignable(returns.NicerType(), t) {
+			c.report(rs.Value, "Cannot return %s from a function returning %s", t, returns.NicerType())
+		}
+	}
+}
+
+func (c *Checker) VisitIfStmt(_ ast.Visitor, is *ast.IfStmt) {
+	c.condition(is.Condition)
+	c.block(is.Then)
+	if is.Else != nil {
+		c.block(is.Else)
+	}
+}
+
+// the variable must hold what the loop goes through: numbers for a range,
+// elements for a list, characters for a string and keys for a map
+func (c *Checker) VisitForLoop(_ ast.Visitor, fl *ast.ForLoop) {
+	source := c.typeOf(fl.Source)
+	element, ok := source.IterationType()
+	c.checkType(fl.Variable.TypeName)
+	declared := fl.Variable.TypeName.NicerType()
+	switch {
+	case source == unknown:
+	case !ok:
+		c.report(fl.Source, "Cannot loop over %s", source)
+	case !assignable(declared, element):
+		c.report(fl.Variable, "Cannot loop over %s with %s %s", source, declared, fl.Variable.Name.Name)
+	}
+	c.block(fl.Body)
+}
+
+func (c *Checker) VisitWhileLoop(_ ast.Visitor, wl *ast.WhileLoop) {
+	c.condition(wl.Condition)
+	c.block(wl.Body)
+}
+
+// conditions are only ever boolean; nothing else counts as true or false
+func (c *Checker) condition(node ast.Visitable) {
+	if t := c.typeOf(node); t != unknown && t != evaluator.NT_boolean {
+		c.report(node, "The condition must be boolean, got %s", t)
+	}
+}
+
+func (c *Checker) block(b *ast.Block) {
+	for _, stmt := range b.Statements {
+		c.VisitStatement(c, stmt)
+	}
+}
+
+func (c *Checker) VisitVarDecl(_ ast.Visitor, vd *ast.VarDecl) {
+	c.declare(vd.VarName, vd.TypeName, vd.Value)
+}
+
+func (c *Checker) VisitConstDecl(_ ast.Visitor, cd *ast.ConstDecl) {
+	c.declare(cd.ConstName, cd.TypeName, cd.Value)
+}
+
+// the value, if any, must fit the declared type
+func (c *Checker) declare(name *ast.Identifier, typeName ast.TypeExpr, val ast.Visitable) {
+	c.checkType(typeName)
+	declared := typeName.NicerType()
+	if val != nil {
+		if t := c.typeOf(val); !assignable(declared, t) {
+			c.report(val, "Cannot declare %s as %s with %s", name.Name, declared, t)
+		}
+	}
+}
+
+func (c *Checker) VisitVarAssignment(_ ast.Visitor, va *ast.VarAssignment) {
+	target := c.typeOf(va.Name)
+	if va.Name.Binding != nil && va.Name.Binding.Constant {
+		c.report(va, "Cannot assign to constant %s", va.Name.Name)
+	}
+	if t := c.typeOf(va.Value); !assignable(target, t) {
+		c.report(va.Value, "Cannot assign %s to %s, which is %s", t, va.Name.Name, target)
+	}
+}
+
+// assigning nothing stores the default value, which always fits
+func (c *Checker) VisitIndexAssignment(_ ast.Visitor, ia *ast.IndexAssignment) {
+	if name := rootName(ia.Target); name != nil && name.Binding != nil && name.Binding.Constant {
+		c.report(ia.Target, "Cannot change an element of constant %s", name.Name)
+	}
+	target, collection := c.indexType(ia.Target)
+	if collection == evaluator.NT_string || collection == evaluator.NT_range {
+		c.report(ia.Target, "Cannot assign to an element of %s", collection)
+		return
+	}
+	if t := c.typeOf(ia.Value); !assignable(target, t) {
+		c.report(ia.Value, "Cannot assign %s to an element of type %s", t, target)
+	}
+}
+
+// constant fields keep the value they were first given
+func (c *Checker) VisitFieldAssignment(_ ast.Visitor, fa *ast.FieldAssignment) {
+	target, constant := c.fieldType(fa.Target)
+	if constant {
+		c.report(fa.Target, "Cannot assign to constant field %s", fa.Target.Field.Name)
+	}
+	if t := c.typeOf(fa.Value); !assignable(target, t) {
+		c.report(fa.Value, "Cannot assign %s to %s, which is %s", t, fa.Target.Field.Name, target)
+	}
+}
+
+// the name an element is taken from, like Nested in `0-th of 1-th of Nested`,
+// if it is taken from one
+func rootName(ie *ast.IndexExpr) *ast.Identifier {
+	switch collection := ie.Collection.(type) {
+	case *ast.Identifier:
+		return collection
+	case *ast.IndexExpr:
+		return rootName(collection)
+	case *ast.GroupedExpr:
+		if inner, ok := collection.Inner.(*ast.IndexExpr); ok {
+			return rootName(inner)
+		}
+		if inner, ok := collection.Inner.(*ast.Identifier); ok {
+			return inner
+		}
+	}
+	return nil
+}
+
+type typeStack []evaluator.NicerType
+
+func (ts *typeStack) Push(t evaluator.NicerType) {
+	*ts = append(*ts, t)
+}
+
+func (ts *typeStack) Pop() evaluator.NicerType {
+	t := (*ts)[len(*ts)-1]
+	*ts = (*ts)[:len(*ts)-1]
+	return t
+}